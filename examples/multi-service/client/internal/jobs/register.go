@@ -2,11 +2,11 @@ package jobs
 
 import "github.com/HorseArcher567/octopus/pkg/assemble"
 
-func Register(target, apiURL string) assemble.Domain {
+func Register() assemble.Domain {
 	return func(ctx *assemble.DomainContext) error {
-		if err := registerRPCJobs(ctx, target); err != nil {
+		if err := registerRPCJobs(ctx); err != nil {
 			return err
 		}
-		return registerHTTPJobs(ctx, apiURL)
+		return registerHTTPJobs(ctx)
 	}
 }
@@ -8,24 +8,31 @@ import (
 	"github.com/HorseArcher567/octopus/examples/multi-service/proto/pb"
 	"github.com/HorseArcher567/octopus/pkg/assemble"
 	"github.com/HorseArcher567/octopus/pkg/job"
-	"github.com/HorseArcher567/octopus/pkg/rpc"
+	"github.com/HorseArcher567/octopus/pkg/store"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
-func registerRPCJobs(ctx *assemble.DomainContext, target string) error {
+// RPCClientName is the name of the configured rpcClients entry used by the
+// RPC scenarios.
+const RPCClientName = "demo"
+
+func registerRPCJobs(ctx *assemble.DomainContext) error {
 	baseLog := ctx.Logger()
+	conn, err := store.GetNamed[*grpc.ClientConn](ctx, RPCClientName)
+	if err != nil {
+		return fmt.Errorf("rpc client %q: %w", RPCClientName, err)
+	}
 
 	jobs := map[string]job.Func{
 		"rpc.user_flow": func(runCtx *job.Context) error {
-			return runRPCUserFlow(runCtx.Context(), preferJobLog(runCtx.Logger(), baseLog), target)
+			return runRPCUserFlow(runCtx.Context(), preferJobLog(runCtx.Logger(), baseLog), conn)
 		},
 		"rpc.order_flow": func(runCtx *job.Context) error {
-			return runRPCOrderFlow(runCtx.Context(), preferJobLog(runCtx.Logger(), baseLog), target)
+			return runRPCOrderFlow(runCtx.Context(), preferJobLog(runCtx.Logger(), baseLog), conn)
 		},
 		"rpc.product_flow": func(runCtx *job.Context) error {
-			return runRPCProductFlow(runCtx.Context(), preferJobLog(runCtx.Logger(), baseLog), target)
+			return runRPCProductFlow(runCtx.Context(), preferJobLog(runCtx.Logger(), baseLog), conn)
 		},
 	}
 
@@ -37,13 +44,7 @@ func registerRPCJobs(ctx *assemble.DomainContext, target string) error {
 	return nil
 }
 
-func runRPCUserFlow(ctx context.Context, log *xlog.Logger, target string) error {
-	conn, err := newRPCClient(log, target)
-	if err != nil {
-		return fmt.Errorf("new rpc client: %w", err)
-	}
-	defer conn.Close()
-
+func runRPCUserFlow(ctx context.Context, log *xlog.Logger, conn *grpc.ClientConn) error {
 	userClient := pb.NewUserClient(conn)
 	username, email := uniqueUser("rpc_user")
 
@@ -60,13 +61,7 @@ func runRPCUserFlow(ctx context.Context, log *xlog.Logger, target string) error
 	return nil
 }
 
-func runRPCOrderFlow(ctx context.Context, log *xlog.Logger, target string) error {
-	conn, err := newRPCClient(log, target)
-	if err != nil {
-		return fmt.Errorf("new rpc client: %w", err)
-	}
-	defer conn.Close()
-
+func runRPCOrderFlow(ctx context.Context, log *xlog.Logger, conn *grpc.ClientConn) error {
 	userClient := pb.NewUserClient(conn)
 	orderClient := pb.NewOrderClient(conn)
 	username, email := uniqueUser("rpc_order_user")
@@ -82,13 +77,7 @@ func runRPCOrderFlow(ctx context.Context, log *xlog.Logger, target string) error
 	return nil
 }
 
-func runRPCProductFlow(ctx context.Context, log *xlog.Logger, target string) error {
-	conn, err := newRPCClient(log, target)
-	if err != nil {
-		return fmt.Errorf("new rpc client: %w", err)
-	}
-	defer conn.Close()
-
+func runRPCProductFlow(ctx context.Context, log *xlog.Logger, conn *grpc.ClientConn) error {
 	productClient := pb.NewProductClient(conn)
 	if _, err := productClient.ListProducts(ctx, &pb.ListProductsRequest{Page: 1, PageSize: 10}); err != nil {
 		return fmt.Errorf("ListProducts: %w", err)
@@ -97,11 +86,6 @@ func runRPCProductFlow(ctx context.Context, log *xlog.Logger, target string) err
 	return nil
 }
 
-func newRPCClient(log *xlog.Logger, target string) (*grpc.ClientConn, error) {
-	_ = log
-	return rpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
-}
-
 func uniqueUser(prefix string) (string, string) {
 	suffix := time.Now().UnixNano()
 	username := fmt.Sprintf("%s_%d", prefix, suffix)
@@ -13,13 +13,11 @@ import (
 
 func main() {
 	configPath := flag.String("config", "config.yaml", "配置文件路径")
-	target := flag.String("target", "etcd:///multi-service-demo", "RPC target")
-	apiURL := flag.String("api", "http://127.0.0.1:8090", "API base URL")
 	flag.Parse()
 
 	a, err := assemble.Load(
 		*configPath,
-		assemble.WithDomains(jobs.Register(*target, *apiURL)),
+		assemble.WithDomains(jobs.Register()),
 	)
 	if err != nil {
 		panic(err)
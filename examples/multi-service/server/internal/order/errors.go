@@ -1,8 +1,8 @@
 package order
 
-import "errors"
+import "github.com/HorseArcher567/octopus/pkg/errors"
 
 var (
-	ErrNotFound        = errors.New("not found")
-	ErrInvalidArgument = errors.New("invalid argument")
+	ErrNotFound        = errors.New(errors.NotFound, "order not found").WithReason("ORDER_NOT_FOUND")
+	ErrInvalidArgument = errors.New(errors.InvalidArgument, "invalid argument").WithReason("ORDER_INVALID_ARGUMENT")
 )
@@ -2,12 +2,9 @@ package order
 
 import (
 	"context"
-	"errors"
 
 	"github.com/HorseArcher567/octopus/examples/multi-service/proto/pb"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type GRPCHandler struct {
@@ -26,7 +23,7 @@ func (h *GRPCHandler) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*p
 	order, err := h.svc.GetByID(ctx, req.OrderId)
 	if err != nil {
 		log.Error("get order failed", "error", err)
-		return nil, mapGRPCError(err, "order not found")
+		return nil, err
 	}
 	return &pb.GetOrderResponse{OrderId: order.OrderID, UserId: order.UserID, ProductName: order.ProductName, Amount: order.Amount, Status: order.Status}, nil
 }
@@ -37,20 +34,7 @@ func (h *GRPCHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderReques
 	id, err := h.svc.Create(ctx, req.UserId, req.ProductName, req.Amount)
 	if err != nil {
 		log.Error("create order failed", "error", err)
-		return nil, mapGRPCError(err, "failed to create order")
+		return nil, err
 	}
 	return &pb.CreateOrderResponse{OrderId: id, Message: "Order created successfully"}, nil
 }
-
-func mapGRPCError(err error, notFoundMsg string) error {
-	if err == nil {
-		return nil
-	}
-	if errors.Is(err, ErrNotFound) {
-		return status.Error(codes.NotFound, notFoundMsg)
-	}
-	if errors.Is(err, ErrInvalidArgument) {
-		return status.Error(codes.InvalidArgument, err.Error())
-	}
-	return status.Error(codes.Internal, err.Error())
-}
@@ -1,7 +1,6 @@
 package order
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
@@ -31,9 +30,6 @@ type createOrderResponse struct {
 	OrderID int64  `json:"order_id"`
 	Message string `json:"message"`
 }
-type errorResponse struct {
-	Error string `json:"error"`
-}
 
 func NewHTTPHandler(svc *Service, log *xlog.Logger) *HTTPHandler {
 	return &HTTPHandler{svc: svc, log: log}
@@ -47,7 +43,7 @@ func RegisterHTTP(engine *api.Engine, h *HTTPHandler) {
 func (h *HTTPHandler) GetOrder(c *gin.Context) {
 	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid order id"})
+		api.WriteError(c, ErrInvalidArgument.WithMessage("invalid order id"))
 		return
 	}
 	ctx := c.Request.Context()
@@ -56,7 +52,7 @@ func (h *HTTPHandler) GetOrder(c *gin.Context) {
 	order, err := h.svc.GetByID(ctx, orderID)
 	if err != nil {
 		log.Error("get order failed", "error", err)
-		writeHTTPError(c, err, "order not found")
+		api.WriteError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, getOrderResponse{OrderID: order.OrderID, UserID: order.UserID, ProductName: order.ProductName, Amount: order.Amount, Status: order.Status})
@@ -65,7 +61,7 @@ func (h *HTTPHandler) GetOrder(c *gin.Context) {
 func (h *HTTPHandler) CreateOrder(c *gin.Context) {
 	var req createOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		api.WriteError(c, ErrInvalidArgument.WithMessage("invalid request body"))
 		return
 	}
 	ctx := c.Request.Context()
@@ -74,22 +70,8 @@ func (h *HTTPHandler) CreateOrder(c *gin.Context) {
 	id, err := h.svc.Create(ctx, req.UserID, req.ProductName, req.Amount)
 	if err != nil {
 		log.Error("create order failed", "error", err)
-		writeHTTPError(c, err, "failed to create order")
+		api.WriteError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, createOrderResponse{OrderID: id, Message: "Order created successfully"})
 }
-
-func writeHTTPError(c *gin.Context, err error, notFoundMsg string) {
-	if err == nil {
-		return
-	}
-	switch {
-	case errors.Is(err, ErrNotFound):
-		c.JSON(http.StatusNotFound, errorResponse{Error: notFoundMsg})
-	case errors.Is(err, ErrInvalidArgument):
-		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
-	default:
-		c.JSON(http.StatusInternalServerError, errorResponse{Error: err.Error()})
-	}
-}
@@ -2,7 +2,6 @@ package order
 
 import (
 	"context"
-	"fmt"
 	"strings"
 )
 
@@ -16,13 +15,13 @@ func (s *Service) GetByID(ctx context.Context, id int64) (*Order, error) {
 
 func (s *Service) Create(ctx context.Context, userID int64, productName string, amount float64) (int64, error) {
 	if userID <= 0 {
-		return 0, fmt.Errorf("user_id must be positive: %w", ErrInvalidArgument)
+		return 0, ErrInvalidArgument.WithMessage("user_id must be positive")
 	}
 	if strings.TrimSpace(productName) == "" {
-		return 0, fmt.Errorf("product_name is required: %w", ErrInvalidArgument)
+		return 0, ErrInvalidArgument.WithMessage("product_name is required")
 	}
 	if amount <= 0 {
-		return 0, fmt.Errorf("amount must be positive: %w", ErrInvalidArgument)
+		return 0, ErrInvalidArgument.WithMessage("amount must be positive")
 	}
 	return s.repo.Create(ctx, &Order{UserID: userID, ProductName: productName, Amount: amount, Status: "pending"})
 }
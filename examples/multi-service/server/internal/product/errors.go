@@ -1,8 +1,8 @@
 package product
 
-import "errors"
+import "github.com/HorseArcher567/octopus/pkg/errors"
 
 var (
-	ErrNotFound        = errors.New("not found")
-	ErrInvalidArgument = errors.New("invalid argument")
+	ErrNotFound        = errors.New(errors.NotFound, "product not found").WithReason("PRODUCT_NOT_FOUND")
+	ErrInvalidArgument = errors.New(errors.InvalidArgument, "invalid argument").WithReason("PRODUCT_INVALID_ARGUMENT")
 )
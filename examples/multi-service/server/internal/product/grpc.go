@@ -2,12 +2,9 @@ package product
 
 import (
 	"context"
-	"errors"
 
 	"github.com/HorseArcher567/octopus/examples/multi-service/proto/pb"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type GRPCHandler struct {
@@ -25,7 +22,7 @@ func (h *GRPCHandler) GetProduct(ctx context.Context, req *pb.GetProductRequest)
 	product, err := h.svc.GetByID(ctx, req.ProductId)
 	if err != nil {
 		log.Error("get product failed", "error", err)
-		return nil, mapGRPCError(err, "product not found")
+		return nil, err
 	}
 	return &pb.GetProductResponse{ProductId: product.ProductID, Name: product.Name, Description: product.Description, Price: product.Price, Stock: int32(product.Stock)}, nil
 }
@@ -36,7 +33,7 @@ func (h *GRPCHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequ
 	products, total, err := h.svc.List(ctx, req.Page, req.PageSize)
 	if err != nil {
 		log.Error("list products failed", "error", err)
-		return nil, mapGRPCError(err, "failed to list products")
+		return nil, err
 	}
 	resp := make([]*pb.GetProductResponse, 0, len(products))
 	for _, p := range products {
@@ -44,16 +41,3 @@ func (h *GRPCHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequ
 	}
 	return &pb.ListProductsResponse{Products: resp, Total: int32(total)}, nil
 }
-
-func mapGRPCError(err error, notFoundMsg string) error {
-	if err == nil {
-		return nil
-	}
-	if errors.Is(err, ErrNotFound) {
-		return status.Error(codes.NotFound, notFoundMsg)
-	}
-	if errors.Is(err, ErrInvalidArgument) {
-		return status.Error(codes.InvalidArgument, err.Error())
-	}
-	return status.Error(codes.Internal, err.Error())
-}
@@ -1,7 +1,6 @@
 package product
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
@@ -25,9 +24,6 @@ type listProductsResponse struct {
 	Products []getProductResponse `json:"products"`
 	Total    int32                `json:"total"`
 }
-type errorResponse struct {
-	Error string `json:"error"`
-}
 
 func NewHTTPHandler(svc *Service, log *xlog.Logger) *HTTPHandler {
 	return &HTTPHandler{svc: svc, log: log}
@@ -40,7 +36,7 @@ func RegisterHTTP(engine *api.Engine, h *HTTPHandler) {
 func (h *HTTPHandler) GetProduct(c *gin.Context) {
 	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid product id"})
+		api.WriteError(c, ErrInvalidArgument.WithMessage("invalid product id"))
 		return
 	}
 	ctx := c.Request.Context()
@@ -49,7 +45,7 @@ func (h *HTTPHandler) GetProduct(c *gin.Context) {
 	product, err := h.svc.GetByID(ctx, productID)
 	if err != nil {
 		log.Error("get product failed", "error", err)
-		writeHTTPError(c, err, "product not found")
+		api.WriteError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, getProductResponse{ProductID: product.ProductID, Name: product.Name, Description: product.Description, Price: product.Price, Stock: int32(product.Stock)})
@@ -58,12 +54,12 @@ func (h *HTTPHandler) GetProduct(c *gin.Context) {
 func (h *HTTPHandler) ListProducts(c *gin.Context) {
 	page, err := parseQueryInt32(c.Query("page"), 1)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid page"})
+		api.WriteError(c, ErrInvalidArgument.WithMessage("invalid page"))
 		return
 	}
 	pageSize, err := parseQueryInt32(c.Query("page_size"), 10)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid page_size"})
+		api.WriteError(c, ErrInvalidArgument.WithMessage("invalid page_size"))
 		return
 	}
 	ctx := c.Request.Context()
@@ -72,7 +68,7 @@ func (h *HTTPHandler) ListProducts(c *gin.Context) {
 	products, total, err := h.svc.List(ctx, page, pageSize)
 	if err != nil {
 		log.Error("list products failed", "error", err)
-		writeHTTPError(c, err, "failed to list products")
+		api.WriteError(c, err)
 		return
 	}
 	resp := make([]getProductResponse, 0, len(products))
@@ -92,17 +88,3 @@ func parseQueryInt32(value string, defaultValue int32) (int32, error) {
 	}
 	return int32(parsed), nil
 }
-
-func writeHTTPError(c *gin.Context, err error, notFoundMsg string) {
-	if err == nil {
-		return
-	}
-	switch {
-	case errors.Is(err, ErrNotFound):
-		c.JSON(http.StatusNotFound, errorResponse{Error: notFoundMsg})
-	case errors.Is(err, ErrInvalidArgument):
-		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
-	default:
-		c.JSON(http.StatusInternalServerError, errorResponse{Error: err.Error()})
-	}
-}
@@ -1,8 +1,8 @@
 package user
 
-import "errors"
+import "github.com/HorseArcher567/octopus/pkg/errors"
 
 var (
-	ErrNotFound        = errors.New("not found")
-	ErrInvalidArgument = errors.New("invalid argument")
+	ErrNotFound        = errors.New(errors.NotFound, "user not found").WithReason("USER_NOT_FOUND")
+	ErrInvalidArgument = errors.New(errors.InvalidArgument, "invalid argument").WithReason("USER_INVALID_ARGUMENT")
 )
@@ -2,12 +2,9 @@ package user
 
 import (
 	"context"
-	"errors"
 
 	"github.com/HorseArcher567/octopus/examples/multi-service/proto/pb"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type GRPCHandler struct {
@@ -27,7 +24,7 @@ func (h *GRPCHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.
 	user, err := h.svc.GetByID(ctx, req.UserId)
 	if err != nil {
 		log.Error("get user failed", "error", err)
-		return nil, mapGRPCError(err, "user not found")
+		return nil, err
 	}
 	return &pb.GetUserResponse{UserId: user.ID, Username: user.Username, Email: user.Email}, nil
 }
@@ -39,20 +36,7 @@ func (h *GRPCHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 	id, err := h.svc.Create(ctx, req.Username, req.Email)
 	if err != nil {
 		log.Error("create user failed", "error", err)
-		return nil, mapGRPCError(err, "failed to create user")
+		return nil, err
 	}
 	return &pb.CreateUserResponse{UserId: id, Message: "User created successfully"}, nil
 }
-
-func mapGRPCError(err error, notFoundMsg string) error {
-	if err == nil {
-		return nil
-	}
-	if errors.Is(err, ErrNotFound) {
-		return status.Error(codes.NotFound, notFoundMsg)
-	}
-	if errors.Is(err, ErrInvalidArgument) {
-		return status.Error(codes.InvalidArgument, err.Error())
-	}
-	return status.Error(codes.Internal, err.Error())
-}
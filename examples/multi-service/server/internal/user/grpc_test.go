@@ -5,33 +5,32 @@ import (
 	"fmt"
 	"testing"
 
+	octoerrors "github.com/HorseArcher567/octopus/pkg/errors"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
-func TestMapGRPCErrorNil(t *testing.T) {
-	if got := mapGRPCError(nil, "not found"); got != nil {
-		t.Fatalf("expected nil, got %v", got)
-	}
-}
-func TestMapGRPCErrorNotFound(t *testing.T) {
-	err := fmt.Errorf("wrapped: %w", ErrNotFound)
-	st := status.Convert(mapGRPCError(err, "resource missing"))
-	if st.Code() != codes.NotFound || st.Message() != "resource missing" {
+func TestGRPCStatusNotFound(t *testing.T) {
+	err := fmt.Errorf("user 7: %w", ErrNotFound)
+	st := octoerrors.ToStatus(err)
+	if st.Code() != codes.NotFound || st.Message() != "user not found" {
 		t.Fatalf("unexpected status: code=%s msg=%q", st.Code(), st.Message())
 	}
+	if !errors.Is(octoerrors.FromStatus(st), ErrNotFound) {
+		t.Fatalf("decoded status does not match ErrNotFound")
+	}
 }
-func TestMapGRPCErrorInvalidArgument(t *testing.T) {
-	err := fmt.Errorf("bad input: %w", ErrInvalidArgument)
-	st := status.Convert(mapGRPCError(err, "unused"))
-	if st.Code() != codes.InvalidArgument || st.Message() != err.Error() {
+
+func TestGRPCStatusInvalidArgument(t *testing.T) {
+	err := ErrInvalidArgument.WithMessage("email is required")
+	st := octoerrors.ToStatus(err)
+	if st.Code() != codes.InvalidArgument || st.Message() != "email is required" {
 		t.Fatalf("unexpected status: code=%s msg=%q", st.Code(), st.Message())
 	}
 }
-func TestMapGRPCErrorInternalFallback(t *testing.T) {
-	err := errors.New("db down")
-	st := status.Convert(mapGRPCError(err, "unused"))
-	if st.Code() != codes.Internal || st.Message() != err.Error() {
+
+func TestGRPCStatusInternalHidesCause(t *testing.T) {
+	st := octoerrors.ToStatus(errors.New("db down"))
+	if st.Code() != codes.Internal || st.Message() != "internal error" {
 		t.Fatalf("unexpected status: code=%s msg=%q", st.Code(), st.Message())
 	}
 }
@@ -1,7 +1,6 @@
 package user
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
@@ -31,10 +30,6 @@ type createUserResponse struct {
 	Message string `json:"message"`
 }
 
-type errorResponse struct {
-	Error string `json:"error"`
-}
-
 func NewHTTPHandler(svc *Service, log *xlog.Logger) *HTTPHandler {
 	return &HTTPHandler{svc: svc, log: log}
 }
@@ -47,7 +42,7 @@ func RegisterHTTP(engine *api.Engine, h *HTTPHandler) {
 func (h *HTTPHandler) GetUser(c *gin.Context) {
 	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid user id"})
+		api.WriteError(c, ErrInvalidArgument.WithMessage("invalid user id"))
 		return
 	}
 
@@ -58,7 +53,7 @@ func (h *HTTPHandler) GetUser(c *gin.Context) {
 	user, err := h.svc.GetByID(ctx, userID)
 	if err != nil {
 		log.Error("get user failed", "error", err)
-		writeHTTPError(c, err, "user not found")
+		api.WriteError(c, err)
 		return
 	}
 
@@ -68,7 +63,7 @@ func (h *HTTPHandler) GetUser(c *gin.Context) {
 func (h *HTTPHandler) CreateUser(c *gin.Context) {
 	var req createUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		api.WriteError(c, ErrInvalidArgument.WithMessage("invalid request body"))
 		return
 	}
 
@@ -79,23 +74,9 @@ func (h *HTTPHandler) CreateUser(c *gin.Context) {
 	id, err := h.svc.Create(ctx, req.Username, req.Email)
 	if err != nil {
 		log.Error("create user failed", "error", err)
-		writeHTTPError(c, err, "failed to create user")
+		api.WriteError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, createUserResponse{UserID: id, Message: "User created successfully"})
 }
-
-func writeHTTPError(c *gin.Context, err error, notFoundMsg string) {
-	if err == nil {
-		return
-	}
-	switch {
-	case errors.Is(err, ErrNotFound):
-		c.JSON(http.StatusNotFound, errorResponse{Error: notFoundMsg})
-	case errors.Is(err, ErrInvalidArgument):
-		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
-	default:
-		c.JSON(http.StatusInternalServerError, errorResponse{Error: err.Error()})
-	}
-}
@@ -2,7 +2,6 @@ package user
 
 import (
 	"context"
-	"fmt"
 	"strings"
 )
 
@@ -20,10 +19,10 @@ func (s *Service) GetByID(ctx context.Context, id int64) (*User, error) {
 
 func (s *Service) Create(ctx context.Context, username, email string) (int64, error) {
 	if strings.TrimSpace(username) == "" {
-		return 0, fmt.Errorf("username is required: %w", ErrInvalidArgument)
+		return 0, ErrInvalidArgument.WithMessage("username is required")
 	}
 	if strings.TrimSpace(email) == "" {
-		return 0, fmt.Errorf("email is required: %w", ErrInvalidArgument)
+		return 0, ErrInvalidArgument.WithMessage("email is required")
 	}
 	return s.repo.Create(ctx, &User{Username: username, Email: email})
 }
@@ -2,7 +2,14 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/authz"
+	"github.com/HorseArcher567/octopus/pkg/loadshed"
+	"github.com/HorseArcher567/octopus/pkg/ratelimit"
+	"github.com/HorseArcher567/octopus/pkg/xtls"
 )
 
 // ServerConfig 是 HTTP API 服务器配置。
@@ -18,6 +25,9 @@ import (
 //	readTimeout: 5s
 //	writeTimeout: 10s
 //	idleTimeout: 60s
+//	tls:
+//	  certFile: /etc/tls/tls.crt
+//	  keyFile: /etc/tls/tls.key
 type ServerConfig struct {
 	// Logger is the name of the logger to use for the API server.
 	// If empty, the app logger will be used.
@@ -46,6 +56,94 @@ type ServerConfig struct {
 
 	// EnablePProf 是否启用 pprof 路由。
 	EnablePProf bool `yaml:"enablePProf" json:"enablePProf" toml:"enablePProf"`
+
+	// TLS 启用 HTTPS；配置 CAFile 时校验客户端证书（双向 TLS）。
+	// 证书文件变更后自动重新加载。为 nil 时使用明文 HTTP。
+	TLS *xtls.Config `yaml:"tls" json:"tls" toml:"tls"`
+
+	// H2C 是否在明文连接上同时支持 HTTP/2（h2c）。不能与 TLS 同时启用，
+	// 启用 TLS 时通过 ALPN 自动协商 HTTP/2。
+	H2C bool `yaml:"h2c" json:"h2c" toml:"h2c"`
+
+	// Gateway 启用 HTTP/JSON 转码网关，将带 google.api.http 注解的 gRPC 方法
+	// 以 REST 路由对外提供，并在进程内调用 RPC 服务。为 nil 时不启用。
+	Gateway *GatewayConfig `yaml:"gateway" json:"gateway" toml:"gateway"`
+
+	// Connect 以 gRPC-Web 和 Connect 协议对外提供 RPC 服务，路由为
+	// POST /<服务全名>/<方法名>，并在进程内调用 RPC 服务。为 nil 时不启用。
+	Connect *ConnectConfig `yaml:"connect" json:"connect" toml:"connect"`
+
+	// Auth 使用 JWT、API Key 或客户端证书认证请求，并将认证主体写入请求
+	// context 和请求日志。为 nil 时不认证。
+	Auth *auth.Config `yaml:"auth" json:"auth" toml:"auth"`
+
+	// Authz 按路由、角色和请求属性授权已认证的请求，拒绝时返回 403，匿名
+	// 请求返回 401。健康检查不受限制。为 nil 时不授权。
+	Authz *authz.Config `yaml:"authz" json:"authz" toml:"authz"`
+
+	// RateLimit 按路由和客户端限制请求速率，规则匹配路由模板（如
+	// GET /v1/users/:id）。为 nil 时不限流。
+	RateLimit *ratelimit.Config `yaml:"rateLimit" json:"rateLimit" toml:"rateLimit"`
+
+	// LoadShed 按自适应并发上限拒绝超出的请求（503）。健康检查、pprof 和
+	// /admin 路由不会被拒绝。为 nil 时不启用。
+	LoadShed *loadshed.Config `yaml:"loadShed" json:"loadShed" toml:"loadShed"`
+}
+
+// GatewayConfig 是 HTTP/JSON 转码网关配置，主要控制 protojson 编解码选项。
+//
+// 示例配置:
+//
+//	gateway:
+//	  services: [multi.User]
+//	  useProtoNames: true
+//	  emitUnpopulated: true
+type GatewayConfig struct {
+	// Services 限定对外提供的 gRPC 服务全名。为空时提供所有带注解的服务。
+	Services []string `yaml:"services" json:"services" toml:"services"`
+
+	// EmitUnpopulated 输出未赋值的字段（零值）。
+	EmitUnpopulated bool `yaml:"emitUnpopulated" json:"emitUnpopulated" toml:"emitUnpopulated"`
+
+	// UseProtoNames 使用 proto 字段名（如 user_id）而非 lowerCamelCase 名称。
+	UseProtoNames bool `yaml:"useProtoNames" json:"useProtoNames" toml:"useProtoNames"`
+
+	// UseEnumNumbers 以数值而非名称输出枚举。
+	UseEnumNumbers bool `yaml:"useEnumNumbers" json:"useEnumNumbers" toml:"useEnumNumbers"`
+
+	// DiscardUnknown 忽略请求中的未知字段，否则返回 INVALID_ARGUMENT。
+	DiscardUnknown bool `yaml:"discardUnknown" json:"discardUnknown" toml:"discardUnknown"`
+}
+
+// ConnectConfig 是 gRPC-Web 和 Connect 协议配置。
+//
+// 示例配置:
+//
+//	connect:
+//	  services: [multi.User]
+//	  protocols: [grpc-web]
+//	  maxMessageSize: 1048576
+type ConnectConfig struct {
+	// Services 限定对外提供的 gRPC 服务全名。为空时提供所有已注册的服务。
+	Services []string `yaml:"services" json:"services" toml:"services"`
+
+	// Protocols 启用的协议: connect / grpc-web。为空时全部启用。
+	Protocols []string `yaml:"protocols" json:"protocols" toml:"protocols"`
+
+	// MaxMessageSize 请求体的最大字节数，默认 4MiB。
+	MaxMessageSize int `yaml:"maxMessageSize" json:"maxMessageSize" toml:"maxMessageSize"`
+}
+
+func (c *ConnectConfig) Validate() error {
+	for _, p := range c.Protocols {
+		if p != ProtocolConnect && p != ProtocolGRPCWeb {
+			return fmt.Errorf("unknown connect protocol %q", p)
+		}
+	}
+	if c.MaxMessageSize < 0 {
+		return errors.New("connect maxMessageSize cannot be negative")
+	}
+	return nil
 }
 
 func (c *ServerConfig) Validate() error {
@@ -57,5 +155,44 @@ func (c *ServerConfig) Validate() error {
 		return errors.New("server port is required")
 	}
 
+	if c.TLS != nil {
+		if c.H2C {
+			return errors.New("server h2c cannot be combined with tls")
+		}
+		if err := c.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Connect != nil {
+		if err := c.Connect.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Auth != nil {
+		if err := c.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Authz != nil {
+		if err := c.Authz.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.LoadShed != nil {
+		if err := c.LoadShed.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
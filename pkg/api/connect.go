@@ -0,0 +1,681 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Protocols served by the Connect handler, as listed in
+// ConnectConfig.Protocols.
+const (
+	ProtocolConnect = "connect"
+	ProtocolGRPCWeb = "grpc-web"
+)
+
+const defaultConnectMaxMessageSize = 4 << 20
+
+// Envelope flags of gRPC-Web and Connect streaming messages.
+const (
+	flagCompressed     = 0x01
+	flagEndStream      = 0x02 // Connect end-of-stream message
+	flagGRPCWebTrailer = 0x80
+)
+
+// connectHandler serves gRPC-Web and Connect requests by forwarding the
+// serialized messages to the backend over its local connection.
+type connectHandler struct {
+	backend  RPCBackend
+	services []string
+	connect  bool
+	grpcWeb  bool
+	maxSize  int
+	conn     *grpc.ClientConn
+}
+
+// rpcMethod describes a method served by the Connect handler.
+type rpcMethod struct {
+	name          string // full method name, e.g. /pkg.Service/Method
+	clientStreams bool
+	serverStreams bool
+}
+
+func newConnectHandler(config *ConnectConfig, backend RPCBackend) *connectHandler {
+	if config == nil {
+		config = &ConnectConfig{}
+	}
+	h := &connectHandler{
+		backend:  backend,
+		services: config.Services,
+		connect:  len(config.Protocols) == 0 || slices.Contains(config.Protocols, ProtocolConnect),
+		grpcWeb:  len(config.Protocols) == 0 || slices.Contains(config.Protocols, ProtocolGRPCWeb),
+		maxSize:  config.MaxMessageSize,
+	}
+	if h.maxSize == 0 {
+		h.maxSize = defaultConnectMaxMessageSize
+	}
+	return h
+}
+
+func (h *connectHandler) protocols() []string {
+	var protocols []string
+	if h.connect {
+		protocols = append(protocols, ProtocolConnect)
+	}
+	if h.grpcWeb {
+		protocols = append(protocols, ProtocolGRPCWeb)
+	}
+	return protocols
+}
+
+// init registers a POST route for every method of the backend's services.
+// It runs when the server starts, after all services are registered.
+func (h *connectHandler) init(engine *gin.Engine) (int, error) {
+	conn, err := h.backend.LocalConn()
+	if err != nil {
+		return 0, fmt.Errorf("api: connect: %w", err)
+	}
+	h.conn = conn
+
+	services := h.backend.GetServiceInfo()
+	routes := 0
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		if len(h.services) > 0 && !slices.Contains(h.services, name) {
+			continue
+		}
+		for _, m := range services[name].Methods {
+			method := rpcMethod{
+				name:          "/" + name + "/" + m.Name,
+				clientStreams: m.IsClientStream,
+				serverStreams: m.IsServerStream,
+			}
+			engine.POST(method.name, func(c *gin.Context) { h.serve(c, method) })
+			routes++
+		}
+	}
+	return routes, nil
+}
+
+// serve dispatches a request on its content type.
+func (h *connectHandler) serve(c *gin.Context, m rpcMethod) {
+	contentType, _, _ := strings.Cut(c.GetHeader("Content-Type"), ";")
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	switch {
+	case h.grpcWeb && (contentType == "application/grpc-web" || contentType == "application/grpc-web+proto"):
+		h.serveGRPCWeb(c, m, contentType, false)
+	case h.grpcWeb && (contentType == "application/grpc-web-text" || contentType == "application/grpc-web-text+proto"):
+		h.serveGRPCWeb(c, m, contentType, true)
+	case h.connect && !m.clientStreams && !m.serverStreams && (contentType == "application/proto" || contentType == "application/json"):
+		h.serveConnectUnary(c, m, contentType)
+	case h.connect && (contentType == "application/connect+proto" || contentType == "application/connect+json"):
+		h.serveConnectStream(c, m, contentType)
+	default:
+		c.AbortWithStatus(http.StatusUnsupportedMediaType)
+	}
+}
+
+// serveGRPCWeb serves the gRPC-Web protocol. The status and trailers are
+// sent in a trailer frame after the response messages.
+func (h *connectHandler) serveGRPCWeb(c *gin.Context, m rpcMethod, contentType string, text bool) {
+	w := c.Writer
+	writeFrame := func(flags byte, data []byte) {
+		frame := appendEnvelope(nil, flags, data)
+		if text {
+			frame = []byte(base64.StdEncoding.EncodeToString(frame))
+		}
+		_, _ = w.Write(frame)
+		w.Flush()
+	}
+	wroteHeader := false
+	writeHeader := func(md metadata.MD) {
+		if wroteHeader {
+			return
+		}
+		wroteHeader = true
+		writeMetadataHeaders(w.Header(), md, "")
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	var trailer metadata.MD
+	requests, err := h.readGRPCWebRequest(c.Request, text)
+	if err == nil {
+		ctx, cancel := requestContext(c.Request, grpcWebTimeout(c.Request.Header))
+		defer cancel()
+		trailer, err = h.call(ctx, m, requests, writeHeader, func(msg []byte) error {
+			writeFrame(0, msg)
+			return nil
+		})
+	}
+	writeHeader(nil)
+	if err != nil {
+		_ = c.Error(err)
+	}
+	writeFrame(flagGRPCWebTrailer, grpcWebTrailer(errors.ToStatus(err), trailer))
+}
+
+func (h *connectHandler) readGRPCWebRequest(r *http.Request, text bool) ([][]byte, error) {
+	body, err := h.readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if text {
+		if body, err = decodeBase64Chunks(body); err != nil {
+			return nil, errors.Newf(errors.InvalidArgument, "invalid grpc-web-text body: %v", err)
+		}
+	}
+	return h.readEnvelopes(body, r.Header.Get("Grpc-Encoding"))
+}
+
+// serveConnectUnary serves a unary call of the Connect protocol: the body is
+// the request message, and errors are returned as JSON with the HTTP status
+// of their code.
+func (h *connectHandler) serveConnectUnary(c *gin.Context, m rpcMethod, contentType string) {
+	codec, err := newMessageCodec(m, contentType == "application/json")
+	var request []byte
+	if err == nil {
+		request, err = h.readBody(c.Request)
+	}
+	if err == nil {
+		request, err = h.decompress(request, c.GetHeader("Content-Encoding"))
+	}
+	if err == nil {
+		request, err = codec.request(request)
+	}
+
+	var header, trailer metadata.MD
+	var response []byte
+	if err == nil {
+		ctx, cancel := requestContext(c.Request, connectTimeout(c.Request.Header))
+		defer cancel()
+		trailer, err = h.call(ctx, m, [][]byte{request},
+			func(md metadata.MD) { header = md },
+			func(msg []byte) error {
+				response = msg
+				return nil
+			})
+	}
+	if err == nil {
+		response, err = codec.response(response)
+	}
+
+	writeMetadataHeaders(c.Writer.Header(), header, "")
+	writeMetadataHeaders(c.Writer.Header(), trailer, "Trailer-")
+	if err != nil {
+		_ = c.Error(err)
+		st := errors.ToStatus(err)
+		c.Abort()
+		c.Data(errors.CodeFromGRPC(st.Code()).HTTPStatus(), "application/json", mustMarshalJSON(newConnectError(st)))
+		return
+	}
+	c.Data(http.StatusOK, contentType, response)
+}
+
+// serveConnectStream serves a streaming call of the Connect protocol. The
+// status and trailers are sent in an end-of-stream message.
+func (h *connectHandler) serveConnectStream(c *gin.Context, m rpcMethod, contentType string) {
+	w := c.Writer
+	wroteHeader := false
+	writeHeader := func(md metadata.MD) {
+		if wroteHeader {
+			return
+		}
+		wroteHeader = true
+		writeMetadataHeaders(w.Header(), md, "")
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	codec, err := newMessageCodec(m, contentType == "application/connect+json")
+	var requests [][]byte
+	if err == nil {
+		requests, err = h.readConnectStreamRequest(c.Request, codec)
+	}
+	var trailer metadata.MD
+	if err == nil {
+		ctx, cancel := requestContext(c.Request, connectTimeout(c.Request.Header))
+		defer cancel()
+		trailer, err = h.call(ctx, m, requests, writeHeader, func(msg []byte) error {
+			out, err := codec.response(msg)
+			if err != nil {
+				return err
+			}
+			_, _ = w.Write(appendEnvelope(nil, 0, out))
+			w.Flush()
+			return nil
+		})
+	}
+	writeHeader(nil)
+
+	end := connectEndStream{Metadata: map[string][]string{}}
+	for k, v := range trailer {
+		if !isReservedMetadata(k) {
+			end.Metadata[k] = encodeMetadataValues(k, v)
+		}
+	}
+	if err != nil {
+		_ = c.Error(err)
+		end.Error = newConnectError(errors.ToStatus(err))
+	}
+	_, _ = w.Write(appendEnvelope(nil, flagEndStream, mustMarshalJSON(end)))
+	w.Flush()
+}
+
+func (h *connectHandler) readConnectStreamRequest(r *http.Request, codec messageCodec) ([][]byte, error) {
+	body, err := h.readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := h.readEnvelopes(body, r.Header.Get("Connect-Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	for i, msg := range requests {
+		if requests[i], err = codec.request(msg); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+// call invokes m with the serialized requests over the local connection.
+// onHeader receives the response header metadata before the first message.
+func (h *connectHandler) call(ctx context.Context, m rpcMethod, requests [][]byte, onHeader func(metadata.MD), onMessage func([]byte) error) (metadata.MD, error) {
+	desc := &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+	stream, err := h.conn.NewStream(ctx, desc, m.name, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range requests {
+		// On failure the status is returned by RecvMsg.
+		if err := stream.SendMsg(&req); err != nil {
+			break
+		}
+	}
+	_ = stream.CloseSend()
+
+	header, err := stream.Header()
+	if err == nil {
+		onHeader(header)
+	}
+	for {
+		var msg []byte
+		if err := stream.RecvMsg(&msg); err != nil {
+			if err == io.EOF {
+				return stream.Trailer(), nil
+			}
+			return stream.Trailer(), err
+		}
+		if err := onMessage(msg); err != nil {
+			return stream.Trailer(), err
+		}
+	}
+}
+
+// readBody reads the request body up to the maximum message size.
+func (h *connectHandler) readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(h.maxSize)+1))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "read request body")
+	}
+	if len(body) > h.maxSize {
+		return nil, errors.Newf(errors.ResourceExhausted, "request body exceeds %d bytes", h.maxSize)
+	}
+	return body, nil
+}
+
+// readEnvelopes splits data into length-prefixed messages, decompressing
+// them with encoding when flagged.
+func (h *connectHandler) readEnvelopes(data []byte, encoding string) ([][]byte, error) {
+	var messages [][]byte
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return nil, errors.New(errors.InvalidArgument, "truncated message envelope")
+		}
+		flags, size := data[0], binary.BigEndian.Uint32(data[1:5])
+		if uint64(size) > uint64(len(data)-5) {
+			return nil, errors.New(errors.InvalidArgument, "truncated message envelope")
+		}
+		msg := data[5 : 5+size]
+		data = data[5+size:]
+		if flags&flagEndStream != 0 {
+			break
+		}
+		if flags&flagCompressed != 0 {
+			var err error
+			if msg, err = h.decompress(msg, encoding); err != nil {
+				return nil, err
+			}
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// decompress supports the identity and gzip encodings.
+func (h *connectHandler) decompress(data []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", "identity":
+		return data, nil
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.Wrap(err, errors.InvalidArgument, "invalid gzip message")
+		}
+		out, err := io.ReadAll(io.LimitReader(r, int64(h.maxSize)+1))
+		if err != nil {
+			return nil, errors.Wrap(err, errors.InvalidArgument, "invalid gzip message")
+		}
+		if len(out) > h.maxSize {
+			return nil, errors.Newf(errors.ResourceExhausted, "message exceeds %d bytes", h.maxSize)
+		}
+		return out, nil
+	}
+	return nil, errors.Newf(errors.Unimplemented, "unsupported compression %q", encoding)
+}
+
+// rawCodec passes serialized messages through unchanged. It is named proto so
+// that the server decodes them with its proto codec.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw codec: unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("raw codec: unexpected message type %T", v)
+	}
+	*b = bytes.Clone(data)
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+// messageCodec converts Connect JSON messages to and from the binary form
+// sent to the backend. Binary messages pass through unchanged.
+type messageCodec struct {
+	in, out protoreflect.MessageType
+}
+
+func newMessageCodec(m rpcMethod, useJSON bool) (messageCodec, error) {
+	if !useJSON {
+		return messageCodec{}, nil
+	}
+	service, method, _ := strings.Cut(strings.TrimPrefix(m.name, "/"), "/")
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err == nil {
+		if sd, ok := desc.(protoreflect.ServiceDescriptor); ok {
+			if md := sd.Methods().ByName(protoreflect.Name(method)); md != nil {
+				return messageCodec{in: messageType(md.Input()), out: messageType(md.Output())}, nil
+			}
+		}
+	}
+	return messageCodec{}, errors.Newf(errors.Unimplemented, "json is not supported for %s: descriptor not registered", m.name)
+}
+
+func (c messageCodec) request(data []byte) ([]byte, error) {
+	if c.in == nil {
+		return data, nil
+	}
+	msg := c.in.New().Interface()
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, msg); err != nil {
+		return nil, errors.Newf(errors.InvalidArgument, "invalid request: %v", err)
+	}
+	return proto.Marshal(msg)
+}
+
+func (c messageCodec) response(data []byte) ([]byte, error) {
+	if c.out == nil {
+		return data, nil
+	}
+	msg := c.out.New().Interface()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, errors.Wrap(err, errors.Internal, "internal error")
+	}
+	return protojson.Marshal(msg)
+}
+
+// connectError is the JSON error of the Connect protocol.
+type connectError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message,omitempty"`
+	Details []connectDetail `json:"details,omitempty"`
+}
+
+type connectDetail struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// connectEndStream is the end-of-stream message of Connect streaming calls.
+type connectEndStream struct {
+	Error    *connectError       `json:"error,omitempty"`
+	Metadata map[string][]string `json:"metadata,omitempty"`
+}
+
+func newConnectError(st *status.Status) *connectError {
+	code := strings.ToLower(string(errors.CodeFromGRPC(st.Code())))
+	if code == "cancelled" {
+		code = "canceled"
+	}
+	e := &connectError{Code: code, Message: st.Message()}
+	for _, d := range st.Proto().GetDetails() {
+		e.Details = append(e.Details, connectDetail{
+			Type:  d.GetTypeUrl()[strings.LastIndexByte(d.GetTypeUrl(), '/')+1:],
+			Value: base64.RawStdEncoding.EncodeToString(d.GetValue()),
+		})
+	}
+	return e
+}
+
+func mustMarshalJSON(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// grpcWebTrailer returns the body of a gRPC-Web trailer frame.
+func grpcWebTrailer(st *status.Status, trailer metadata.MD) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "grpc-status: %d\r\n", st.Code())
+	if msg := st.Message(); msg != "" {
+		fmt.Fprintf(&b, "grpc-message: %s\r\n", encodeGRPCMessage(msg))
+	}
+	if len(st.Proto().GetDetails()) > 0 {
+		if data, err := proto.Marshal(st.Proto()); err == nil {
+			fmt.Fprintf(&b, "grpc-status-details-bin: %s\r\n", base64.RawStdEncoding.EncodeToString(data))
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(trailer)) {
+		if isReservedMetadata(k) {
+			continue
+		}
+		for _, v := range encodeMetadataValues(k, trailer[k]) {
+			fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+		}
+	}
+	return b.Bytes()
+}
+
+// encodeGRPCMessage percent-encodes msg as required for grpc-message.
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if c := msg[i]; c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func appendEnvelope(dst []byte, flags byte, data []byte) []byte {
+	dst = append(dst, flags)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(data)))
+	return append(dst, data...)
+}
+
+// decodeBase64Chunks decodes base64 data that may consist of several padded
+// chunks, as sent by gRPC-Web text clients.
+func decodeBase64Chunks(data []byte) ([]byte, error) {
+	data = bytes.Join(bytes.Fields(data), nil)
+	var out []byte
+	for len(data) > 0 {
+		n := len(data)
+		if i := bytes.IndexByte(data, '='); i >= 0 {
+			n = i
+			for n < len(data) && data[n] == '=' {
+				n++
+			}
+		}
+		enc := base64.StdEncoding
+		if n%4 != 0 {
+			enc = base64.RawStdEncoding
+		}
+		chunk, err := enc.DecodeString(string(data[:n]))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+		data = data[n:]
+	}
+	return out, nil
+}
+
+// requestContext returns the context of the backend call, carrying the
+// request headers as outgoing metadata and the client's timeout, if any.
+func requestContext(r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	md := metadata.MD{}
+	for key, values := range r.Header {
+		k := strings.ToLower(key)
+		if isReservedHeader(k) || !validMetadataKey(k) {
+			continue
+		}
+		if strings.HasSuffix(k, "-bin") {
+			for _, v := range values {
+				if data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(v, "=")); err == nil {
+					md.Append(k, string(data))
+				}
+			}
+			continue
+		}
+		md.Append(k, values...)
+	}
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// isReservedHeader reports whether the lower-case HTTP header k is part of
+// the transport or protocol and not forwarded as metadata.
+func isReservedHeader(k string) bool {
+	switch k {
+	case "accept", "accept-encoding", "connection", "content-encoding", "content-length",
+		"content-type", "host", "keep-alive", "te", "trailer", "transfer-encoding",
+		"upgrade", "user-agent", "x-grpc-web", "x-user-agent":
+		return true
+	}
+	return strings.HasPrefix(k, "grpc-") || strings.HasPrefix(k, "connect-") || strings.HasPrefix(k, "proxy-")
+}
+
+// isReservedMetadata reports whether the response metadata key k is part of
+// the gRPC transport and not returned to the client.
+func isReservedMetadata(k string) bool {
+	return k == "content-type" || strings.HasPrefix(k, "grpc-") || strings.HasPrefix(k, ":")
+}
+
+func validMetadataKey(k string) bool {
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return k != ""
+}
+
+// writeMetadataHeaders adds md to h with the given key prefix.
+func writeMetadataHeaders(h http.Header, md metadata.MD, prefix string) {
+	for k, v := range md {
+		if isReservedMetadata(k) {
+			continue
+		}
+		for _, value := range encodeMetadataValues(k, v) {
+			h.Add(prefix+k, value)
+		}
+	}
+}
+
+// encodeMetadataValues base64-encodes the values of binary metadata keys.
+func encodeMetadataValues(k string, values []string) []string {
+	if !strings.HasSuffix(k, "-bin") {
+		return values
+	}
+	encoded := make([]string, len(values))
+	for i, v := range values {
+		encoded[i] = base64.RawStdEncoding.EncodeToString([]byte(v))
+	}
+	return encoded
+}
+
+// grpcWebTimeout parses the grpc-timeout header, e.g. 100m or 5S.
+func grpcWebTimeout(h http.Header) time.Duration {
+	v := h.Get("Grpc-Timeout")
+	if len(v) < 2 {
+		return 0
+	}
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}
+	unit, ok := units[v[len(v)-1]]
+	if !ok {
+		return 0
+	}
+	return time.Duration(n) * unit
+}
+
+// connectTimeout parses the Connect-Timeout-Ms header.
+func connectTimeout(h http.Header) time.Duration {
+	ms, err := strconv.ParseInt(h.Get("Connect-Timeout-Ms"), 10, 64)
+	if err != nil || ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func echoMessage(t *testing.T, name string) *dynamicpb.Message {
+	t.Helper()
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		t.Fatalf("FindDescriptorByName(%s) error = %v", name, err)
+	}
+	return dynamicpb.NewMessage(d.(protoreflect.MessageDescriptor))
+}
+
+func echoRequest(t *testing.T, text string, times int) []byte {
+	t.Helper()
+	msg := echoMessage(t, "octopus.test.EchoRequest")
+	if err := protojson.Unmarshal(fmt.Appendf(nil, `{"text":%q,"times":%d}`, text, times), msg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return data
+}
+
+func echoResponseText(t *testing.T, data []byte) string {
+	t.Helper()
+	msg := echoMessage(t, "octopus.test.EchoResponse")
+	if err := proto.Unmarshal(data, msg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return msg.Get(msg.Descriptor().Fields().ByName("text")).String()
+}
+
+type frame struct {
+	flags byte
+	data  []byte
+}
+
+func readFrames(t *testing.T, data []byte) []frame {
+	t.Helper()
+	var frames []frame
+	for len(data) > 0 {
+		if len(data) < 5 {
+			t.Fatalf("truncated frame %q", data)
+		}
+		size := binary.BigEndian.Uint32(data[1:5])
+		frames = append(frames, frame{flags: data[0], data: data[5 : 5+size]})
+		data = data[5+size:]
+	}
+	return frames
+}
+
+func TestServerConnect(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	backend := startEchoBackend(t, log)
+	server, err := NewServer(log, &ServerConfig{
+		Name:    "api-test",
+		Host:    "127.0.0.1",
+		Port:    freePort(t),
+		Mode:    "release",
+		Connect: &ConnectConfig{Services: []string{"octopus.test.Echo"}, MaxMessageSize: 1024},
+	}, WithConnect(backend))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	startServer(t, server)
+
+	do := func(contentType string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/octopus.test.Echo/Echo", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-Request-Id", "req-1")
+		w := httptest.NewRecorder()
+		server.Engine().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("grpc-web", func(t *testing.T) {
+		w := do("application/grpc-web+proto", appendEnvelope(nil, 0, echoRequest(t, "ab", 2)))
+		if w.Code != http.StatusOK || w.Header().Get("X-Request-Id") != "req-1" {
+			t.Fatalf("response = %d %v", w.Code, w.Header())
+		}
+		frames := readFrames(t, w.Body.Bytes())
+		if len(frames) != 2 || echoResponseText(t, frames[0].data) != "abab" {
+			t.Fatalf("frames = %q", frames)
+		}
+		if frames[1].flags != flagGRPCWebTrailer || !strings.Contains(string(frames[1].data), "grpc-status: 0\r\n") {
+			t.Fatalf("trailer = %q", frames[1].data)
+		}
+	})
+
+	t.Run("grpc-web error", func(t *testing.T) {
+		w := do("application/grpc-web", appendEnvelope(nil, 0, echoRequest(t, "missing", 1)))
+		frames := readFrames(t, w.Body.Bytes())
+		if len(frames) != 1 {
+			t.Fatalf("frames = %q", frames)
+		}
+		trailer := string(frames[0].data)
+		for _, want := range []string{"grpc-status: 5\r\n", "grpc-message: text not found\r\n", "grpc-status-details-bin: "} {
+			if !strings.Contains(trailer, want) {
+				t.Fatalf("trailer = %q, want %q", trailer, want)
+			}
+		}
+	})
+
+	t.Run("grpc-web-text", func(t *testing.T) {
+		body := base64.StdEncoding.EncodeToString(appendEnvelope(nil, 0, echoRequest(t, "x", 3)))
+		w := do("application/grpc-web-text", []byte(body))
+		data, err := decodeBase64Chunks(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("decode body %q: %v", w.Body.String(), err)
+		}
+		if frames := readFrames(t, data); len(frames) != 2 || echoResponseText(t, frames[0].data) != "xxx" {
+			t.Fatalf("frames = %q", frames)
+		}
+	})
+
+	t.Run("connect json", func(t *testing.T) {
+		w := do("application/json", []byte(`{"text":"ab","times":2}`))
+		if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"text":"abab"}` {
+			t.Fatalf("response = %d %s", w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Request-Id") != "req-1" {
+			t.Fatalf("header = %v", w.Header())
+		}
+	})
+
+	t.Run("connect proto", func(t *testing.T) {
+		w := do("application/proto", echoRequest(t, "p", 2))
+		if w.Code != http.StatusOK || echoResponseText(t, w.Body.Bytes()) != "pp" {
+			t.Fatalf("response = %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("connect error", func(t *testing.T) {
+		w := do("application/json", []byte(`{"text":"missing"}`))
+		var body connectError
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode body %q: %v", w.Body.String(), err)
+		}
+		if w.Code != http.StatusNotFound || body.Code != "not_found" || body.Message != "text not found" {
+			t.Fatalf("response = %d %+v", w.Code, body)
+		}
+		if len(body.Details) != 1 || body.Details[0].Type != "google.rpc.ErrorInfo" {
+			t.Fatalf("details = %+v", body.Details)
+		}
+	})
+
+	t.Run("connect stream", func(t *testing.T) {
+		w := do("application/connect+json", appendEnvelope(nil, 0, []byte(`{"text":"s","times":2}`)))
+		frames := readFrames(t, w.Body.Bytes())
+		if len(frames) != 2 || string(frames[0].data) != `{"text":"ss"}` {
+			t.Fatalf("frames = %q", frames)
+		}
+		if frames[1].flags != flagEndStream || strings.Contains(string(frames[1].data), "error") {
+			t.Fatalf("end stream = %q", frames[1].data)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if w := do("text/plain", nil); w.Code != http.StatusUnsupportedMediaType {
+			t.Fatalf("text/plain = %d", w.Code)
+		}
+		w := do("application/json", []byte(fmt.Sprintf(`{"text":%q}`, strings.Repeat("a", 2048))))
+		if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), `"resource_exhausted"`) {
+			t.Fatalf("large body = %d %s", w.Code, w.Body.String())
+		}
+		if w := do("application/json", []byte(`{"text":`)); w.Code != http.StatusBadRequest {
+			t.Fatalf("invalid json = %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestServerConnectProtocols(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	backend := startEchoBackend(t, log)
+	server, err := NewServer(log, &ServerConfig{
+		Name:    "api-test",
+		Host:    "127.0.0.1",
+		Port:    freePort(t),
+		Mode:    "release",
+		Connect: &ConnectConfig{Protocols: []string{ProtocolGRPCWeb}},
+	}, WithConnect(backend))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	startServer(t, server)
+
+	req := httptest.NewRequest(http.MethodPost, "/octopus.test.Echo/Echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.Engine().ServeHTTP(w, req)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("connect request = %d, want 415", w.Code)
+	}
+
+	if _, err := NewServer(log, &ServerConfig{
+		Name:    "api-test",
+		Port:    8080,
+		Connect: &ConnectConfig{Protocols: []string{"grpc"}},
+	}); err == nil {
+		t.Fatal("NewServer() accepted an unknown protocol")
+	}
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// requestIDHeader is forwarded between HTTP headers and gRPC metadata in both
+// directions, so that the gateway and the gRPC request log share the ID.
+const requestIDHeader = "X-Request-Id"
+
+type ginContextKey struct{}
+
+// gateway transcodes HTTP/JSON requests to the google.api.http annotated
+// methods of the backend's services.
+type gateway struct {
+	config  *GatewayConfig
+	backend RPCBackend
+	mux     *runtime.ServeMux
+}
+
+func newGateway(config *GatewayConfig, backend RPCBackend) *gateway {
+	if config == nil {
+		config = &GatewayConfig{}
+	}
+	marshaler := &runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{
+			EmitUnpopulated: config.EmitUnpopulated,
+			UseProtoNames:   config.UseProtoNames,
+			UseEnumNumbers:  config.UseEnumNumbers,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: config.DiscardUnknown,
+		},
+	}
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, marshaler),
+		runtime.WithErrorHandler(writeGatewayError),
+		runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+			if strings.EqualFold(key, requestIDHeader) {
+				return strings.ToLower(key), true
+			}
+			return runtime.DefaultHeaderMatcher(key)
+		}),
+		runtime.WithOutgoingHeaderMatcher(func(key string) (string, bool) {
+			if strings.EqualFold(key, requestIDHeader) {
+				return requestIDHeader, true
+			}
+			return runtime.MetadataHeaderPrefix + key, true
+		}),
+	)
+	return &gateway{config: config, backend: backend, mux: mux}
+}
+
+// init registers a route for every annotated method of the backend's
+// services. It runs when the server starts, after all services are
+// registered.
+func (g *gateway) init() (int, error) {
+	conn, err := g.backend.LocalConn()
+	if err != nil {
+		return 0, fmt.Errorf("api: gateway: %w", err)
+	}
+	routes := 0
+	for name := range g.backend.GetServiceInfo() {
+		if len(g.config.Services) > 0 && !slices.Contains(g.config.Services, name) {
+			continue
+		}
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+		sd, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		for i := 0; i < sd.Methods().Len(); i++ {
+			md := sd.Methods().Get(i)
+			rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+			if md.IsStreamingClient() || md.IsStreamingServer() {
+				return 0, fmt.Errorf("api: gateway: %s: streaming methods are not supported", md.FullName())
+			}
+			for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				if err := g.handle(conn, md, r); err != nil {
+					return 0, fmt.Errorf("api: gateway: %s: %w", md.FullName(), err)
+				}
+				routes++
+			}
+		}
+	}
+	return routes, nil
+}
+
+// handle registers the route of one HTTP rule of md.
+func (g *gateway) handle(conn grpc.ClientConnInterface, md protoreflect.MethodDescriptor, rule *annotations.HttpRule) error {
+	method, pattern := httpRulePattern(rule)
+	if pattern == "" {
+		return fmt.Errorf("http rule has no pattern")
+	}
+	in, out := messageType(md.Input()), messageType(md.Output())
+
+	var respField protoreflect.FieldDescriptor
+	if name := rule.GetResponseBody(); name != "" {
+		respField = md.Output().Fields().ByName(protoreflect.Name(name))
+		if respField == nil || respField.Message() == nil || respField.IsList() || respField.IsMap() {
+			return fmt.Errorf("response_body %q must name a message field", name)
+		}
+	}
+	body := rule.GetBody()
+	if body != "" && body != "*" && md.Input().Fields().ByName(protoreflect.Name(body)) == nil {
+		return fmt.Errorf("body %q is not a field of %s", body, md.Input().FullName())
+	}
+
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	return g.mux.HandlePath(method, pattern, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		inbound, outbound := runtime.MarshalerForRequest(g.mux, r)
+		ctx, err := runtime.AnnotateContext(r.Context(), g.mux, r, fullMethod, runtime.WithHTTPPathPattern(pattern))
+		if err != nil {
+			runtime.HTTPError(ctx, g.mux, outbound, w, r, err)
+			return
+		}
+		req := in.New().Interface()
+		if err := decodeGatewayRequest(inbound, r, req, body, params); err != nil {
+			runtime.HTTPError(ctx, g.mux, outbound, w, r, err)
+			return
+		}
+
+		var meta runtime.ServerMetadata
+		resp := out.New().Interface()
+		err = conn.Invoke(ctx, fullMethod, req, resp, grpc.Header(&meta.HeaderMD), grpc.Trailer(&meta.TrailerMD))
+		ctx = runtime.NewServerMetadataContext(ctx, meta)
+		if err != nil {
+			runtime.HTTPError(ctx, g.mux, outbound, w, r, err)
+			return
+		}
+		if respField != nil {
+			resp = resp.ProtoReflect().Get(respField).Message().Interface()
+		}
+		runtime.ForwardResponseMessage(ctx, g.mux, outbound, w, r, resp)
+	})
+}
+
+// serve is the gin handler of the gateway. It runs as the engine's NoRoute
+// handler, so gin routes take precedence.
+func (g *gateway) serve(c *gin.Context) {
+	// gin presets 404 for NoRoute handlers, while the gateway only writes
+	// the header for non-200 responses.
+	c.Status(http.StatusOK)
+	ctx := context.WithValue(c.Request.Context(), ginContextKey{}, c)
+	g.mux.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
+
+// writeGatewayError renders gateway errors, including gRPC statuses returned
+// by the backend, with the same body as WriteError.
+func writeGatewayError(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if c, ok := r.Context().Value(ginContextKey{}).(*gin.Context); ok {
+		middleware.WriteError(c, err)
+		return
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, m, w, r, err)
+}
+
+// decodeGatewayRequest fills req from the request body, the path parameters
+// and, unless the whole body is mapped, the query parameters.
+func decodeGatewayRequest(marshaler runtime.Marshaler, r *http.Request, req proto.Message, body string, params map[string]string) error {
+	var filter [][]string
+	switch body {
+	case "":
+	case "*":
+		if err := decodeGatewayBody(marshaler, r.Body, req); err != nil {
+			return err
+		}
+	default:
+		// The body is decoded as the value of the named field.
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			fd := req.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(body))
+			wrapped := fmt.Appendf(nil, "{%q:%s}", fd.JSONName(), data)
+			field := req.ProtoReflect().New().Interface()
+			if err := marshaler.Unmarshal(wrapped, field); err != nil {
+				return invalidGatewayRequest(err)
+			}
+			proto.Merge(req, field)
+		}
+		filter = append(filter, []string{body})
+	}
+
+	for name, value := range params {
+		if err := runtime.PopulateFieldFromPath(req, name, value); err != nil {
+			return invalidGatewayRequest(err)
+		}
+		filter = append(filter, strings.Split(name, "."))
+	}
+	if body == "*" {
+		return nil
+	}
+	if err := runtime.PopulateQueryParameters(req, r.URL.Query(), utilities.NewDoubleArray(filter)); err != nil {
+		return invalidGatewayRequest(err)
+	}
+	return nil
+}
+
+func decodeGatewayBody(marshaler runtime.Marshaler, r io.Reader, req proto.Message) error {
+	if err := marshaler.NewDecoder(r).Decode(req); err != nil && err != io.EOF {
+		return invalidGatewayRequest(err)
+	}
+	return nil
+}
+
+func invalidGatewayRequest(err error) error {
+	return errors.Newf(errors.InvalidArgument, "invalid request: %v", err)
+}
+
+// httpRulePattern returns the HTTP method and path template of rule.
+func httpRulePattern(rule *annotations.HttpRule) (string, string) {
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath()
+	}
+	return "", ""
+}
+
+// messageType returns the registered Go type of md, or a dynamic type when
+// none is registered.
+func messageType(md protoreflect.MessageDescriptor) protoreflect.MessageType {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		return mt
+	}
+	return dynamicpb.NewMessageType(md)
+}
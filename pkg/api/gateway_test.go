@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/HorseArcher567/octopus/pkg/rpc"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// echoService registers octopus.test.Echo, an annotated service without
+// generated Go types, so both sides use dynamic messages.
+func echoService(t *testing.T) *grpc.ServiceDesc {
+	t.Helper()
+	const name = "octopus.test.Echo"
+	if d, err := protoregistry.GlobalFiles.FindDescriptorByName(name); err == nil {
+		return echoServiceDesc(d.(protoreflect.ServiceDescriptor))
+	}
+
+	httpRule := func(rule *annotations.HttpRule) *descriptorpb.MethodOptions {
+		opts := &descriptorpb.MethodOptions{}
+		proto.SetExtension(opts, annotations.E_Http, rule)
+		return opts
+	}
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+	}
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("octopus/test/echo.proto"),
+		Package: proto.String("octopus.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("EchoRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("text", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("times", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+			}},
+			{Name: proto.String("EchoResponse"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("text", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Echo"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Echo"),
+				InputType:  proto.String(".octopus.test.EchoRequest"),
+				OutputType: proto.String(".octopus.test.EchoResponse"),
+				Options: httpRule(&annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/v1/echo/{text}"},
+					AdditionalBindings: []*annotations.HttpRule{{
+						Pattern: &annotations.HttpRule_Post{Post: "/v1/echo"},
+						Body:    "*",
+					}},
+				}),
+			}},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("NewFile() error = %v", err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		t.Fatalf("RegisterFile() error = %v", err)
+	}
+	return echoServiceDesc(fd.Services().Get(0))
+}
+
+var errEchoNotFound = errors.New(errors.NotFound, "text not found").WithReason("TEXT_NOT_FOUND")
+
+func echoServiceDesc(sd protoreflect.ServiceDescriptor) *grpc.ServiceDesc {
+	md := sd.Methods().Get(0)
+	return &grpc.ServiceDesc{
+		ServiceName: string(sd.FullName()),
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: string(md.Name()),
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				in := dynamicpb.NewMessage(md.Input())
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				handler := func(_ context.Context, req any) (any, error) {
+					msg := req.(*dynamicpb.Message)
+					text := msg.Get(md.Input().Fields().ByName("text")).String()
+					if text == "missing" {
+						return nil, errEchoNotFound
+					}
+					times := int(msg.Get(md.Input().Fields().ByName("times")).Int())
+					out := dynamicpb.NewMessage(md.Output())
+					out.Set(md.Output().Fields().ByName("text"), protoreflect.ValueOfString(strings.Repeat(text, max(times, 1))))
+					return out, nil
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fmt.Sprintf("/%s/%s", sd.FullName(), md.Name())}
+				return interceptor(ctx, in, info, handler)
+			},
+		}},
+		Metadata: sd.ParentFile().Path(),
+	}
+}
+
+// startEchoBackend runs an rpc server with the echo service until the test
+// ends.
+func startEchoBackend(t *testing.T, log *xlog.Logger) *rpc.Server {
+	t.Helper()
+	desc := echoService(t)
+	backend, err := rpc.NewServer(log, &rpc.ServerConfig{Name: "echo", Host: "127.0.0.1", Port: freePort(t)})
+	if err != nil {
+		t.Fatalf("rpc.NewServer() error = %v", err)
+	}
+	_ = backend.Register(func(r grpc.ServiceRegistrar) { r.RegisterService(desc, struct{}{}) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = backend.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		_ = backend.Stop(context.Background())
+	})
+	<-backend.Ready()
+	return backend
+}
+
+// startServer runs server until the test ends.
+func startServer(t *testing.T, server *Server) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = server.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		_ = server.Stop(context.Background())
+	})
+	select {
+	case <-server.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("api server not ready")
+	}
+}
+
+func TestServerGateway(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	backend := startEchoBackend(t, log)
+	server, err := NewServer(log, &ServerConfig{
+		Name:    "api-test",
+		Host:    "127.0.0.1",
+		Port:    freePort(t),
+		Mode:    "release",
+		Gateway: &GatewayConfig{EmitUnpopulated: true},
+	}, WithGateway(backend))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	// gin routes take precedence over the gateway.
+	server.Engine().GET("/v1/echo/static", func(c *gin.Context) { c.String(http.StatusOK, "gin") })
+	startServer(t, server)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Request-Id", "req-1")
+		w := httptest.NewRecorder()
+		server.Engine().ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/v1/echo/ab?times=2", "")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"text":"abab"}` {
+		t.Fatalf("GET = %d %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Request-Id"); got != "req-1" {
+		t.Fatalf("X-Request-Id = %q, want req-1", got)
+	}
+
+	w = do(http.MethodPost, "/v1/echo", `{"text":"c","times":3}`)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"text":"ccc"}` {
+		t.Fatalf("POST = %d %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodGet, "/v1/echo/static", "")
+	if w.Body.String() != "gin" {
+		t.Fatalf("gin route = %d %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		code               errors.Code
+	}{
+		{http.MethodGet, "/v1/echo/missing", "", http.StatusNotFound, errors.NotFound},
+		{http.MethodPost, "/v1/echo", `{"text":`, http.StatusBadRequest, errors.InvalidArgument},
+		{http.MethodGet, "/v1/unknown", "", http.StatusNotFound, errors.NotFound},
+	}
+	for _, tt := range tests {
+		w := do(tt.method, tt.path, tt.body)
+		var body middleware.ErrorBody
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: decode body %q: %v", tt.method, tt.path, w.Body.String(), err)
+		}
+		if w.Code != tt.status || body.Code != tt.code {
+			t.Fatalf("%s %s = %d %+v, want %d %s", tt.method, tt.path, w.Code, body, tt.status, tt.code)
+		}
+	}
+	if w := do(http.MethodGet, "/v1/echo/missing", ""); !strings.Contains(w.Body.String(), "TEXT_NOT_FOUND") {
+		t.Fatalf("error reason missing from %s", w.Body.String())
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorBody is the JSON body of error responses.
+type ErrorBody struct {
+	Code            errors.Code             `json:"code"`
+	Message         string                  `json:"message"`
+	Reason          string                  `json:"reason,omitempty"`
+	Metadata        map[string]string       `json:"metadata,omitempty"`
+	FieldViolations []errors.FieldViolation `json:"fieldViolations,omitempty"`
+}
+
+// WriteError aborts the request with err rendered as an ErrorBody and the
+// HTTP status of its code. err is converted with errors.FromError, so errors
+// other than *errors.Error are reported as internal errors without their
+// text. err is also recorded in c.Errors for logging and tracing.
+func WriteError(c *gin.Context, err error) {
+	WriteErrorStatus(c, 0, err)
+}
+
+// WriteErrorStatus is like WriteError but responds with status instead of
+// the HTTP status of the error code, unless status is zero.
+func WriteErrorStatus(c *gin.Context, status int, err error) {
+	e := errors.FromError(err)
+	if e == nil {
+		return
+	}
+	if status == 0 {
+		status = e.HTTPStatus()
+	}
+	_ = c.Error(err)
+	c.AbortWithStatusJSON(status, ErrorBody{
+		Code:            e.Code,
+		Message:         e.Message,
+		Reason:          e.Reason,
+		Metadata:        e.Metadata,
+		FieldViolations: e.FieldViolations,
+	})
+}
+
+// Errors renders the last error added with c.Error as an ErrorBody when the
+// handler did not write a response itself.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		WriteError(c, c.Errors.Last().Err)
+	}
+}
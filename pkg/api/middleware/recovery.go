@@ -1,14 +1,14 @@
 package middleware
 
 import (
-	"net/http"
-
+	"github.com/HorseArcher567/octopus/pkg/errors"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
 	"github.com/gin-gonic/gin"
 )
 
 // Recovery returns a simple panic recovery middleware.
-// It recovers from panics, logs the error, and returns HTTP 500.
+// It recovers from panics, logs the error, and returns HTTP 500
+// with an ErrorBody.
 func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
@@ -16,10 +16,7 @@ func Recovery() gin.HandlerFunc {
 				log := xlog.Get(c.Request.Context())
 				log.Error("panic recovered in http handler", "panic", r)
 
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"code":    http.StatusInternalServerError,
-					"message": "internal server error",
-				})
+				WriteError(c, errors.New(errors.Internal, "internal server error"))
 			}
 		}()
 
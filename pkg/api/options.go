@@ -1,6 +1,12 @@
 package api
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
 
 // Option customizes HTTP Server behavior.
 type Option func(s *Server)
@@ -18,3 +24,42 @@ func WithoutDefaultMiddleware() Option {
 		s.defaultMiddleware = false
 	}
 }
+
+// WithTracing starts a server span for every request and adds the trace and
+// span IDs to the request logger. The tracing middleware runs right after the
+// logger is injected, so the default request log carries the IDs.
+func WithTracing(tp trace.TracerProvider, propagator propagation.TextMapPropagator) Option {
+	return func(s *Server) {
+		s.tracing = middleware.Tracing(tp, propagator)
+	}
+}
+
+// RPCBackend is the gRPC server behind the gateway and the Connect handler.
+// *rpc.Server implements it.
+type RPCBackend interface {
+	// LocalConn returns an in-process connection to the backend's services.
+	LocalConn() (*grpc.ClientConn, error)
+
+	// GetServiceInfo returns the registered services by full name.
+	GetServiceInfo() map[string]grpc.ServiceInfo
+}
+
+// WithGateway serves the google.api.http annotated methods of the backend's
+// services as HTTP/JSON routes, using ServerConfig.Gateway for the JSON
+// options. Requests are transcoded and sent to the backend in process. The
+// gateway only handles requests that match no gin route.
+func WithGateway(backend RPCBackend) Option {
+	return func(s *Server) {
+		s.gatewayBackend = backend
+	}
+}
+
+// WithConnect serves the backend's services to gRPC-Web and Connect clients,
+// using ServerConfig.Connect for the protocol options. Requests are sent to
+// the backend in process, so they run the same handlers and interceptors as
+// native gRPC calls.
+func WithConnect(backend RPCBackend) Option {
+	return func(s *Server) {
+		s.connectBackend = backend
+	}
+}
@@ -2,9 +2,12 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/pprof"
+	"sync"
 
 	"github.com/HorseArcher567/octopus/pkg/api/middleware"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
@@ -17,6 +20,12 @@ type Engine = gin.Engine
 // Router is a type alias for gin.IRouter.
 type Router = gin.IRouter
 
+// WriteError aborts the request with err rendered as the JSON error body and
+// the HTTP status of its code. See middleware.WriteError.
+func WriteError(c *gin.Context, err error) {
+	middleware.WriteError(c, err)
+}
+
 // Server encapsulates the lifecycle of a Gin HTTP server.
 type Server struct {
 	log    *xlog.Logger
@@ -24,9 +33,18 @@ type Server struct {
 
 	defaultMiddleware bool
 	extraMiddleware   []gin.HandlerFunc
+	tracing           gin.HandlerFunc
+	gatewayBackend    RPCBackend
+	gateway           *gateway
+	connectBackend    RPCBackend
+	connect           *connectHandler
 
 	engine     *gin.Engine
 	httpServer *http.Server
+	tlsConfig  *tls.Config
+
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
 // MustNewServer creates a new Server and panics if initialization fails.
@@ -49,6 +67,7 @@ func NewServer(log *xlog.Logger, config *ServerConfig, opts ...Option) (*Server,
 		log:               log,
 		config:            config,
 		defaultMiddleware: true,
+		ready:             make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -58,21 +77,43 @@ func NewServer(log *xlog.Logger, config *ServerConfig, opts ...Option) (*Server,
 	gin.SetMode(config.Mode)
 	s.engine = gin.New()
 	if s.defaultMiddleware {
+		s.engine.Use(middleware.LoggerInjector(s.log))
+		if s.tracing != nil {
+			s.engine.Use(s.tracing)
+		}
 		s.engine.Use(
-			middleware.LoggerInjector(s.log),
 			middleware.Recovery(),
 			middleware.Logging(),
+			middleware.Errors(),
 		)
+	} else if s.tracing != nil {
+		s.engine.Use(s.tracing)
 	}
 	if len(s.extraMiddleware) > 0 {
 		s.engine.Use(s.extraMiddleware...)
 	}
 
+	if s.gatewayBackend != nil {
+		s.gateway = newGateway(config.Gateway, s.gatewayBackend)
+		s.engine.NoRoute(s.gateway.serve)
+	}
+	if s.connectBackend != nil {
+		s.connect = newConnectHandler(config.Connect, s.connectBackend)
+	}
+
 	// Mount pprof routes if enabled.
 	if config.EnablePProf {
 		s.registerPProf()
 	}
 
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.ServerTLSConfig("h2", "http/1.1")
+		if err != nil {
+			return nil, err
+		}
+		s.tlsConfig = tlsConfig
+	}
+
 	return s, nil
 }
 
@@ -93,25 +134,61 @@ func (s *Server) Register(register func(*Engine)) error {
 // Note: Run does NOT call Stop; Stop is called by App uniformly.
 func (s *Server) Run(ctx context.Context) error {
 	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+	if s.gateway != nil {
+		routes, err := s.gateway.init()
+		if err != nil {
+			s.log.Error("failed to initialize gateway", "error", err)
+			return err
+		}
+		s.log.Info("gateway routes registered", "routes", routes)
+	}
+	if s.connect != nil {
+		routes, err := s.connect.init(s.engine)
+		if err != nil {
+			s.log.Error("failed to initialize connect handler", "error", err)
+			return err
+		}
+		s.log.Info("connect routes registered", "routes", routes, "protocols", s.connect.protocols())
+	}
 	s.httpServer = &http.Server{
 		Addr:         addr,
 		Handler:      s.engine,
 		ReadTimeout:  s.config.ReadTimeout,
 		WriteTimeout: s.config.WriteTimeout,
 		IdleTimeout:  s.config.IdleTimeout,
+		TLSConfig:    s.tlsConfig,
+	}
+	if s.config.H2C {
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		s.httpServer.Protocols = &protocols
 	}
 
-	s.log.Info("starting api server", "addr", addr)
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		s.log.Error("failed to listen", "error", err)
+		return err
+	}
+
+	s.log.Info("starting api server", "addr", addr,
+		"tls", s.tlsConfig != nil, "h2c", s.config.H2C)
 
 	// Start server in goroutine
 	errCh := make(chan error, 1)
 	go func() {
-		err := s.httpServer.ListenAndServe()
+		var err error
+		if s.tlsConfig != nil {
+			err = s.httpServer.ServeTLS(lis, "", "")
+		} else {
+			err = s.httpServer.Serve(lis)
+		}
 		if err != nil && err != http.ErrServerClosed {
 			errCh <- err
 		}
 		close(errCh)
 	}()
+	s.readyOnce.Do(func() { close(s.ready) })
 
 	// Wait for ctx cancelled or server error
 	select {
@@ -122,6 +199,11 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 }
 
+// Ready returns a channel that is closed once the server is listening.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
 // Stop gracefully shuts down the HTTP server with the given context for timeout control.
 func (s *Server) Stop(ctx context.Context) error {
 	if s.httpServer == nil {
@@ -2,15 +2,27 @@ package api
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/errors"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/HorseArcher567/octopus/pkg/xtls"
+	"github.com/HorseArcher567/octopus/pkg/xtls/xtlstest"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestServerRegisterRunAndStop(t *testing.T) {
@@ -95,6 +107,52 @@ func TestServerWithMiddleware(t *testing.T) {
 	}
 }
 
+func TestServerErrorRendering(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	server, err := NewServer(log, &ServerConfig{
+		Name: "api-test",
+		Host: "127.0.0.1",
+		Port: freePort(t),
+		Mode: "release",
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	notFound := errors.New(errors.NotFound, "user not found").WithReason("USER_NOT_FOUND")
+	server.Engine().GET("/domain", func(c *gin.Context) {
+		WriteError(c, fmt.Errorf("user 7: %w", notFound))
+	})
+	server.Engine().GET("/plain", func(c *gin.Context) {
+		_ = c.Error(fmt.Errorf("dial db: connection refused"))
+	})
+	server.Engine().GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   middleware.ErrorBody
+	}{
+		{"/domain", http.StatusNotFound, middleware.ErrorBody{Code: errors.NotFound, Message: "user not found", Reason: "USER_NOT_FOUND"}},
+		{"/plain", http.StatusInternalServerError, middleware.ErrorBody{Code: errors.Internal, Message: "internal error"}},
+		{"/panic", http.StatusInternalServerError, middleware.ErrorBody{Code: errors.Internal, Message: "internal server error"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.Engine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		var body middleware.ErrorBody
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("GET %s: decode body %q: %v", tt.path, w.Body.String(), err)
+		}
+		if w.Code != tt.status || body.Code != tt.body.Code || body.Message != tt.body.Message || body.Reason != tt.body.Reason {
+			t.Fatalf("GET %s = %d %+v, want %d %+v", tt.path, w.Code, body, tt.status, tt.body)
+		}
+	}
+}
+
 func TestServerWithoutDefaultMiddleware(t *testing.T) {
 	log := xlog.MustNew(nil)
 	defer log.Close()
@@ -150,3 +208,168 @@ func waitHTTPReady(t *testing.T, url string, timeout time.Duration) {
 	}
 	t.Fatalf("timeout waiting for %s", url)
 }
+
+func TestServerWithTracing(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	server, err := NewServer(log, &ServerConfig{
+		Name: "api-test",
+		Host: "127.0.0.1",
+		Port: 8080,
+		Mode: "release",
+	}, WithTracing(tp, propagation.TraceContext{}))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	var traced bool
+	_ = server.Register(func(engine *Engine) {
+		engine.GET("/users/:id", func(c *gin.Context) {
+			traced = trace.SpanContextFromContext(c.Request.Context()).IsValid()
+			c.Status(http.StatusInternalServerError)
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	server.Engine().ServeHTTP(httptest.NewRecorder(), req)
+
+	if !traced {
+		t.Fatalf("handler context has no span")
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /users/:id" {
+		t.Fatalf("span name = %q", span.Name())
+	}
+	if got := span.Parent().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("parent trace id = %s", got)
+	}
+	if span.Status().Code != codes.Error {
+		t.Fatalf("span status = %v, want error", span.Status())
+	}
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	dir := t.TempDir()
+	ca := xtlstest.NewCA(t)
+	caFile := ca.WriteCA(t, dir)
+	serverCert, serverKey := ca.Issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.Issue(t, dir, "client", x509.ExtKeyUsageClientAuth)
+
+	port := freePort(t)
+	server, err := NewServer(log, &ServerConfig{
+		Name: "api-test",
+		Host: "127.0.0.1",
+		Port: port,
+		Mode: "release",
+		TLS:  &xtls.Config{CertFile: serverCert, KeyFile: serverKey, CAFile: caFile},
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	server.Engine().GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.TLS.PeerCertificates[0].Subject.CommonName)
+	})
+	stop := runServer(t, server)
+	defer stop()
+
+	get := func(cfg *xtls.Config) (*http.Response, error) {
+		tlsCfg, err := cfg.ClientTLSConfig()
+		if err != nil {
+			t.Fatalf("ClientTLSConfig() error = %v", err)
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg, ForceAttemptHTTP2: true}}
+		return client.Get(fmt.Sprintf("https://localhost:%d/whoami", port))
+	}
+
+	resp, err := get(&xtls.Config{CertFile: clientCert, KeyFile: clientKey, CAFile: caFile})
+	if err != nil {
+		t.Fatalf("mTLS request error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "client" {
+		t.Fatalf("body = %q, want client", body)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("protocol = %s, want HTTP/2", resp.Proto)
+	}
+
+	if resp, err := get(&xtls.Config{CAFile: caFile}); err == nil {
+		resp.Body.Close()
+		t.Fatalf("request without client certificate should fail")
+	}
+}
+
+func TestServerH2C(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	port := freePort(t)
+	server, err := NewServer(log, &ServerConfig{
+		Name: "api-test",
+		Host: "127.0.0.1",
+		Port: port,
+		Mode: "release",
+		H2C:  true,
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	server.Engine().GET("/proto", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Proto)
+	})
+	stop := runServer(t, server)
+	defer stop()
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/proto", port))
+	if err != nil {
+		t.Fatalf("h2c request error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "HTTP/2.0" {
+		t.Fatalf("server saw %q, want HTTP/2.0", body)
+	}
+
+	if err := (&ServerConfig{Name: "api-test", Port: port, H2C: true, TLS: &xtls.Config{}}).Validate(); err == nil {
+		t.Fatalf("Validate() expected error for h2c with tls")
+	}
+}
+
+// runServer runs s until the returned function is called, which stops it
+// gracefully.
+func runServer(t *testing.T, s *Server) func() {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	select {
+	case <-s.Ready():
+	case err := <-done:
+		t.Fatalf("run returned error: %v", err)
+	}
+	return func() {
+		cancel()
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer stopCancel()
+		if err := s.Stop(stopCtx); err != nil {
+			t.Fatalf("stop server: %v", err)
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("run returned error: %v", err)
+		}
+	}
+}
@@ -48,9 +48,12 @@ type App struct {
 	log   *xlog.Logger
 	store store.Store
 
-	services      []Service
-	startupHooks  []hook.Func
-	shutdownHooks []hook.Func
+	services         []Service
+	stopOrder        []int
+	ready            chan struct{}
+	startupHooks     []hook.Func
+	preShutdownHooks []hook.Func
+	shutdownHooks    []hook.Func
 
 	runMu  sync.Mutex
 	hasRun bool
@@ -64,7 +67,7 @@ func New(log *xlog.Logger, opts ...Option) *App {
 	if log == nil {
 		log = xlog.MustNew(nil)
 	}
-	a := &App{log: log}
+	a := &App{log: log, ready: make(chan struct{})}
 	for _, opt := range opts {
 		if opt != nil {
 			opt(a)
@@ -78,7 +81,15 @@ func (a *App) Logger() *xlog.Logger {
 	return a.log
 }
 
+// Ready returns a channel that is closed once every service is ready.
+// See Readier for how a service reports readiness.
+func (a *App) Ready() <-chan struct{} {
+	return a.ready
+}
+
 // AddServices appends runtime services to the app.
+// Services implementing Dependent start once their dependencies are ready
+// and stop before them.
 func (a *App) AddServices(services ...Service) *App {
 	for _, svc := range services {
 		if svc != nil {
@@ -96,6 +107,16 @@ func (a *App) OnStartup(h hook.Func) *App {
 	return a
 }
 
+// OnPreShutdown registers a hook that runs as soon as shutdown begins,
+// before services are stopped. It suits work such as failing readiness so
+// that traffic drains while services are still serving.
+func (a *App) OnPreShutdown(h hook.Func) *App {
+	if h != nil {
+		a.preShutdownHooks = append(a.preShutdownHooks, h)
+	}
+	return a
+}
+
 // OnShutdown registers a shutdown hook.
 func (a *App) OnShutdown(h hook.Func) *App {
 	if h != nil {
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Dependent is implemented by services that must start after other services.
+// Dependencies are referenced by service name.
+type Dependent interface {
+	DependsOn() []string
+}
+
+// Readier is implemented by services that report when they are ready to
+// serve. Services that do not implement it are ready as soon as they start.
+type Readier interface {
+	// Ready returns a channel that is closed once the service is ready.
+	Ready() <-chan struct{}
+}
+
+// ReadySignal is a one-shot readiness signal that services can embed to
+// implement Readier. The zero value is ready to use.
+type ReadySignal struct {
+	once sync.Once
+	mu   sync.Mutex
+	ch   chan struct{}
+}
+
+// Ready returns a channel that is closed once MarkReady has been called.
+func (r *ReadySignal) Ready() <-chan struct{} {
+	return r.channel()
+}
+
+// MarkReady reports the service as ready. Calls after the first are no-ops.
+func (r *ReadySignal) MarkReady() {
+	ch := r.channel()
+	r.once.Do(func() { close(ch) })
+}
+
+func (r *ReadySignal) channel() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ch == nil {
+		r.ch = make(chan struct{})
+	}
+	return r.ch
+}
+
+// dependencies returns the declared dependencies of svc.
+func dependencies(svc Service) []string {
+	if d, ok := svc.(Dependent); ok {
+		return d.DependsOn()
+	}
+	return nil
+}
+
+// startOrder returns the indexes of services ordered so that every service
+// comes after its dependencies. Services without an ordering constraint keep
+// their registration order, so reversing the result gives the stop order.
+// It also returns, for each service, the indexes of its dependencies.
+func startOrder(services []Service) (order []int, deps [][]int, err error) {
+	byName := make(map[string]int, len(services))
+	declared := false
+	for i, svc := range services {
+		if len(dependencies(svc)) > 0 {
+			declared = true
+		}
+		if _, dup := byName[svc.Name()]; !dup {
+			byName[svc.Name()] = i
+		}
+	}
+
+	deps = make([][]int, len(services))
+	if declared {
+		if len(byName) != len(services) {
+			return nil, nil, fmt.Errorf("app: service names must be unique when dependencies are declared")
+		}
+		for i, svc := range services {
+			for _, name := range dependencies(svc) {
+				j, ok := byName[name]
+				if !ok {
+					return nil, nil, fmt.Errorf("app: service %q depends on unknown service %q", svc.Name(), name)
+				}
+				if j == i {
+					return nil, nil, fmt.Errorf("app: service %q depends on itself", svc.Name())
+				}
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make([]int, len(services))
+	var path []string
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch marks[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("app: service dependency cycle: %s -> %s", strings.Join(path, " -> "), services[i].Name())
+		}
+		marks[i] = visiting
+		path = append(path, services[i].Name())
+		for _, j := range deps[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[i] = visited
+		order = append(order, i)
+		return nil
+	}
+	for i := range services {
+		if err := visit(i); err != nil {
+			return nil, nil, err
+		}
+	}
+	return order, deps, nil
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/hook"
+)
+
+type dependentService struct {
+	testService
+	ReadySignal
+	deps []string
+}
+
+func (s *dependentService) DependsOn() []string { return s.deps }
+
+func TestAppRun_StartsAfterDependenciesAreReady(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var events []string
+	record := func(v string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, v)
+	}
+
+	cache := &dependentService{}
+	cache.testService = testService{
+		name: "cache",
+		runFn: func(ctx context.Context) error {
+			record("cache-run")
+			time.Sleep(20 * time.Millisecond)
+			record("cache-ready")
+			cache.MarkReady()
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		stopFn: func(context.Context) error {
+			record("cache-stop")
+			return nil
+		},
+	}
+	rpc := &dependentService{deps: []string{"cache"}}
+	rpc.testService = testService{
+		name: "rpc",
+		runFn: func(ctx context.Context) error {
+			record("rpc-run")
+			rpc.MarkReady()
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		stopFn: func(context.Context) error {
+			record("rpc-stop")
+			return nil
+		},
+	}
+
+	// rpc is registered first but must start after and stop before cache.
+	a := New(nil).AddServices(rpc, cache)
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	select {
+	case <-a.Ready():
+	case <-time.After(2 * time.Second):
+		t.Fatal("app did not become ready")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []string{"cache-run", "cache-ready", "rpc-run", "rpc-stop", "cache-stop"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+}
+
+func TestAppRun_ExitedDependencyUnblocksDependents(t *testing.T) {
+	migrate := &dependentService{}
+	migrate.testService = testService{name: "migrate", runFn: func(context.Context) error { return nil }}
+	worker := &dependentService{deps: []string{"migrate"}}
+	worker.testService = testService{name: "worker", runFn: func(context.Context) error { return nil }}
+
+	if err := New(nil).AddServices(worker, migrate).Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if worker.runCnt != 1 {
+		t.Fatalf("worker runCnt = %d, want 1", worker.runCnt)
+	}
+}
+
+func TestAppRun_RejectsInvalidDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []Service
+		want     string
+	}{
+		{
+			name:     "unknown",
+			services: []Service{&dependentService{testService: testService{name: "a"}, deps: []string{"missing"}}},
+			want:     `service "a" depends on unknown service "missing"`,
+		},
+		{
+			name: "cycle",
+			services: []Service{
+				&dependentService{testService: testService{name: "a"}, deps: []string{"b"}},
+				&dependentService{testService: testService{name: "b"}, deps: []string{"a"}},
+			},
+			want: "service dependency cycle: a -> b -> a",
+		},
+		{
+			name: "duplicate",
+			services: []Service{
+				&dependentService{testService: testService{name: "a"}},
+				&dependentService{testService: testService{name: "a"}},
+				&dependentService{testService: testService{name: "b"}, deps: []string{"a"}},
+			},
+			want: "service names must be unique",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(nil).AddServices(tt.services...).Run(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Run() error = %v, want %q", err, tt.want)
+			}
+			for _, svc := range tt.services {
+				if n := svc.(*dependentService).runCnt; n != 0 {
+					t.Fatalf("service %q ran %d times, want 0", svc.Name(), n)
+				}
+			}
+		})
+	}
+}
+
+func TestAppRun_PreShutdownHooksRunBeforeServicesStop(t *testing.T) {
+	var events []string
+	svc := &testService{
+		name:  "svc",
+		runFn: func(context.Context) error { return nil },
+		stopFn: func(context.Context) error {
+			events = append(events, "stop")
+			return nil
+		},
+	}
+	a := New(nil).
+		AddServices(svc).
+		OnPreShutdown(func(*hook.Context) error {
+			events = append(events, "pre-shutdown")
+			return nil
+		}).
+		OnShutdown(func(*hook.Context) error {
+			events = append(events, "shutdown")
+			return nil
+		})
+
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []string{"pre-shutdown", "stop", "shutdown"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+}
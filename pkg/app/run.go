@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/HorseArcher567/octopus/pkg/hook"
 	"golang.org/x/sync/errgroup"
@@ -25,23 +26,48 @@ func (a *App) Run(ctx context.Context) (retErr error) {
 		retErr = errors.Join(retErr, a.shutdown())
 	}()
 
+	order, deps, err := startOrder(a.services)
+	if err != nil {
+		return err
+	}
+	a.stopOrder = make([]int, 0, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		a.stopOrder = append(a.stopOrder, order[i])
+	}
+
 	if err := a.runStartupHooks(ctx); err != nil {
 		return err
 	}
 
 	g, groupCtx := errgroup.WithContext(ctx)
-	for _, svc := range a.services {
-		svc := svc
-		a.log.Info("starting service", "service", svc.Name())
+	ready := make([]chan struct{}, len(a.services))
+	for i := range ready {
+		ready[i] = make(chan struct{})
+	}
+	for _, i := range order {
+		svc := a.services[i]
 		g.Go(func() error {
-			if err := svc.Run(groupCtx); err != nil {
-				a.log.Error("service exited with error", "service", svc.Name(), "error", err)
-				return fmt.Errorf("service %q: %w", svc.Name(), err)
+			for _, j := range deps[i] {
+				select {
+				case <-ready[j]:
+				case <-groupCtx.Done():
+					return nil
+				}
 			}
-			a.log.Info("service exited", "service", svc.Name())
-			return nil
+			return a.runService(groupCtx, svc, ready[i])
 		})
 	}
+	go func() {
+		for _, ch := range ready {
+			select {
+			case <-ch:
+			case <-groupCtx.Done():
+				return
+			}
+		}
+		a.log.Info("all services ready")
+		close(a.ready)
+	}()
 
 	waitErr := g.Wait()
 	if waitErr == nil {
@@ -53,6 +79,38 @@ func (a *App) Run(ctx context.Context) (retErr error) {
 	return waitErr
 }
 
+// runService runs svc and closes ready once the service reports readiness.
+// A service that does not implement Readier is ready as soon as it starts,
+// and a service that exits is treated as ready so dependents are not blocked.
+func (a *App) runService(ctx context.Context, svc Service, ready chan struct{}) error {
+	var once sync.Once
+	markReady := func() { once.Do(func() { close(ready) }) }
+	defer markReady()
+
+	a.log.Info("starting service", "service", svc.Name())
+	if r, ok := svc.(Readier); ok {
+		exited := make(chan struct{})
+		defer close(exited)
+		go func() {
+			select {
+			case <-r.Ready():
+				a.log.Info("service ready", "service", svc.Name())
+				markReady()
+			case <-exited:
+			}
+		}()
+	} else {
+		markReady()
+	}
+
+	if err := svc.Run(ctx); err != nil {
+		a.log.Error("service exited with error", "service", svc.Name(), "error", err)
+		return fmt.Errorf("service %q: %w", svc.Name(), err)
+	}
+	a.log.Info("service exited", "service", svc.Name())
+	return nil
+}
+
 func (a *App) runStartupHooks(ctx context.Context) error {
 	hookCtx := hook.NewContext(ctx, a.log, a.store)
 	for i, h := range a.startupHooks {
@@ -65,6 +123,19 @@ func (a *App) runStartupHooks(ctx context.Context) error {
 	return nil
 }
 
+func (a *App) runPreShutdownHooks(ctx context.Context) error {
+	hookCtx := hook.NewContext(ctx, a.log, a.store)
+	var errs []error
+	for i, h := range a.preShutdownHooks {
+		a.log.Info("running pre-shutdown hook", "hook", i)
+		if err := h(hookCtx); err != nil {
+			a.log.Error("pre-shutdown hook failed", "hook", i, "error", err)
+			errs = append(errs, fmt.Errorf("pre-shutdown hook %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (a *App) runShutdownHooks(ctx context.Context) error {
 	hookCtx := hook.NewContext(ctx, a.log, a.store)
 	var errs []error
@@ -89,6 +160,9 @@ func (a *App) shutdown() error {
 		defer cancel()
 
 		var errs []error
+		if err := a.runPreShutdownHooks(shutdownCtx); err != nil {
+			errs = append(errs, err)
+		}
 		err := a.stopServices(shutdownCtx)
 		if err == nil {
 			a.log.Info("all services stopped")
@@ -112,9 +186,17 @@ func (a *App) shutdown() error {
 	return shutdownErr
 }
 
+// stopServices stops services so that each one stops before the services it
+// depends on. Without dependencies this is reverse registration order.
 func (a *App) stopServices(ctx context.Context) error {
+	order := a.stopOrder
+	if order == nil {
+		for i := len(a.services) - 1; i >= 0; i-- {
+			order = append(order, i)
+		}
+	}
 	var errs []error
-	for i := len(a.services) - 1; i >= 0; i-- {
+	for _, i := range order {
 		svc := a.services[i]
 		a.log.Info("stopping service", "service", svc.Name())
 		if err := svc.Stop(ctx); err != nil {
@@ -3,20 +3,29 @@ package assemble
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/HorseArcher567/octopus/pkg/api"
+	"github.com/HorseArcher567/octopus/pkg/authz"
 	"github.com/HorseArcher567/octopus/pkg/config"
 	"github.com/HorseArcher567/octopus/pkg/hook"
+	"github.com/HorseArcher567/octopus/pkg/httpclient"
 	"github.com/HorseArcher567/octopus/pkg/job"
 	"github.com/HorseArcher567/octopus/pkg/rpc"
 	"github.com/HorseArcher567/octopus/pkg/store"
+	"github.com/HorseArcher567/octopus/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	grpcresolver "google.golang.org/grpc/resolver"
 )
 
@@ -184,6 +193,41 @@ func TestNew_DomainsCanRegisterHooksAndServices(t *testing.T) {
 	}
 }
 
+func TestNew_HealthShutdownDelay(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("health", map[string]any{"shutdownDelay": "100ms"})
+
+	var returned, stopped time.Time
+	a, err := New(cfg, WithDomains(func(ctx *DomainContext) error {
+		ctx.AddService(&testService{
+			name: "custom",
+			run: func(context.Context) error {
+				returned = time.Now()
+				return nil
+			},
+			stop: func(context.Context) error {
+				stopped = time.Now()
+				return nil
+			},
+		})
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if d := stopped.Sub(returned); d < 100*time.Millisecond {
+		t.Fatalf("service stopped %v after shutdown began, want the 100ms drain delay first", d)
+	}
+
+	cfg.Set("health", map[string]any{"shutdownDelay": "-1s"})
+	if _, err := New(cfg); err == nil || !strings.Contains(err.Error(), "shutdownDelay and cacheTTL cannot be negative") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
 func TestLoad(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
@@ -217,6 +261,67 @@ func TestContext_RegisterWithoutConfiguredRuntime(t *testing.T) {
 	}
 }
 
+func TestContext_DependOn(t *testing.T) {
+	cfg := minimalConfig()
+	var events []string
+	a, err := New(cfg, WithDomains(func(ctx *DomainContext) error {
+		if err := ctx.DependOn(ServiceRPC, "warmer"); !errors.Is(err, ErrRPCNotConfigured) {
+			return fmt.Errorf("DependOn(rpc) error = %v", err)
+		}
+		if err := ctx.DependOn("bogus", "warmer"); err == nil {
+			return errors.New("expected unknown builtin service error")
+		}
+		ctx.AddService(&testService{
+			name: "warmer",
+			run: func(context.Context) error {
+				events = append(events, "warmer-run")
+				return nil
+			},
+			stop: func(context.Context) error {
+				events = append(events, "warmer-stop")
+				return nil
+			},
+		})
+		if err := ctx.DependOn(ServiceJobs, "warmer"); err != nil {
+			return err
+		}
+		return ctx.RegisterJob("job", func(*job.Context) error {
+			events = append(events, "job-run")
+			return nil
+		})
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []string{"warmer-run", "job-run", "warmer-stop"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+}
+
+func TestContext_RegisterHealthCheck(t *testing.T) {
+	cfg := minimalConfig()
+	a, err := New(cfg, WithDomains(func(ctx *DomainContext) error {
+		if err := ctx.RegisterHealthCheck("cache", func(context.Context) error { return nil }); err != nil {
+			return err
+		}
+		if err := ctx.RegisterHealthCheck("jobs", func(context.Context) error { return nil }); err == nil {
+			return errors.New("expected duplicate check error for builtin jobs check")
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if a == nil {
+		t.Fatalf("New() returned nil app")
+	}
+}
+
 func TestContext_RegisterJob_DefaultSchedulerAvailable(t *testing.T) {
 	cfg := minimalConfig()
 	_, err := New(cfg, WithDomains(func(ctx *DomainContext) error {
@@ -227,6 +332,324 @@ func TestContext_RegisterJob_DefaultSchedulerAvailable(t *testing.T) {
 	}
 }
 
+func TestContext_RegisterJob_WithScheduleAndConfigOverride(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("jobScheduler.jobs", map[string]any{
+		"report": map[string]any{"schedule": "0 3 * * *"},
+	})
+	_, err := New(cfg, WithDomains(func(ctx *DomainContext) error {
+		return ctx.RegisterJob("report", func(*job.Context) error { return nil }, job.WithSchedule("@every 1m"))
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_JobSchedulerRejectsInvalidSchedule(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("jobScheduler.jobs", map[string]any{
+		"report": map[string]any{"schedule": "bogus"},
+	})
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: setup jobs: assemble: job scheduler: job: jobs[report]:") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_JobSchedulerLeaderElectionRequiresEtcdClient(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("jobScheduler.leaderElection", map[string]any{"etcd": "missing"})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: setup jobs: assemble: jobScheduler.leaderElection.etcd:") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_JobSchedulerLockRequiresRedisClient(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("jobScheduler.lock", map[string]any{"redis": "missing"})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: setup jobs: assemble: jobScheduler.lock.redis:") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_JobSchedulerAdminRequiresAPIServer(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("jobScheduler.admin", map[string]any{"enabled": true})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: jobScheduler.admin requires apiServer") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestContext_RegisterJob_SingletonRequiresLeaderElection(t *testing.T) {
+	_, err := New(minimalConfig(), WithDomains(func(ctx *DomainContext) error {
+		return ctx.RegisterJob("report", func(*job.Context) error { return nil }, job.WithSingleton())
+	}))
+	if err == nil || !strings.Contains(err.Error(), "singleton requires a leader elector") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_RateLimitRedisMustExist(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("rpcServer", map[string]any{
+		"name": "rpc", "host": "127.0.0.1", "port": 19090,
+		"rateLimit": map[string]any{
+			"redis": "cache",
+			"rules": []any{map[string]any{"name": "all", "limit": 10}},
+		},
+	})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: rpcServer.rateLimit.redis") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_RateLimitValidatesRules(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{
+		"name": "api", "host": "127.0.0.1", "port": 18080,
+		"rateLimit": map[string]any{"rules": []any{map[string]any{"name": "all"}}},
+	})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "limit must be positive") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_AuthLoadsJWKS(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{
+		"name": "api", "host": "127.0.0.1", "port": 18080,
+		"auth": map[string]any{"jwt": map[string]any{"jwksFile": filepath.Join(t.TempDir(), "missing.json")}},
+	})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: apiServer.auth: auth: jwt: read jwks") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_AuthKeepsMetricsPublic(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("metrics", map[string]any{"enabled": true})
+	cfg.Set("apiServer", map[string]any{
+		"name": "api", "host": "127.0.0.1", "port": 18080,
+		"auth": map[string]any{"apiKeys": map[string]any{"keys": []any{map[string]any{"name": "ci", "key": "ci-key"}}}},
+	})
+
+	st, err := setup(cfg)
+	if err != nil {
+		t.Fatalf("setup() error = %v", err)
+	}
+	defer st.store.Close()
+	engine := st.api.(*api.Server).Engine()
+	for path, want := range map[string]int{"/metrics": http.StatusOK, "/private": http.StatusUnauthorized} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("anonymous GET %s = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestNew_RateLimitsByIPBeforeAuth(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{
+		"name": "api", "host": "127.0.0.1", "port": 18080,
+		"auth": map[string]any{"apiKeys": map[string]any{"keys": []any{
+			map[string]any{"name": "ops", "key": "ops-key"},
+			map[string]any{"name": "ci", "key": "ci-key"},
+		}}},
+		"rateLimit": map[string]any{"rules": []any{
+			map[string]any{"name": "login", "match": []any{"/login"}, "limit": 1, "window": "1m"},
+			map[string]any{"name": "private", "match": []any{"/private"}, "key": "principal", "limit": 1, "window": "1m"},
+		}},
+	})
+
+	st, err := setup(cfg)
+	if err != nil {
+		t.Fatalf("setup() error = %v", err)
+	}
+	defer st.store.Close()
+	engine := st.api.(*api.Server).Engine()
+	do := func(key, path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// The IP rule rejects the second anonymous request before auth does.
+	for i, want := range []int{http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if got := do("", "/login"); got != want {
+			t.Fatalf("anonymous GET /login #%d = %d, want %d", i+1, got, want)
+		}
+	}
+	// The principal rule counts each authenticated subject.
+	for i, tt := range []struct {
+		key  string
+		want int
+	}{{"ops-key", http.StatusNotFound}, {"ops-key", http.StatusTooManyRequests}, {"ci-key", http.StatusNotFound}} {
+		if got := do(tt.key, "/private"); got != tt.want {
+			t.Fatalf("GET /private #%d as %s = %d, want %d", i+1, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestContext_AddAuthzRules(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{"name": "api", "host": "127.0.0.1", "port": 18080, "authz": map[string]any{}})
+	cfg.Set("rpcServer", map[string]any{"name": "rpc", "host": "127.0.0.1", "port": 19090})
+
+	_, err := New(cfg, WithDomains(func(ctx *DomainContext) error {
+		if err := ctx.AddAuthzRules(ServiceAPI, authz.Rule{Name: "admins", Match: []string{"/admin/*"}, Roles: []string{"admin"}}); err != nil {
+			return err
+		}
+		if err := ctx.AddAuthzRules(ServiceAPI, authz.Rule{}); err == nil || !strings.Contains(err.Error(), "name is required") {
+			return fmt.Errorf("expected invalid rule error, got %v", err)
+		}
+		if err := ctx.AddAuthzRules(ServiceRPC, authz.Rule{Name: "any"}); !errors.Is(err, ErrAuthzNotConfigured) {
+			return fmt.Errorf("expected authz not configured, got %v", err)
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_PropagationValidatesConfig(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("propagation", map[string]any{"headers": []any{"x-request-id", "grpc-timeout"}})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), `assemble: propagation: headers[1]: "grpc-timeout" is reserved`) {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_LoadShedValidatesConfig(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("rpcServer", map[string]any{
+		"name": "rpc", "host": "127.0.0.1", "port": 19090,
+		"loadShed": map[string]any{"algorithm": "vegas"},
+	})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "loadshed: algorithm must be gradient or aimd") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_APIGatewayRequiresRPCServer(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{"name": "api", "host": "127.0.0.1", "port": 18080, "gateway": map[string]any{}})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: apiServer.gateway requires rpcServer") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_APIConnectRequiresRPCServer(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{"name": "api", "host": "127.0.0.1", "port": 18080, "connect": map[string]any{}})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: apiServer.connect requires rpcServer") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_APIGatewayDependsOnRPCServer(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{"name": "api", "host": "127.0.0.1", "port": 18080, "gateway": map[string]any{}})
+	cfg.Set("rpcServer", map[string]any{"name": "rpc", "host": "127.0.0.1", "port": 19090})
+
+	st, err := setup(cfg)
+	if err != nil {
+		t.Fatalf("setup() error = %v", err)
+	}
+	defer st.store.Close()
+	for _, svc := range builtinServices(st, nil) {
+		if svc.Name() != ServiceAPI {
+			continue
+		}
+		if deps := svc.(*namedService).DependsOn(); !reflect.DeepEqual(deps, []string{ServiceRPC}) {
+			t.Fatalf("api DependsOn() = %v, want [%s]", deps, ServiceRPC)
+		}
+		return
+	}
+	t.Fatal("api service not found")
+}
+
+func TestNew_MetricsRequiresAPIServerOrAddr(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("metrics", map[string]any{"enabled": true})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: metrics requires apiServer or metrics.addr") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestContext_RegisterMetrics(t *testing.T) {
+	collector := prometheus.NewCounter(prometheus.CounterOpts{Name: "orders_total", Help: "Orders."})
+
+	_, err := New(minimalConfig(), WithDomains(func(ctx *DomainContext) error {
+		if err := ctx.RegisterMetrics(collector); !errors.Is(err, ErrMetricsNotConfigured) {
+			return fmt.Errorf("RegisterMetrics() error = %v, want %v", err, ErrMetricsNotConfigured)
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	cfg := minimalConfig()
+	cfg.Set("metrics", map[string]any{"enabled": true, "addr": "127.0.0.1:0"})
+	_, err = New(cfg, WithDomains(func(ctx *DomainContext) error {
+		return ctx.RegisterMetrics(collector)
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_TracingRequiresServiceName(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("tracing", map[string]any{"enabled": true})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: setup tracing: assemble: tracing: serviceName is required") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func TestNew_TracingProvidesProvider(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("tracing", map[string]any{"enabled": true, "serviceName": "demo", "exporter": "none"})
+
+	_, err := New(cfg, WithDomains(func(ctx *DomainContext) error {
+		_, err := store.GetNamed[*tracing.Provider](ctx, "tracing")
+		return err
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
 func TestNew_AppLoggerMustExistInConfiguredLoggers(t *testing.T) {
 	cfg := config.New()
 	cfg.Set("logger", []any{
@@ -358,3 +781,138 @@ func TestRegisterResolver_IgnoresDuplicateScheme(t *testing.T) {
 		t.Fatalf("second RegisterResolver() should be ignored")
 	}
 }
+
+func TestNew_RPCClientsProvidesConnections(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("rpcClients", []any{
+		map[string]any{
+			"name":                "users",
+			"target":              "passthrough:///127.0.0.1:9001",
+			"loadBalancingPolicy": "pick_first",
+			"timeout":             "3s",
+			"interceptors":        []any{"logging"},
+			"methods": []any{
+				map[string]any{
+					"service": "user.v1.UserService",
+					"timeout": "2s",
+					"retry":   map[string]any{"maxAttempts": 4, "retryableCodes": []any{"UNAVAILABLE"}},
+				},
+			},
+			"circuitBreaker": map[string]any{"errorRatio": 0.6, "cooldown": "10s"},
+		},
+	})
+
+	st, err := setup(cfg)
+	if err != nil {
+		t.Fatalf("setup() error = %v", err)
+	}
+	conn, err := store.GetNamed[*grpc.ClientConn](st.store, "users")
+	if err != nil {
+		t.Fatalf("GetNamed() error = %v", err)
+	}
+	if conn.Target() != "passthrough:///127.0.0.1:9001" {
+		t.Fatalf("conn target = %q", conn.Target())
+	}
+	if err := st.store.Close(); err != nil {
+		t.Fatalf("store Close() error = %v", err)
+	}
+	if conn.GetState() != connectivity.Shutdown {
+		t.Fatalf("conn state after store close = %s, want SHUTDOWN", conn.GetState())
+	}
+}
+
+func TestNew_RPCClientsValidation(t *testing.T) {
+	tests := []struct {
+		items []any
+		want  string
+	}{
+		{
+			items: []any{map[string]any{"target": "direct:///a:1"}},
+			want:  "assemble: rpcClients[0]: name is required",
+		},
+		{
+			items: []any{
+				map[string]any{"name": "users", "target": "direct:///a:1"},
+				map[string]any{"name": "users", "target": "direct:///b:1"},
+			},
+			want: "assemble: rpcClients[users]: duplicate name",
+		},
+		{
+			items: []any{map[string]any{"name": "users", "target": "direct:///a:1", "interceptors": []any{"missing"}}},
+			want:  `assemble: rpcClients[users]: unknown client interceptor "missing"`,
+		},
+		{
+			items: []any{map[string]any{"name": "users", "target": "direct:///a:1", "methods": []any{
+				map[string]any{"service": "a.S", "retry": map[string]any{"maxAttempts": 1}},
+			}}},
+			want: "assemble: rpcClients[users]: methods[0]: retry maxAttempts must be at least 2",
+		},
+	}
+	for _, tt := range tests {
+		cfg := minimalConfig()
+		cfg.Set("rpcClients", tt.items)
+		_, err := New(cfg)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("New() error = %v, want %q", err, tt.want)
+		}
+	}
+}
+
+func TestNew_HTTPClientsProvidesClients(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("httpClients", []any{
+		map[string]any{
+			"name":    "billing",
+			"baseURL": "http://billing.internal/api",
+			"timeout": "3s",
+			"retry":   map[string]any{"maxAttempts": 2},
+		},
+	})
+
+	st, err := setup(cfg)
+	if err != nil {
+		t.Fatalf("setup() error = %v", err)
+	}
+	defer st.store.Close()
+	client, err := store.GetNamed[*httpclient.Client](st.store, "billing")
+	if err != nil {
+		t.Fatalf("GetNamed() error = %v", err)
+	}
+	if got := client.URL("/invoices"); got != "http://billing.internal/api/invoices" {
+		t.Fatalf("URL() = %q", got)
+	}
+	if client.HTTPClient().Timeout != 3*time.Second {
+		t.Fatalf("timeout = %s, want 3s", client.HTTPClient().Timeout)
+	}
+}
+
+func TestNew_HTTPClientsValidation(t *testing.T) {
+	tests := []struct {
+		items []any
+		want  string
+	}{
+		{
+			items: []any{map[string]any{"baseURL": "http://a"}},
+			want:  "assemble: httpClients[0]: name is required",
+		},
+		{
+			items: []any{
+				map[string]any{"name": "billing", "baseURL": "http://a"},
+				map[string]any{"name": "billing", "baseURL": "http://b"},
+			},
+			want: "assemble: httpClients[billing]: duplicate name",
+		},
+		{
+			items: []any{map[string]any{"name": "billing", "baseURL": "billing/api"}},
+			want:  "assemble: httpClients[billing]: baseURL must be an absolute http or https URL",
+		},
+	}
+	for _, tt := range tests {
+		cfg := minimalConfig()
+		cfg.Set("httpClients", tt.items)
+		_, err := New(cfg)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("New() error = %v, want %q", err, tt.want)
+		}
+	}
+}
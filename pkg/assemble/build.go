@@ -1,11 +1,15 @@
 package assemble
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/HorseArcher567/octopus/pkg/app"
 	"github.com/HorseArcher567/octopus/pkg/config"
+	"github.com/HorseArcher567/octopus/pkg/hook"
 )
 
 func build(raw *config.Config, s *state, opts ...Option) (*app.App, error) {
@@ -109,9 +113,38 @@ func assembleApp(s *state, ctx *DomainContext, appCfg *app.Config) *app.App {
 		a.OnShutdown(h)
 	}
 	// Register builtin services after custom services so builtin services stop
-	// first during pkg/app's reverse-order shutdown.
-	for _, svc := range builtinServices(s) {
+	// first during pkg/app's reverse-order shutdown, unless dependencies
+	// declared through DependOn order them otherwise.
+	for _, svc := range builtinServices(s, ctx.builtinDeps) {
 		a.AddServices(svc)
 	}
+
+	// Readiness requires every service to be ready, and flips to not ready as
+	// soon as shutdown begins; services stop after health.shutdownDelay so
+	// that traffic drains first.
+	_ = s.health.Register("services", func(context.Context) error {
+		select {
+		case <-a.Ready():
+			return nil
+		default:
+			return errors.New("services not ready")
+		}
+	})
+	delay := s.shutdownDelay
+	a.OnPreShutdown(func(hc *hook.Context) error {
+		s.health.Shutdown()
+		if delay <= 0 {
+			return nil
+		}
+		hc.Logger().Info("waiting for traffic to drain", "delay", delay)
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-hc.Context().Done():
+			return hc.Context().Err()
+		}
+	})
 	return a
 }
@@ -7,11 +7,14 @@ import (
 
 	"github.com/HorseArcher567/octopus/pkg/api"
 	"github.com/HorseArcher567/octopus/pkg/app"
+	"github.com/HorseArcher567/octopus/pkg/authz"
 	"github.com/HorseArcher567/octopus/pkg/config"
+	"github.com/HorseArcher567/octopus/pkg/health"
 	"github.com/HorseArcher567/octopus/pkg/hook"
 	"github.com/HorseArcher567/octopus/pkg/job"
 	"github.com/HorseArcher567/octopus/pkg/store"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 )
 
@@ -19,16 +22,25 @@ type apiServer interface {
 	Register(func(*api.Engine)) error
 	Run(ctx context.Context) error
 	Stop(ctx context.Context) error
+	Ready() <-chan struct{}
 }
 
 type rpcServer interface {
 	Register(func(grpc.ServiceRegistrar)) error
 	Run(ctx context.Context) error
 	Stop(ctx context.Context) error
+	Ready() <-chan struct{}
+}
+
+type metricsServer interface {
+	Run(ctx context.Context) error
+	Stop(ctx context.Context) error
+	Ready() <-chan struct{}
 }
 
 type jobScheduler interface {
-	Add(name string, fn job.Func) error
+	Add(name string, fn job.Func, opts ...job.Option) error
+	Check(ctx context.Context) error
 	Run(ctx context.Context) error
 	Stop(ctx context.Context) error
 }
@@ -47,6 +59,7 @@ type DomainContext struct {
 	startupHooks  []hook.Func
 	shutdownHooks []hook.Func
 	services      []app.Service
+	builtinDeps   map[string][]string
 }
 
 func newSetupContext(cfg *config.Config, s *state) (*SetupContext, error) {
@@ -110,6 +123,12 @@ func (c *SetupContext) Provide(name string, value any, opts ...store.SetOption)
 	return c.inner.provide(name, value, opts...)
 }
 
+// RegisterHealthCheck adds a health check for a resource created by a custom
+// setup step. Checks count toward readiness unless health.Liveness is given.
+func (c *SetupContext) RegisterHealthCheck(name string, check health.Check, opts ...health.CheckOption) error {
+	return c.inner.state.health.Register(name, check, opts...)
+}
+
 func (c *DomainContext) Logger() *xlog.Logger { return c.state.log }
 
 func (c *DomainContext) RegisterAPI(fn func(*api.Engine)) error {
@@ -126,8 +145,54 @@ func (c *DomainContext) RegisterRPC(fn func(grpc.ServiceRegistrar)) error {
 	return c.state.rpc.Register(fn)
 }
 
-func (c *DomainContext) RegisterJob(name string, fn job.Func) error {
-	return c.state.job.Add(name, fn)
+// RegisterJob registers a background job. Without options the job runs once
+// at startup; use job.WithSchedule to run it on a cron or interval schedule.
+func (c *DomainContext) RegisterJob(name string, fn job.Func, opts ...job.Option) error {
+	return c.state.job.Add(name, fn, opts...)
+}
+
+// RegisterMetrics registers application-defined Prometheus collectors with the
+// registry served on the metrics endpoint.
+func (c *DomainContext) RegisterMetrics(cs ...prometheus.Collector) error {
+	if c.state.metrics == nil {
+		return ErrMetricsNotConfigured
+	}
+	return c.state.metrics.Register(cs...)
+}
+
+// RegisterHealthCheck adds a domain-defined health check reported by
+// /healthz, /readyz and grpc.health.v1. Checks count toward readiness; pass
+// health.Liveness() to have a failure also fail liveness.
+func (c *DomainContext) RegisterHealthCheck(name string, check health.Check, opts ...health.CheckOption) error {
+	return c.state.health.Register(name, check, opts...)
+}
+
+// AddAuthzRules adds authorization rules declared in code to the policy of
+// the builtin service (ServiceAPI or ServiceRPC), configured by its authz
+// section. Use Rule.Check for decisions that configuration cannot express.
+func (c *DomainContext) AddAuthzRules(service string, rules ...authz.Rule) error {
+	var policy *authz.Policy
+	switch service {
+	case ServiceAPI:
+		if c.state.api == nil {
+			return ErrAPINotConfigured
+		}
+		policy = c.state.apiAuthz
+	case ServiceRPC:
+		if c.state.rpc == nil {
+			return ErrRPCNotConfigured
+		}
+		policy = c.state.rpcAuthz
+	default:
+		return fmt.Errorf("assemble: unknown builtin service %q", service)
+	}
+	if policy == nil {
+		return ErrAuthzNotConfigured
+	}
+	if err := policy.AddRules(rules...); err != nil {
+		return fmt.Errorf("assemble: %s authz: %w", service, err)
+	}
+	return nil
 }
 
 func (c *DomainContext) OnStartup(h hook.Func) {
@@ -147,3 +212,29 @@ func (c *DomainContext) AddService(s app.Service) {
 		c.services = append(c.services, s)
 	}
 }
+
+// DependOn makes the builtin service (ServiceAPI, ServiceRPC or ServiceJobs)
+// start only after the named services are ready, and stop before them.
+// For example, DependOn(ServiceRPC, "cache-warmer") delays the RPC server and
+// its service discovery registration until the custom "cache-warmer" service
+// reports ready.
+func (c *DomainContext) DependOn(service string, deps ...string) error {
+	switch service {
+	case ServiceAPI:
+		if c.state.api == nil {
+			return ErrAPINotConfigured
+		}
+	case ServiceRPC:
+		if c.state.rpc == nil {
+			return ErrRPCNotConfigured
+		}
+	case ServiceJobs:
+	default:
+		return fmt.Errorf("assemble: unknown builtin service %q", service)
+	}
+	if c.builtinDeps == nil {
+		c.builtinDeps = make(map[string][]string)
+	}
+	c.builtinDeps[service] = append(c.builtinDeps[service], deps...)
+	return nil
+}
@@ -0,0 +1,111 @@
+package assemble
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/HorseArcher567/octopus/pkg/api"
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/authz"
+	"github.com/HorseArcher567/octopus/pkg/job"
+	"github.com/gin-gonic/gin"
+)
+
+type jobAdminError struct {
+	Error string `json:"error"`
+}
+
+// registerJobAdmin mounts the admin routes of scheduler under cfg.Path:
+//
+//	GET  {path}                list all jobs
+//	GET  {path}/:name          get one job
+//	POST {path}/:name/trigger  run a job now
+//	POST {path}/:name/pause    skip scheduled activations
+//	POST {path}/:name/resume   re-enable scheduled activations
+//
+// Only principals with one of cfg.Roles may use them; the auth middleware of
+// the API server authenticates the requests.
+func registerJobAdmin(r api.Router, scheduler *job.Scheduler, cfg job.AdminConfig) {
+	path := cfg.Path
+	if path == "" {
+		path = job.DefaultAdminPath
+	}
+	roles := cfg.Roles
+	if len(roles) == 0 {
+		roles = job.DefaultAdminRoles
+	}
+	h := jobAdmin{scheduler: scheduler}
+	g := r.Group(strings.TrimSuffix(path, "/"), requireRoles(roles))
+	{
+		g.GET("", h.list)
+		g.GET("/:name", h.get)
+		g.POST("/:name/trigger", h.action(scheduler.Trigger, http.StatusAccepted))
+		g.POST("/:name/pause", h.action(scheduler.Pause, http.StatusOK))
+		g.POST("/:name/resume", h.action(scheduler.Resume, http.StatusOK))
+	}
+}
+
+// requireRoles rejects requests whose principal has none of roles.
+func requireRoles(roles []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := auth.FromContext(c.Request.Context())
+		if !ok {
+			middleware.WriteError(c, auth.ErrUnauthenticated)
+			return
+		}
+		if !slices.ContainsFunc(roles, p.HasRole) {
+			middleware.WriteError(c, authz.ErrPermissionDenied)
+			return
+		}
+		c.Next()
+	}
+}
+
+type jobAdmin struct {
+	scheduler *job.Scheduler
+}
+
+func (h jobAdmin) list(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"jobs": h.scheduler.States()})
+}
+
+func (h jobAdmin) get(c *gin.Context) {
+	state, err := h.scheduler.State(c.Param("name"))
+	if err != nil {
+		writeJobAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+func (h jobAdmin) action(action func(string) error, status int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		if err := action(name); err != nil {
+			writeJobAdminError(c, err)
+			return
+		}
+		state, err := h.scheduler.State(name)
+		if err != nil {
+			writeJobAdminError(c, err)
+			return
+		}
+		c.JSON(status, state)
+	}
+}
+
+func writeJobAdminError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, job.ErrJobNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, job.ErrSchedulerNotRunning):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, job.ErrNotLeader), errors.Is(err, job.ErrJobRunning):
+		status = http.StatusConflict
+	}
+	c.JSON(status, jobAdminError{Error: err.Error()})
+}
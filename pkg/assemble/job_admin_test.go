@@ -0,0 +1,123 @@
+package assemble
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/api"
+	"github.com/HorseArcher567/octopus/pkg/job"
+)
+
+func TestJobAdminRoutes(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{
+		"name": "api", "host": "127.0.0.1", "port": 18080,
+		"auth": map[string]any{"apiKeys": map[string]any{"keys": []any{
+			map[string]any{"name": "ops", "key": "ops-key", "roles": []any{"admin"}},
+			map[string]any{"name": "ci", "key": "ci-key"},
+		}}},
+	})
+	cfg.Set("jobScheduler.admin", map[string]any{"enabled": true})
+
+	st, err := setup(cfg)
+	if err != nil {
+		t.Fatalf("setup() error = %v", err)
+	}
+	defer st.store.Close()
+	s := st.job.(*job.Scheduler)
+	var runs atomic.Int32
+	if err := s.Add("report.daily", func(*job.Context) error {
+		runs.Add(1)
+		return nil
+	}, job.WithSchedule("@daily")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	engine := st.api.(*api.Server).Engine()
+
+	do := func(key, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("", http.MethodPost, "/admin/jobs/report.daily/pause"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous pause: code = %d, want 401", rec.Code)
+	}
+	if rec := do("ci-key", http.MethodPost, "/admin/jobs/report.daily/pause"); rec.Code != http.StatusForbidden {
+		t.Fatalf("pause without admin role: code = %d, want 403", rec.Code)
+	}
+
+	rec := do("ops-key", http.MethodGet, "/admin/jobs")
+	var list struct {
+		Jobs []job.State `json:"jobs"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("list: code = %d, body = %s", rec.Code, rec.Body)
+	}
+	if len(list.Jobs) != 1 || list.Jobs[0].Name != "report.daily" || list.Jobs[0].Schedule != "@daily" {
+		t.Fatalf("list = %+v", list.Jobs)
+	}
+
+	if rec := do("ops-key", http.MethodGet, "/admin/jobs/missing"); rec.Code != http.StatusNotFound {
+		t.Fatalf("get missing: code = %d, want 404", rec.Code)
+	}
+	if rec := do("ops-key", http.MethodPost, "/admin/jobs/report.daily/trigger"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("trigger before Run: code = %d, want 503", rec.Code)
+	}
+
+	rec = do("ops-key", http.MethodPost, "/admin/jobs/report.daily/pause")
+	var state job.State
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || rec.Code != http.StatusOK || !state.Paused {
+		t.Fatalf("pause: code = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+	waitUntil(t, func() bool {
+		state, _ := s.State("report.daily")
+		return !state.NextFire.IsZero()
+	})
+
+	if rec := do("ops-key", http.MethodPost, "/admin/jobs/report.daily/trigger"); rec.Code != http.StatusAccepted {
+		t.Fatalf("trigger: code = %d, body = %s", rec.Code, rec.Body)
+	}
+	waitUntil(t, func() bool { return runs.Load() == 1 })
+
+	rec = do("ops-key", http.MethodPost, "/admin/jobs/report.daily/resume")
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || rec.Code != http.StatusOK || state.Paused {
+		t.Fatalf("resume: code = %d, body = %s", rec.Code, rec.Body)
+	}
+}
+
+func TestJobAdminRequiresAuth(t *testing.T) {
+	cfg := minimalConfig()
+	cfg.Set("apiServer", map[string]any{"name": "api", "host": "127.0.0.1", "port": 18080})
+	cfg.Set("jobScheduler.admin", map[string]any{"enabled": true})
+
+	_, err := New(cfg)
+	if err == nil || !strings.Contains(err.Error(), "assemble: jobScheduler.admin requires apiServer.auth") {
+		t.Fatalf("New() error = %v", err)
+	}
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
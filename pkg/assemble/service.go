@@ -4,23 +4,42 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/HorseArcher567/octopus/pkg/app"
 )
 
 var (
-	ErrAPINotConfigured = errors.New("assemble: api not configured")
-	ErrRPCNotConfigured = errors.New("assemble: rpc not configured")
+	ErrAPINotConfigured     = errors.New("assemble: api not configured")
+	ErrRPCNotConfigured     = errors.New("assemble: rpc not configured")
+	ErrMetricsNotConfigured = errors.New("assemble: metrics not configured")
+	ErrAuthzNotConfigured   = errors.New("assemble: authz not configured")
 )
 
 type namedService struct {
-	name string
-	run  func(context.Context) error
-	stop func(context.Context) error
+	name  string
+	run   func(context.Context) error
+	stop  func(context.Context) error
+	ready func() <-chan struct{}
+	deps  []string
 }
 
 func (s *namedService) Name() string { return s.name }
 
+// DependsOn implements app.Dependent.
+func (s *namedService) DependsOn() []string { return s.deps }
+
+// Ready implements app.Readier. A service without a readiness signal is
+// ready as soon as it starts.
+func (s *namedService) Ready() <-chan struct{} {
+	if s.ready != nil {
+		return s.ready()
+	}
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
 func (s *namedService) Run(ctx context.Context) error {
 	name := "<nil>"
 	if s != nil {
@@ -43,16 +62,37 @@ func (s *namedService) Stop(ctx context.Context) error {
 	return s.stop(ctx)
 }
 
-func builtinServices(s *state) []app.Service {
-	services := make([]app.Service, 0, 3)
+// Builtin service names, usable as dependencies of custom services and with
+// DomainContext.DependOn.
+const (
+	ServiceAPI     = "api"
+	ServiceRPC     = "rpc"
+	ServiceJobs    = "jobs"
+	ServiceMetrics = "metrics"
+)
+
+func builtinServices(s *state, deps map[string][]string) []app.Service {
+	services := make([]app.Service, 0, 4)
+	// The metrics listener is registered first so that it stops last and
+	// stays scrapeable while the other services drain.
+	if s.metricsServer != nil {
+		services = append(services, &namedService{name: ServiceMetrics, run: s.metricsServer.Run, stop: s.metricsServer.Stop, ready: s.metricsServer.Ready})
+	}
 	if s.api != nil {
-		services = append(services, &namedService{name: "api", run: s.api.Run, stop: s.api.Stop})
+		apiDeps := deps[ServiceAPI]
+		if s.apiCallsRPC {
+			// The gateway and the Connect handler call the rpc services
+			// in-process, so the api server starts after and stops before
+			// the rpc server.
+			apiDeps = append(slices.Clone(apiDeps), ServiceRPC)
+		}
+		services = append(services, &namedService{name: ServiceAPI, run: s.api.Run, stop: s.api.Stop, ready: s.api.Ready, deps: apiDeps})
 	}
 	if s.rpc != nil {
-		services = append(services, &namedService{name: "rpc", run: s.rpc.Run, stop: s.rpc.Stop})
+		services = append(services, &namedService{name: ServiceRPC, run: s.rpc.Run, stop: s.rpc.Stop, ready: s.rpc.Ready, deps: deps[ServiceRPC]})
 	}
 	if s.job != nil {
-		services = append(services, &namedService{name: "jobs", run: s.job.Run, stop: s.job.Stop})
+		services = append(services, &namedService{name: ServiceJobs, run: s.job.Run, stop: s.job.Stop, deps: deps[ServiceJobs]})
 	}
 	return services
 }
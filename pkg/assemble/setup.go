@@ -2,9 +2,16 @@ package assemble
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/authz"
 	"github.com/HorseArcher567/octopus/pkg/config"
+	"github.com/HorseArcher567/octopus/pkg/health"
+	"github.com/HorseArcher567/octopus/pkg/metrics"
+	"github.com/HorseArcher567/octopus/pkg/propagation"
 	"github.com/HorseArcher567/octopus/pkg/store"
+	"github.com/HorseArcher567/octopus/pkg/tracing"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
 )
 
@@ -12,9 +19,33 @@ type state struct {
 	log   *xlog.Logger
 	store store.Store
 
-	api apiServer
-	rpc rpcServer
-	job jobScheduler
+	api    apiServer
+	rpc    rpcServer
+	job    jobScheduler
+	health *health.Registry
+
+	// shutdownDelay is how long readiness reports down before services stop.
+	shutdownDelay time.Duration
+
+	// apiCallsRPC is set when the api server serves rpc services in-process.
+	apiCallsRPC bool
+
+	// apiAuth is the authentication chain of the api server, if configured.
+	apiAuth *auth.Chain
+
+	// apiAuthz and rpcAuthz hold the servers' authorization policies, to
+	// which domains add rules declared in code.
+	apiAuthz *authz.Policy
+	rpcAuthz *authz.Policy
+
+	metrics       *metrics.Registry
+	metricsServer metricsServer
+	metricsPath   string
+
+	tracing *tracing.Provider
+
+	// propagation captures the request values that outgoing calls forward.
+	propagation *propagation.Propagator
 }
 
 // setupContext is the internal setup-time context used by builtin setup steps.
@@ -30,15 +61,21 @@ type builtinSetupStep struct {
 }
 
 var builtinSetupSteps = []builtinSetupStep{
+	{name: "health", run: setupHealth},
 	{name: "loggers", run: setupLoggers},
 	{name: "app-logger", run: selectAppLogger},
+	{name: "metrics", run: setupMetrics},
+	{name: "tracing", run: setupTracing},
+	{name: "propagation", run: setupPropagation},
 	{name: "etcd", run: setupEtcd},
 	{name: "mysql", run: setupMySQL},
 	{name: "sqlite", run: setupSQLite},
 	{name: "redis", run: setupRedis},
 	{name: "rpc-resolver", run: setupRPCResolver},
-	{name: "api", run: setupAPI},
+	{name: "rpc-clients", run: setupRPCClients},
+	{name: "http-clients", run: setupHTTPClients},
 	{name: "rpc", run: setupRPC},
+	{name: "api", run: setupAPI},
 	{name: "jobs", run: setupJobs},
 }
 
@@ -82,3 +119,8 @@ func (c *setupContext) provide(name string, value any, opts ...store.SetOption)
 	}
 	return nil
 }
+
+// registerHealth adds a readiness check for a resource created during setup.
+func (c *setupContext) registerHealth(name string, check health.Check) error {
+	return c.state.health.Register(name, check)
+}
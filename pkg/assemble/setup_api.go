@@ -2,8 +2,15 @@ package assemble
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/HorseArcher567/octopus/pkg/api"
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/authz"
+	"github.com/HorseArcher567/octopus/pkg/health"
+	"github.com/HorseArcher567/octopus/pkg/loadshed"
+	"github.com/HorseArcher567/octopus/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
 )
 
 func setupAPI(c *setupContext) error {
@@ -18,10 +25,90 @@ func setupAPI(c *setupContext) error {
 	if err != nil {
 		return fmt.Errorf("assemble: apiServer.logger: %w", err)
 	}
-	server, err := api.NewServer(log, &cfg)
+	var opts []api.Option
+	if t := c.state.tracing; t != nil {
+		opts = append(opts, api.WithTracing(t.TracerProvider(), t.Propagator()))
+	}
+	if c.state.metrics != nil {
+		opts = append(opts, api.WithMiddleware(c.state.metrics.HTTPMiddleware()))
+	}
+	if cfg.LoadShed != nil {
+		shedCfg := *cfg.LoadShed
+		if c.state.metricsPath != "" {
+			shedCfg.Critical = append(slices.Clone(shedCfg.Critical), c.state.metricsPath)
+		}
+		shedder, err := loadshed.New(&shedCfg)
+		if err != nil {
+			return fmt.Errorf("assemble: apiServer.loadShed: %w", err)
+		}
+		opts = append(opts, api.WithMiddleware(shedder.HTTPMiddleware()))
+	}
+	var principalLimits *ratelimit.Policy
+	if cfg.RateLimit != nil {
+		admission, principal, err := c.rateLimitPolicies("apiServer", cfg.RateLimit, cfg.Auth != nil)
+		if err != nil {
+			return err
+		}
+		if admission != nil {
+			opts = append(opts, api.WithMiddleware(admission.HTTPMiddleware()))
+		}
+		principalLimits = principal
+	}
+	if cfg.Auth != nil {
+		authCfg := *cfg.Auth
+		if c.state.metricsPath != "" {
+			authCfg.Public = append(slices.Clone(authCfg.Public), c.state.metricsPath)
+		}
+		chain, err := auth.New(&authCfg)
+		if err != nil {
+			return fmt.Errorf("assemble: apiServer.auth: %w", err)
+		}
+		opts = append(opts, api.WithMiddleware(chain.HTTPMiddleware()))
+		c.state.apiAuth = chain
+	}
+	if cfg.Authz != nil {
+		policy, err := authz.New(cfg.Authz)
+		if err != nil {
+			return fmt.Errorf("assemble: apiServer.authz: %w", err)
+		}
+		opts = append(opts, api.WithMiddleware(policy.HTTPMiddleware()))
+		c.state.apiAuthz = policy
+	}
+	if p := c.state.propagation; p != nil {
+		opts = append(opts, api.WithMiddleware(p.HTTPMiddleware()))
+	}
+	if principalLimits != nil {
+		opts = append(opts, api.WithMiddleware(principalLimits.HTTPMiddleware()))
+	}
+	if cfg.Gateway != nil {
+		backend, ok := c.state.rpc.(api.RPCBackend)
+		if !ok {
+			return fmt.Errorf("assemble: apiServer.gateway requires rpcServer")
+		}
+		opts = append(opts, api.WithGateway(backend))
+		c.state.apiCallsRPC = true
+	}
+	if cfg.Connect != nil {
+		backend, ok := c.state.rpc.(api.RPCBackend)
+		if !ok {
+			return fmt.Errorf("assemble: apiServer.connect requires rpcServer")
+		}
+		opts = append(opts, api.WithConnect(backend))
+		c.state.apiCallsRPC = true
+	}
+	server, err := api.NewServer(log, &cfg, opts...)
 	if err != nil {
 		return fmt.Errorf("assemble: api server: %w", err)
 	}
+	if err := server.Register(func(e *api.Engine) {
+		e.GET(health.LivenessPath, gin.WrapH(c.state.health.LivenessHandler()))
+		e.GET(health.ReadinessPath, gin.WrapH(c.state.health.ReadinessHandler()))
+		if c.state.metricsPath != "" {
+			e.GET(c.state.metricsPath, gin.WrapH(c.state.metrics.Handler()))
+		}
+	}); err != nil {
+		return fmt.Errorf("assemble: api server: %w", err)
+	}
 	c.state.api = server
 	return nil
 }
@@ -1,11 +1,14 @@
 package assemble
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/HorseArcher567/octopus/pkg/config"
 	"github.com/HorseArcher567/octopus/pkg/etcd"
+	"github.com/HorseArcher567/octopus/pkg/health"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 func setupEtcd(c *setupContext) error {
@@ -48,10 +51,21 @@ func setupEtcd(c *setupContext) error {
 			_ = client.Close()
 			return fmt.Errorf("assemble: etcd[%s]: %w", item.Name, err)
 		}
+		if err := c.registerHealth("etcd/"+item.Name, etcdHealthCheck(client)); err != nil {
+			return fmt.Errorf("assemble: etcd[%s]: %w", item.Name, err)
+		}
 	}
 	return nil
 }
 
+// etcdHealthCheck reads a key the same way etcdctl endpoint health does.
+func etcdHealthCheck(client *clientv3.Client) health.Check {
+	return func(ctx context.Context) error {
+		_, err := client.Get(ctx, "health")
+		return err
+	}
+}
+
 func validateEtcdConfigs(items []etcd.Config) error {
 	seen := make(map[string]struct{}, len(items))
 	for i, item := range items {
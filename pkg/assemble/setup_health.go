@@ -0,0 +1,26 @@
+package assemble
+
+import (
+	"fmt"
+
+	"github.com/HorseArcher567/octopus/pkg/health"
+)
+
+func setupHealth(c *setupContext) error {
+	var cfg health.Config
+	if _, ok := c.get("health"); ok {
+		if err := c.decodeStruct("health", &cfg); err != nil {
+			return err
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("assemble: %w", err)
+		}
+	}
+	var opts []health.Option
+	if cfg.CacheTTL > 0 {
+		opts = append(opts, health.WithCacheTTL(cfg.CacheTTL))
+	}
+	c.state.health = health.NewRegistry(opts...)
+	c.state.shutdownDelay = cfg.ShutdownDelay
+	return nil
+}
@@ -0,0 +1,79 @@
+package assemble
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HorseArcher567/octopus/pkg/config"
+	"github.com/HorseArcher567/octopus/pkg/httpclient"
+	"github.com/HorseArcher567/octopus/pkg/store"
+)
+
+func setupHTTPClients(c *setupContext) error {
+	value, ok := c.get("httpClients")
+	if !ok {
+		return nil
+	}
+	rawItems, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("decode config %q: invalid type %T", "httpClients", value)
+	}
+	items := make([]httpclient.Config, 0, len(rawItems))
+	for i, raw := range rawItems {
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("assemble: httpClients[%d]: invalid config type %T", i, raw)
+		}
+		tmp := config.New()
+		for k, v := range m {
+			tmp.Set(k, v)
+		}
+		var item httpclient.Config
+		if err := tmp.UnmarshalStrict(&item); err != nil {
+			return fmt.Errorf("assemble: httpClients[%d]: %w", i, err)
+		}
+		items = append(items, item)
+	}
+	if err := validateHTTPClientConfigs(items); err != nil {
+		return err
+	}
+
+	opts := []httpclient.Option{httpclient.WithLogger(c.state.log)}
+	if t := c.state.tracing; t != nil {
+		opts = append(opts, httpclient.WithTracing(t.TracerProvider(), t.Propagator()))
+	}
+	if c.state.propagation != nil {
+		opts = append(opts, httpclient.WithPropagation())
+	}
+	for _, item := range items {
+		client, err := httpclient.New(&item, opts...)
+		if err != nil {
+			return fmt.Errorf("assemble: httpClients[%s]: %w", item.Name, err)
+		}
+		if err := c.provide(item.Name, client, store.WithClose(client.Close)); err != nil {
+			_ = client.Close()
+			return fmt.Errorf("assemble: httpClients[%s]: %w", item.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateHTTPClientConfigs(items []httpclient.Config) error {
+	seen := make(map[string]struct{}, len(items))
+	for i := range items {
+		item := &items[i]
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			return fmt.Errorf("assemble: httpClients[%d]: name is required", i)
+		}
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("assemble: httpClients[%s]: duplicate name", name)
+		}
+		seen[name] = struct{}{}
+		item.Normalize()
+		if err := item.Validate(); err != nil {
+			return fmt.Errorf("assemble: httpClients[%s]: %w", name, err)
+		}
+	}
+	return nil
+}
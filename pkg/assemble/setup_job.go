@@ -3,7 +3,12 @@ package assemble
 import (
 	"fmt"
 
+	"github.com/HorseArcher567/octopus/pkg/api"
 	"github.com/HorseArcher567/octopus/pkg/job"
+	"github.com/HorseArcher567/octopus/pkg/lock"
+	redisclient "github.com/HorseArcher567/octopus/pkg/redis"
+	"github.com/HorseArcher567/octopus/pkg/store"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 func setupJobs(c *setupContext) error {
@@ -17,6 +22,44 @@ func setupJobs(c *setupContext) error {
 	if err != nil {
 		return fmt.Errorf("assemble: jobScheduler.logger: %w", err)
 	}
-	c.state.job = job.NewScheduler(log, c.state.store)
+	var opts []job.SchedulerOption
+	if le := cfg.LeaderElection; le != nil {
+		client, err := store.GetNamed[*clientv3.Client](c.state.store, le.Etcd)
+		if err != nil {
+			return fmt.Errorf("assemble: jobScheduler.leaderElection.etcd: %w", err)
+		}
+		opts = append(opts, job.WithElector(job.NewEtcdElector(log, client, le.Prefix, le.TTL)))
+	}
+	if lc := cfg.Lock; lc != nil {
+		client, err := store.GetNamed[*redisclient.Client](c.state.store, lc.Redis)
+		if err != nil {
+			return fmt.Errorf("assemble: jobScheduler.lock.redis: %w", err)
+		}
+		opts = append(opts, job.WithLocker(lock.New(client, lock.WithPrefix(lc.Prefix))))
+	}
+	if c.state.metrics != nil {
+		opts = append(opts, job.WithObserver(c.state.metrics))
+	}
+	scheduler, err := job.NewSchedulerWithConfig(log, c.state.store, &cfg, opts...)
+	if err != nil {
+		return fmt.Errorf("assemble: job scheduler: %w", err)
+	}
+	if cfg.Admin.Enabled {
+		if c.state.api == nil {
+			return fmt.Errorf("assemble: jobScheduler.admin requires apiServer")
+		}
+		if c.state.apiAuth == nil {
+			return fmt.Errorf("assemble: jobScheduler.admin requires apiServer.auth")
+		}
+		if err := c.state.api.Register(func(e *api.Engine) {
+			registerJobAdmin(e, scheduler, cfg.Admin)
+		}); err != nil {
+			return fmt.Errorf("assemble: jobScheduler.admin: %w", err)
+		}
+	}
+	if err := c.registerHealth("jobs", scheduler.Check); err != nil {
+		return fmt.Errorf("assemble: job scheduler: %w", err)
+	}
+	c.state.job = scheduler
 	return nil
 }
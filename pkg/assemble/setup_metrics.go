@@ -0,0 +1,58 @@
+package assemble
+
+import (
+	"fmt"
+
+	"github.com/HorseArcher567/octopus/pkg/metrics"
+)
+
+func setupMetrics(c *setupContext) error {
+	if _, ok := c.get("metrics"); !ok {
+		return nil
+	}
+	var cfg metrics.Config
+	if err := c.decodeStruct("metrics", &cfg); err != nil {
+		return err
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("assemble: %w", err)
+	}
+	cfg.Normalize()
+
+	reg := metrics.New(metrics.WithNamespace(cfg.Namespace))
+	if cfg.Addr != "" {
+		server, err := metrics.NewServer(c.state.log, &cfg, reg)
+		if err != nil {
+			return fmt.Errorf("assemble: metrics server: %w", err)
+		}
+		c.state.metricsServer = server
+	} else {
+		if _, ok := c.get("apiServer"); !ok {
+			return fmt.Errorf("assemble: metrics requires apiServer or metrics.addr")
+		}
+		c.state.metricsPath = cfg.Path
+	}
+	c.state.metrics = reg
+	return nil
+}
+
+// observeDB exports the connection pool stats of a database created during
+// setup when metrics are enabled.
+func (c *setupContext) observeDB(name string, db metrics.DBStatser) error {
+	if c.state.metrics == nil {
+		return nil
+	}
+	return c.state.metrics.RegisterDB(name, db)
+}
+
+// observeRedis exports the connection pool stats of a Redis client created
+// during setup when metrics are enabled.
+func (c *setupContext) observeRedis(name string, client metrics.RedisPoolStatser) error {
+	if c.state.metrics == nil {
+		return nil
+	}
+	return c.state.metrics.RegisterRedis(name, client)
+}
@@ -49,6 +49,13 @@ func setupMySQL(c *setupContext) error {
 			_ = db.Close()
 			return fmt.Errorf("assemble: mysql[%s]: %w", item.Name, err)
 		}
+		if err := c.registerHealth("mysql/"+item.Name, db.PingContext); err != nil {
+			return fmt.Errorf("assemble: mysql[%s]: %w", item.Name, err)
+		}
+		c.traceDB(db)
+		if err := c.observeDB("mysql/"+item.Name, db); err != nil {
+			return fmt.Errorf("assemble: mysql[%s]: %w", item.Name, err)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,23 @@
+package assemble
+
+import (
+	"fmt"
+
+	"github.com/HorseArcher567/octopus/pkg/propagation"
+)
+
+func setupPropagation(c *setupContext) error {
+	if _, ok := c.get("propagation"); !ok {
+		return nil
+	}
+	var cfg propagation.Config
+	if err := c.decodeStruct("propagation", &cfg); err != nil {
+		return err
+	}
+	p, err := propagation.New(&cfg)
+	if err != nil {
+		return fmt.Errorf("assemble: %w", err)
+	}
+	c.state.propagation = p
+	return nil
+}
@@ -0,0 +1,67 @@
+package assemble
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/ratelimit"
+	redisclient "github.com/HorseArcher567/octopus/pkg/redis"
+	"github.com/HorseArcher567/octopus/pkg/store"
+)
+
+// rateLimitPolicies splits the rate limit rules of the server configured
+// under key by when they can run. With authenticated requests, rules keyed by
+// principal need the auth subject and go to principal, which runs after
+// authentication; the others go to admission, which runs before it so that
+// rejected requests skip authentication. Either policy is nil without rules.
+func (c *setupContext) rateLimitPolicies(key string, cfg *ratelimit.Config, authenticated bool) (admission, principal *ratelimit.Policy, err error) {
+	all := *cfg
+	all.Rules = slices.Clone(cfg.Rules)
+	all.Normalize()
+	if err := all.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("assemble: %s.rateLimit: %w", key, err)
+	}
+	before, after := all, all
+	before.Rules, after.Rules = nil, nil
+	for _, r := range all.Rules {
+		if authenticated && r.Key == ratelimit.KeyPrincipal {
+			after.Rules = append(after.Rules, r)
+		} else {
+			before.Rules = append(before.Rules, r)
+		}
+	}
+	if len(before.Rules) > 0 {
+		if admission, err = c.rateLimitPolicy(key, &before, false); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(after.Rules) > 0 {
+		if principal, err = c.rateLimitPolicy(key, &after, true); err != nil {
+			return nil, nil, err
+		}
+	}
+	return admission, principal, nil
+}
+
+// rateLimitPolicy builds the rate limit policy of the server configured
+// under key, resolving its Redis client from the store. With authenticated
+// requests, the "principal" key is their auth subject.
+func (c *setupContext) rateLimitPolicy(key string, cfg *ratelimit.Config, authenticated bool) (*ratelimit.Policy, error) {
+	var opts []ratelimit.Option
+	if authenticated {
+		opts = append(opts, ratelimit.WithPrincipal(auth.SubjectFromContext))
+	}
+	if cfg.Redis != "" {
+		client, err := store.GetNamed[*redisclient.Client](c.state.store, cfg.Redis)
+		if err != nil {
+			return nil, fmt.Errorf("assemble: %s.rateLimit.redis: %w", key, err)
+		}
+		opts = append(opts, ratelimit.WithRedis(client))
+	}
+	policy, err := ratelimit.New(cfg, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("assemble: %s.rateLimit: %w", key, err)
+	}
+	return policy, nil
+}
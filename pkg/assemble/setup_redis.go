@@ -1,6 +1,7 @@
 package assemble
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -53,6 +54,13 @@ func setupRedis(c *setupContext) error {
 			_ = client.Close()
 			return fmt.Errorf("assemble: redis[%s]: %w", item.Name, err)
 		}
+		if err := c.registerHealth("redis/"+item.Name, func(ctx context.Context) error { return client.Ping(ctx).Err() }); err != nil {
+			return fmt.Errorf("assemble: redis[%s]: %w", item.Name, err)
+		}
+		c.traceRedis(client)
+		if err := c.observeRedis(item.Name, client); err != nil {
+			return fmt.Errorf("assemble: redis[%s]: %w", item.Name, err)
+		}
 	}
 	return nil
 }
@@ -4,7 +4,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/authz"
 	"github.com/HorseArcher567/octopus/pkg/discovery"
+	"github.com/HorseArcher567/octopus/pkg/health"
+	"github.com/HorseArcher567/octopus/pkg/loadshed"
+	"github.com/HorseArcher567/octopus/pkg/ratelimit"
 	"github.com/HorseArcher567/octopus/pkg/rpc"
 	"github.com/HorseArcher567/octopus/pkg/store"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -37,10 +42,68 @@ func setupRPC(c *setupContext) error {
 		opts = append(opts, rpc.WithRegistrar(discovery.NewEtcdRegistrar(log, client)))
 	}
 
+	if t := c.state.tracing; t != nil {
+		opts = append(opts, rpc.WithTracing(t.TracerProvider(), t.Propagator()))
+	}
+	if m := c.state.metrics; m != nil {
+		opts = append(opts, rpc.WithAdmission(m.UnaryServerInterceptor(), m.StreamServerInterceptor()))
+	}
+	if cfg.LoadShed != nil {
+		shedder, err := loadshed.New(cfg.LoadShed)
+		if err != nil {
+			return fmt.Errorf("assemble: rpcServer.loadShed: %w", err)
+		}
+		opts = append(opts, rpc.WithAdmission(shedder.UnaryServerInterceptor(), shedder.StreamServerInterceptor()))
+	}
+	var principalLimits *ratelimit.Policy
+	if cfg.RateLimit != nil {
+		admission, principal, err := c.rateLimitPolicies("rpcServer", cfg.RateLimit, cfg.Auth != nil)
+		if err != nil {
+			return err
+		}
+		if admission != nil {
+			opts = append(opts, rpc.WithAdmission(admission.UnaryServerInterceptor(), admission.StreamServerInterceptor()))
+		}
+		principalLimits = principal
+	}
+	if cfg.Auth != nil {
+		chain, err := auth.New(cfg.Auth)
+		if err != nil {
+			return fmt.Errorf("assemble: rpcServer.auth: %w", err)
+		}
+		opts = append(opts, rpc.WithAuth(chain.UnaryServerInterceptor(), chain.StreamServerInterceptor()))
+	}
+	if cfg.Authz != nil {
+		policy, err := authz.New(cfg.Authz)
+		if err != nil {
+			return fmt.Errorf("assemble: rpcServer.authz: %w", err)
+		}
+		opts = append(opts,
+			rpc.WithUnaryInterceptors(policy.UnaryServerInterceptor()),
+			rpc.WithStreamInterceptors(policy.StreamServerInterceptor()),
+		)
+		c.state.rpcAuthz = policy
+	}
+	if p := c.state.propagation; p != nil {
+		opts = append(opts,
+			rpc.WithUnaryInterceptors(p.UnaryServerInterceptor()),
+			rpc.WithStreamInterceptors(p.StreamServerInterceptor()),
+		)
+	}
+	if principalLimits != nil {
+		opts = append(opts,
+			rpc.WithUnaryInterceptors(principalLimits.UnaryServerInterceptor()),
+			rpc.WithStreamInterceptors(principalLimits.StreamServerInterceptor()),
+		)
+	}
+
 	server, err := rpc.NewServer(log, &cfg, opts...)
 	if err != nil {
 		return fmt.Errorf("assemble: rpc server: %w", err)
 	}
+	if err := server.Register(health.NewGRPCServer(c.state.health, 0).Register); err != nil {
+		return fmt.Errorf("assemble: rpc server: %w", err)
+	}
 	c.state.rpc = server
 
 	return nil
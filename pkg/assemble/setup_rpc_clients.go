@@ -0,0 +1,83 @@
+package assemble
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HorseArcher567/octopus/pkg/config"
+	"github.com/HorseArcher567/octopus/pkg/propagation"
+	"github.com/HorseArcher567/octopus/pkg/rpc"
+	"github.com/HorseArcher567/octopus/pkg/store"
+	"google.golang.org/grpc"
+)
+
+func setupRPCClients(c *setupContext) error {
+	value, ok := c.get("rpcClients")
+	if !ok {
+		return nil
+	}
+	rawItems, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("decode config %q: invalid type %T", "rpcClients", value)
+	}
+	items := make([]rpc.ClientConfig, 0, len(rawItems))
+	for i, raw := range rawItems {
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("assemble: rpcClients[%d]: invalid config type %T", i, raw)
+		}
+		tmp := config.New()
+		for k, v := range m {
+			tmp.Set(k, v)
+		}
+		var item rpc.ClientConfig
+		if err := tmp.UnmarshalStrict(&item); err != nil {
+			return fmt.Errorf("assemble: rpcClients[%d]: %w", i, err)
+		}
+		items = append(items, item)
+	}
+	if err := validateRPCClientConfigs(items); err != nil {
+		return err
+	}
+
+	var opts []grpc.DialOption
+	if t := c.state.tracing; t != nil {
+		opts = append(opts, rpc.WithClientTracing(t.TracerProvider(), t.Propagator()))
+	}
+	if c.state.propagation != nil {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(propagation.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(propagation.StreamClientInterceptor()),
+		)
+	}
+	for _, item := range items {
+		// grpc.NewClient does not connect, so the first RPC dials the target.
+		conn, err := rpc.NewClientFromConfig(&item, opts...)
+		if err != nil {
+			return fmt.Errorf("assemble: rpcClients[%s]: %w", item.Name, err)
+		}
+		if err := c.provide(item.Name, conn, store.WithClose(conn.Close)); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("assemble: rpcClients[%s]: %w", item.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateRPCClientConfigs(items []rpc.ClientConfig) error {
+	seen := make(map[string]struct{}, len(items))
+	for i, item := range items {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			return fmt.Errorf("assemble: rpcClients[%d]: name is required", i)
+		}
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("assemble: rpcClients[%s]: duplicate name", name)
+		}
+		seen[name] = struct{}{}
+		if err := item.Validate(); err != nil {
+			return fmt.Errorf("assemble: rpcClients[%s]: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -53,6 +53,13 @@ func setupSQLite(c *setupContext) error {
 			_ = db.Close()
 			return fmt.Errorf("assemble: sqlite[%s]: %w", item.Name, err)
 		}
+		if err := c.registerHealth("sqlite/"+item.Name, db.PingContext); err != nil {
+			return fmt.Errorf("assemble: sqlite[%s]: %w", item.Name, err)
+		}
+		c.traceDB(db)
+		if err := c.observeDB("sqlite/"+item.Name, db); err != nil {
+			return fmt.Errorf("assemble: sqlite[%s]: %w", item.Name, err)
+		}
 	}
 	return nil
 }
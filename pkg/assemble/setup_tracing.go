@@ -0,0 +1,57 @@
+package assemble
+
+import (
+	"fmt"
+
+	"github.com/HorseArcher567/octopus/pkg/database"
+	redisclient "github.com/HorseArcher567/octopus/pkg/redis"
+	"github.com/HorseArcher567/octopus/pkg/store"
+	"github.com/HorseArcher567/octopus/pkg/tracing"
+	"go.opentelemetry.io/otel"
+)
+
+func setupTracing(c *setupContext) error {
+	if _, ok := c.get("tracing"); !ok {
+		return nil
+	}
+	var cfg tracing.Config
+	if err := c.decodeStruct("tracing", &cfg); err != nil {
+		return err
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	provider, err := tracing.New(&cfg)
+	if err != nil {
+		return fmt.Errorf("assemble: %w", err)
+	}
+	// The store is closed after all services stop, so pending spans are
+	// flushed once nothing can record new ones.
+	if err := c.provide("tracing", provider, store.WithClose(provider.Close)); err != nil {
+		_ = provider.Close()
+		return fmt.Errorf("assemble: tracing: %w", err)
+	}
+
+	// Register globally so that application code and third-party
+	// instrumentation join the same traces.
+	otel.SetTracerProvider(provider.TracerProvider())
+	otel.SetTextMapPropagator(provider.Propagator())
+	c.state.tracing = provider
+	return nil
+}
+
+// traceDB enables query tracing on a database created during setup when
+// tracing is enabled.
+func (c *setupContext) traceDB(db *database.DB) {
+	if c.state.tracing != nil {
+		db.EnableTracing(c.state.tracing.TracerProvider())
+	}
+}
+
+// traceRedis enables command tracing on a Redis client created during setup
+// when tracing is enabled.
+func (c *setupContext) traceRedis(client *redisclient.Client) {
+	if c.state.tracing != nil {
+		client.EnableTracing(c.state.tracing.TracerProvider())
+	}
+}
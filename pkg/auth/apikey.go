@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+)
+
+const defaultAPIKeyHeader = "X-API-Key"
+
+// APIKeys authenticates static API keys. Keys are compared by their SHA-256
+// hash in constant time.
+type APIKeys struct {
+	header string
+	keys   []apiKey
+}
+
+type apiKey struct {
+	name  string
+	hash  []byte
+	roles []string
+}
+
+// NewAPIKeys creates an API key authenticator from cfg.
+func NewAPIKeys(cfg *APIKeyConfig) (*APIKeys, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	a := &APIKeys{header: cfg.Header}
+	if a.header == "" {
+		a.header = defaultAPIKeyHeader
+	}
+	for _, k := range cfg.Keys {
+		var hash []byte
+		if k.Key != "" {
+			sum := sha256.Sum256([]byte(k.Key))
+			hash = sum[:]
+		} else {
+			hash, _ = hex.DecodeString(k.SHA256)
+		}
+		a.keys = append(a.keys, apiKey{name: k.Name, hash: hash, roles: slices.Clone(k.Roles)})
+	}
+	return a, nil
+}
+
+// Authenticate implements Authenticator.
+func (a *APIKeys) Authenticate(_ context.Context, req *Request) (*Principal, error) {
+	key := req.Header(a.header)
+	if key == "" {
+		scheme, value, _ := strings.Cut(req.Header("Authorization"), " ")
+		if strings.EqualFold(scheme, "ApiKey") {
+			key = strings.TrimSpace(value)
+		}
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.hash) == 1 {
+			return &Principal{Subject: k.name, Method: MethodAPIKey, Roles: slices.Clone(k.roles)}, nil
+		}
+	}
+	return nil, invalid(errors.New("unknown api key"))
+}
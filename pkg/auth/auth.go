@@ -0,0 +1,108 @@
+// Package auth authenticates HTTP requests and gRPC calls.
+//
+// An Authenticator turns the credentials of a request into a Principal: JWT
+// bearer tokens signed with HMAC, RSA or ECDSA keys, static API keys, or the
+// verified client certificate of a mutual TLS connection. A Chain tries its
+// authenticators in order and is applied as Gin middleware and as gRPC server
+// interceptors. It stores the Principal in the request context, where
+// handlers read it with FromContext, and adds it to the request logger.
+//
+// Requests without valid credentials are rejected with UNAUTHENTICATED
+// (HTTP 401), except on public routes, which accept anonymous requests.
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	stderrors "errors"
+	"slices"
+
+	"github.com/HorseArcher567/octopus/pkg/errors"
+)
+
+// Authentication methods of a Principal.
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "apikey"
+	MethodMTLS   = "mtls"
+)
+
+var (
+	// ErrUnauthenticated is returned for requests without credentials.
+	ErrUnauthenticated = errors.New(errors.Unauthenticated, "authentication required").WithReason("UNAUTHENTICATED")
+
+	// ErrInvalidCredentials is returned for requests whose credentials are
+	// wrong, expired or malformed. The cause is kept for logging only.
+	ErrInvalidCredentials = errors.New(errors.Unauthenticated, "invalid credentials").WithReason("INVALID_CREDENTIALS")
+
+	// ErrNoCredentials is returned by an Authenticator when the request does
+	// not carry its kind of credentials, so that the next one is tried.
+	ErrNoCredentials = stderrors.New("auth: no credentials")
+)
+
+// Principal is the authenticated identity of a request.
+type Principal struct {
+	// Subject identifies the caller: the JWT subject, the name of the API
+	// key, or the identity of the client certificate.
+	Subject string
+
+	// Method is the authentication method, e.g. MethodJWT.
+	Method string
+
+	// Roles are the roles granted to the caller.
+	Roles []string
+
+	// Claims are the claims of the JWT; nil for other methods.
+	Claims map[string]any
+}
+
+// HasRole reports whether the principal has role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Request is what authenticators see of an HTTP request or gRPC call.
+type Request struct {
+	// Header returns the first value of an HTTP header or of a gRPC
+	// metadata key, matched case-insensitively.
+	Header func(name string) string
+
+	// TLS is the state of the connection, nil for plaintext ones.
+	TLS *tls.ConnectionState
+}
+
+// Authenticator authenticates requests with one kind of credentials.
+type Authenticator interface {
+	// Authenticate returns the principal of req. It returns
+	// ErrNoCredentials if req does not carry credentials of its kind, and
+	// an error matching ErrInvalidCredentials if they are not valid.
+	Authenticate(ctx context.Context, req *Request) (*Principal, error)
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request in ctx, if it was
+// authenticated.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// SubjectFromContext returns the subject of the principal in ctx, or "" for
+// anonymous requests.
+func SubjectFromContext(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.Subject
+	}
+	return ""
+}
+
+// invalid returns ErrInvalidCredentials caused by err.
+func invalid(err error) error {
+	return ErrInvalidCredentials.WithCause(err)
+}
@@ -0,0 +1,383 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func b64url(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// signJWT returns a token signed with key: a []byte secret, an
+// *rsa.PrivateKey or an *ecdsa.PrivateKey.
+func signJWT(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := b64url(h) + "." + b64url(c)
+
+	hash := algorithms[alg].hash
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		d := hash.New()
+		d.Write([]byte(signed))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, d.Sum(nil)); err != nil {
+			t.Fatalf("SignPKCS1v15() error = %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		d := hash.New()
+		d.Write([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, k, d.Sum(nil))
+		if err != nil {
+			t.Fatalf("ecdsa.Sign() error = %v", err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+	return signed + "." + b64url(sig)
+}
+
+func bearer(token string) *Request {
+	return headers(map[string]string{"Authorization": "Bearer " + token})
+}
+
+func headers(h map[string]string) *Request {
+	return &Request{Header: func(name string) string {
+		for k, v := range h {
+			if strings.EqualFold(k, name) {
+				return v
+			}
+		}
+		return ""
+	}}
+}
+
+func TestJWT(t *testing.T) {
+	secret := []byte("s3cret")
+	j, err := NewJWT(&JWTConfig{Secret: string(secret), Issuer: "https://id.example.com", Audience: "orders", Leeway: time.Second})
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	j.now = func() time.Time { return now }
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{
+			"sub": "alice", "iss": "https://id.example.com", "aud": []string{"orders", "billing"},
+			"exp": now.Add(time.Minute).Unix(), "roles": []string{"admin"},
+		}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	p, err := j.Authenticate(context.Background(), bearer(signJWT(t, "HS256", "", secret, claims(nil))))
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if p.Subject != "alice" || p.Method != MethodJWT || !p.HasRole("admin") || p.Claims["iss"] != "https://id.example.com" {
+		t.Fatalf("principal = %+v", p)
+	}
+
+	invalidTokens := map[string]string{
+		"expired":   signJWT(t, "HS256", "", secret, claims(map[string]any{"exp": now.Add(-time.Minute).Unix()})),
+		"no exp":    signJWT(t, "HS256", "", secret, claims(map[string]any{"exp": nil})),
+		"not yet":   signJWT(t, "HS256", "", secret, claims(map[string]any{"nbf": now.Add(time.Minute).Unix()})),
+		"issuer":    signJWT(t, "HS256", "", secret, claims(map[string]any{"iss": "other"})),
+		"audience":  signJWT(t, "HS256", "", secret, claims(map[string]any{"aud": "billing"})),
+		"no sub":    signJWT(t, "HS256", "", secret, claims(map[string]any{"sub": ""})),
+		"signature": signJWT(t, "HS256", "", []byte("wrong"), claims(nil)),
+		"alg none":  b64url([]byte(`{"alg":"none"}`)) + "." + b64url([]byte(`{"sub":"alice"}`)) + ".",
+		"malformed": "abc.def",
+	}
+	for name, token := range invalidTokens {
+		if _, err := j.Authenticate(context.Background(), bearer(token)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("%s: Authenticate() error = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+	if _, err := j.Authenticate(context.Background(), headers(nil)); err != ErrNoCredentials {
+		t.Fatalf("Authenticate() without token error = %v, want ErrNoCredentials", err)
+	}
+
+	j.allowNoExp = true
+	if _, err := j.Authenticate(context.Background(), bearer(invalidTokens["no exp"])); err != nil {
+		t.Fatalf("Authenticate() without exp and AllowMissingExp error = %v", err)
+	}
+}
+
+func TestJWTWithJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	jwks := map[string]any{"keys": []map[string]any{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64url(rsaKey.N.Bytes()), "e": b64url(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64url(ecKey.X.Bytes()), "y": b64url(ecKey.Y.Bytes())},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+	data, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	j, err := NewJWT(&JWTConfig{JWKSFile: path, Algorithms: []string{"RS256", "ES256"}, RolesClaim: "scope"})
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	claims := map[string]any{"sub": "svc", "exp": time.Now().Add(time.Hour).Unix(), "scope": "read write"}
+
+	for _, tt := range []struct {
+		alg, kid string
+		key      any
+	}{
+		{"RS256", "rsa-1", rsaKey},
+		{"ES256", "ec-1", ecKey},
+		{"RS256", "", rsaKey},
+	} {
+		p, err := j.Authenticate(context.Background(), bearer(signJWT(t, tt.alg, tt.kid, tt.key, claims)))
+		if err != nil {
+			t.Fatalf("%s/%s: Authenticate() error = %v", tt.alg, tt.kid, err)
+		}
+		if p.Subject != "svc" || !p.HasRole("write") {
+			t.Fatalf("%s: principal = %+v", tt.alg, p)
+		}
+	}
+
+	// The kid selects the key, and algorithms outside the list are refused,
+	// including HMAC with the public key as secret.
+	rejected := []string{
+		signJWT(t, "RS256", "ec-1", rsaKey, claims),
+		signJWT(t, "RS384", "rsa-1", rsaKey, claims),
+		signJWT(t, "HS256", "rsa-1", rsaKey.N.Bytes(), claims),
+	}
+	for i, token := range rejected {
+		if _, err := j.Authenticate(context.Background(), bearer(token)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("rejected[%d]: Authenticate() error = %v, want ErrInvalidCredentials", i, err)
+		}
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	sum := sha256.Sum256([]byte("ci-key"))
+	a, err := NewAPIKeys(&APIKeyConfig{Keys: []APIKey{
+		{Name: "admin", Key: "admin-key", Roles: []string{"admin"}},
+		{Name: "ci", SHA256: hex.EncodeToString(sum[:])},
+	}})
+	if err != nil {
+		t.Fatalf("NewAPIKeys() error = %v", err)
+	}
+
+	p, err := a.Authenticate(context.Background(), headers(map[string]string{"X-API-Key": "admin-key"}))
+	if err != nil || p.Subject != "admin" || p.Method != MethodAPIKey || !p.HasRole("admin") {
+		t.Fatalf("Authenticate() = %+v, %v", p, err)
+	}
+	p, err = a.Authenticate(context.Background(), headers(map[string]string{"Authorization": "ApiKey ci-key"}))
+	if err != nil || p.Subject != "ci" {
+		t.Fatalf("Authenticate() by hash = %+v, %v", p, err)
+	}
+	if _, err := a.Authenticate(context.Background(), headers(map[string]string{"X-API-Key": "nope"})); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Authenticate() unknown key error = %v", err)
+	}
+	if _, err := a.Authenticate(context.Background(), headers(map[string]string{"Authorization": "Bearer x"})); err != ErrNoCredentials {
+		t.Fatalf("Authenticate() without key error = %v", err)
+	}
+}
+
+func TestMTLS(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/orders")
+	state := func(cert *x509.Certificate) *Request {
+		return &Request{Header: func(string) string { return "" }, TLS: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+	}
+	m := NewMTLS()
+
+	p, err := m.Authenticate(context.Background(), state(&x509.Certificate{Subject: pkix.Name{CommonName: "orders"}, URIs: []*url.URL{spiffe}}))
+	if err != nil || p.Subject != spiffe.String() || p.Method != MethodMTLS {
+		t.Fatalf("Authenticate() = %+v, %v", p, err)
+	}
+	p, err = m.Authenticate(context.Background(), state(&x509.Certificate{Subject: pkix.Name{CommonName: "orders"}}))
+	if err != nil || p.Subject != "orders" {
+		t.Fatalf("Authenticate() by CN = %+v, %v", p, err)
+	}
+	if _, err := m.Authenticate(context.Background(), &Request{TLS: &tls.ConnectionState{}}); err != ErrNoCredentials {
+		t.Fatalf("Authenticate() unverified error = %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"empty", Config{}, "one of jwt, apiKeys or mtls"},
+		{"jwt keys", Config{JWT: &JWTConfig{}}, "secret or jwksFile"},
+		{"jwt alg", Config{JWT: &JWTConfig{Secret: "x", Algorithms: []string{"none"}}}, "unsupported algorithm"},
+		{"no keys", Config{APIKeys: &APIKeyConfig{}}, "keys are required"},
+		{"both", Config{APIKeys: &APIKeyConfig{Keys: []APIKey{{Name: "a", Key: "k", SHA256: "00"}}}}, "either key or sha256"},
+		{"hash", Config{APIKeys: &APIKeyConfig{Keys: []APIKey{{Name: "a", SHA256: "00"}}}}, "64 hex digits"},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: Validate() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if err := (&Config{MTLS: true}).Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestChainHTTPMiddleware(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	chain, err := New(&Config{
+		JWT:     &JWTConfig{Secret: "s3cret"},
+		APIKeys: &APIKeyConfig{Keys: []APIKey{{Name: "ci", Key: "ci-key"}}},
+		Public:  []string{"GET /docs/*"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var buf bytes.Buffer
+	log := &xlog.Logger{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+	engine := gin.New()
+	engine.Use(middleware.LoggerInjector(log), middleware.Logging(), middleware.Errors(), chain.HTTPMiddleware())
+	whoami := func(c *gin.Context) {
+		c.String(http.StatusOK, SubjectFromContext(c.Request.Context()))
+	}
+	engine.GET("/me", whoami)
+	engine.GET("/docs/*path", whoami)
+	engine.GET("/healthz", whoami)
+
+	do := func(path string, h map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range h {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/me", nil)
+	var body middleware.ErrorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "Bearer" || body.Reason != "UNAUTHENTICATED" {
+		t.Fatalf("anonymous request = %d %v %+v", w.Code, w.Header(), body)
+	}
+	if w := do("/me", map[string]string{"Authorization": "Bearer bad"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token = %d", w.Code)
+	}
+
+	token := signJWT(t, "HS256", "", []byte("s3cret"), map[string]any{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+	if w := do("/me", map[string]string{"Authorization": "Bearer " + token}); w.Code != http.StatusOK || w.Body.String() != "alice" {
+		t.Fatalf("jwt request = %d %q", w.Code, w.Body.String())
+	}
+	if !strings.Contains(buf.String(), "principal=alice") || !strings.Contains(buf.String(), "auth_method=jwt") {
+		t.Fatalf("request log %q does not record the principal", buf.String())
+	}
+	if w := do("/me", map[string]string{"X-API-Key": "ci-key"}); w.Code != http.StatusOK || w.Body.String() != "ci" {
+		t.Fatalf("api key request = %d %q", w.Code, w.Body.String())
+	}
+
+	// Public routes accept anonymous requests and ignore bad credentials.
+	if w := do("/healthz", nil); w.Code != http.StatusOK {
+		t.Fatalf("health check = %d", w.Code)
+	}
+	if w := do("/docs/index.html", map[string]string{"X-API-Key": "nope"}); w.Code != http.StatusOK || w.Body.String() != "" {
+		t.Fatalf("public route = %d %q", w.Code, w.Body.String())
+	}
+	if w := do("/docs/index.html", map[string]string{"X-API-Key": "ci-key"}); w.Body.String() != "ci" {
+		t.Fatalf("public route with key = %q, want the principal", w.Body.String())
+	}
+}
+
+func TestChainGRPCInterceptors(t *testing.T) {
+	chain := NewChain(nil, mustAPIKeys(t))
+	unary := chain.UnaryServerInterceptor()
+	handler := func(ctx context.Context, _ any) (any, error) { return SubjectFromContext(ctx), nil }
+	call := func(method string, md metadata.MD) (any, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		return unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	}
+
+	if _, err := call("/multi.User/GetUser", nil); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("anonymous call error = %v, want ErrUnauthenticated", err)
+	}
+	if got, err := call("/multi.User/GetUser", metadata.Pairs("x-api-key", "ci-key")); err != nil || got != "ci" {
+		t.Fatalf("call = %v, %v", got, err)
+	}
+	if _, err := call("/grpc.health.v1.Health/Check", nil); err != nil {
+		t.Fatalf("health check error = %v", err)
+	}
+
+	stream := chain.StreamServerInterceptor()
+	ss := &testStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "ci-key"))}
+	var subject string
+	err := stream(nil, ss, &grpc.StreamServerInfo{FullMethod: "/multi.User/Watch"}, func(_ any, s grpc.ServerStream) error {
+		subject = SubjectFromContext(s.Context())
+		return nil
+	})
+	if err != nil || subject != "ci" {
+		t.Fatalf("stream = %q, %v", subject, err)
+	}
+}
+
+func mustAPIKeys(t *testing.T) *APIKeys {
+	t.Helper()
+	a, err := NewAPIKeys(&APIKeyConfig{Keys: []APIKey{{Name: "ci", Key: "ci-key"}}})
+	if err != nil {
+		t.Fatalf("NewAPIKeys() error = %v", err)
+	}
+	return a
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context { return s.ctx }
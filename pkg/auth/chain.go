@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/HorseArcher567/octopus/pkg/internal/route"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+// Chain authenticates requests with the first of its authenticators whose
+// credentials they carry.
+type Chain struct {
+	authenticators []Authenticator
+	public         []string
+	challenge      string // WWW-Authenticate value of rejected HTTP requests
+}
+
+// NewChain returns a Chain of authenticators. Requests matching public, or
+// DefaultPublic, may be anonymous.
+func NewChain(public []string, authenticators ...Authenticator) *Chain {
+	c := &Chain{
+		authenticators: authenticators,
+		public:         slices.Concat(DefaultPublic, public),
+	}
+	for _, a := range authenticators {
+		switch a.(type) {
+		case *JWT:
+			c.challenge = "Bearer"
+		case *APIKeys:
+			if c.challenge == "" {
+				c.challenge = "ApiKey"
+			}
+		}
+	}
+	return c
+}
+
+// New creates the Chain configured by cfg.
+func New(cfg *Config) (*Chain, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var authenticators []Authenticator
+	if cfg.JWT != nil {
+		j, err := NewJWT(cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, j)
+	}
+	if cfg.APIKeys != nil {
+		a, err := NewAPIKeys(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if cfg.MTLS {
+		authenticators = append(authenticators, NewMTLS())
+	}
+	return NewChain(cfg.Public, authenticators...), nil
+}
+
+// authenticate returns the principal of req, or nil for an anonymous request
+// to a public route. method is the HTTP method, empty for gRPC.
+func (c *Chain) authenticate(ctx context.Context, method, path string, req *Request) (*Principal, error) {
+	public := route.MatchAny(c.public, method, path)
+	for _, a := range c.authenticators {
+		p, err := a.Authenticate(ctx, req)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			if public {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return p, nil
+	}
+	if public {
+		return nil, nil
+	}
+	return nil, ErrUnauthenticated
+}
+
+// withPrincipal returns ctx carrying p and a request logger that records it.
+func withPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = NewContext(ctx, p)
+	return xlog.Put(ctx, xlog.Get(ctx).With("principal", p.Subject, "auth_method", p.Method))
+}
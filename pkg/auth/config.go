@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultPublic lists the routes and methods that accept anonymous requests:
+// health probes and gRPC health checks.
+var DefaultPublic = []string{
+	"/healthz",
+	"/readyz",
+	"/grpc.health.v1.Health/*",
+}
+
+// Config configures the authentication of a server. The configured methods
+// are tried in the order JWT, API keys, mutual TLS.
+//
+// Example:
+//
+//	auth:
+//	  jwt:
+//	    jwksFile: /etc/auth/jwks.json
+//	    issuer: https://id.example.com
+//	    audience: orders
+//	  apiKeys:
+//	    keys:
+//	      - name: ci
+//	        sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	        roles: [deployer]
+//	  public: ["POST /v1/login"]
+type Config struct {
+	// JWT accepts bearer tokens in the Authorization header.
+	JWT *JWTConfig `yaml:"jwt" json:"jwt" toml:"jwt"`
+
+	// APIKeys accepts static API keys.
+	APIKeys *APIKeyConfig `yaml:"apiKeys" json:"apiKeys" toml:"apiKeys"`
+
+	// MTLS accepts the verified client certificate of mutual TLS
+	// connections.
+	MTLS bool `yaml:"mtls" json:"mtls" toml:"mtls"`
+
+	// Public lists routes and methods that accept anonymous requests, in
+	// addition to DefaultPublic. Requests to them are still authenticated
+	// when they carry valid credentials. Patterns are HTTP route templates,
+	// optionally preceded by a method, or full gRPC method names; a
+	// trailing * matches any suffix.
+	Public []string `yaml:"public" json:"public" toml:"public"`
+}
+
+// JWTConfig configures JWT bearer tokens. Tokens are verified with Secret
+// (HS256/384/512) or with the keys of JWKSFile (RS*, ES* and HS* keys), and
+// must carry an "exp" claim that has not passed.
+type JWTConfig struct {
+	// Secret is the shared HMAC key.
+	Secret string `yaml:"secret" json:"secret" toml:"secret"`
+
+	// JWKSFile is the path of a JSON Web Key Set. Tokens with a "kid"
+	// header are verified with the key of that ID.
+	JWKSFile string `yaml:"jwksFile" json:"jwksFile" toml:"jwksFile"`
+
+	// Algorithms restricts the accepted signature algorithms, e.g. [RS256].
+	// Empty accepts every algorithm the keys support.
+	Algorithms []string `yaml:"algorithms" json:"algorithms" toml:"algorithms"`
+
+	// Issuer, if set, must equal the "iss" claim.
+	Issuer string `yaml:"issuer" json:"issuer" toml:"issuer"`
+
+	// Audience, if set, must be one of the "aud" claim.
+	Audience string `yaml:"audience" json:"audience" toml:"audience"`
+
+	// Leeway tolerates clock skew on "exp" and "nbf" (default: 1m).
+	Leeway time.Duration `yaml:"leeway" json:"leeway" toml:"leeway"`
+
+	// AllowMissingExp accepts tokens without an "exp" claim, which never
+	// expire. Only enable it for issuers that cannot set one.
+	AllowMissingExp bool `yaml:"allowMissingExp" json:"allowMissingExp" toml:"allowMissingExp"`
+
+	// RolesClaim names the claim holding the roles of the principal, a list
+	// or a space-separated string (default: "roles").
+	RolesClaim string `yaml:"rolesClaim" json:"rolesClaim" toml:"rolesClaim"`
+}
+
+// APIKeyConfig configures static API keys.
+type APIKeyConfig struct {
+	// Header carries the key (default: X-API-Key). The key may also be sent
+	// as "Authorization: ApiKey <key>".
+	Header string `yaml:"header" json:"header" toml:"header"`
+
+	// Keys are the accepted keys.
+	Keys []APIKey `yaml:"keys" json:"keys" toml:"keys"`
+}
+
+// APIKey is an accepted API key. Set either Key or SHA256, preferably the
+// latter to keep the key out of the configuration.
+type APIKey struct {
+	// Name is the subject of the principal.
+	Name string `yaml:"name" json:"name" toml:"name"`
+
+	// Key is the key in clear text.
+	Key string `yaml:"key" json:"key" toml:"key"`
+
+	// SHA256 is the hex-encoded SHA-256 hash of the key.
+	SHA256 string `yaml:"sha256" json:"sha256" toml:"sha256"`
+
+	// Roles are the roles of the principal.
+	Roles []string `yaml:"roles" json:"roles" toml:"roles"`
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	if c.JWT == nil && c.APIKeys == nil && !c.MTLS {
+		return errors.New("auth: one of jwt, apiKeys or mtls is required")
+	}
+	if c.JWT != nil {
+		if err := c.JWT.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.APIKeys != nil {
+		if err := c.APIKeys.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates the JWT configuration.
+func (c *JWTConfig) Validate() error {
+	if c.Secret == "" && c.JWKSFile == "" {
+		return errors.New("auth: jwt secret or jwksFile is required")
+	}
+	for _, alg := range c.Algorithms {
+		if _, ok := algorithms[alg]; !ok {
+			return fmt.Errorf("auth: jwt: unsupported algorithm %q", alg)
+		}
+	}
+	if c.Leeway < 0 {
+		return errors.New("auth: jwt leeway cannot be negative")
+	}
+	return nil
+}
+
+// Validate validates the API key configuration.
+func (c *APIKeyConfig) Validate() error {
+	if len(c.Keys) == 0 {
+		return errors.New("auth: apiKeys keys are required")
+	}
+	for i, k := range c.Keys {
+		if k.Name == "" {
+			return fmt.Errorf("auth: apiKeys keys[%d]: name is required", i)
+		}
+		if (k.Key == "") == (k.SHA256 == "") {
+			return fmt.Errorf("auth: apiKey %q: set either key or sha256", k.Name)
+		}
+		if k.SHA256 != "" {
+			if b, err := hex.DecodeString(k.SHA256); err != nil || len(b) != 32 {
+				return fmt.Errorf("auth: apiKey %q: sha256 must be 64 hex digits", k.Name)
+			}
+		}
+	}
+	return nil
+}
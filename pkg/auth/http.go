@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPMiddleware authenticates the requests handled by a Gin engine. Public
+// patterns match the route template, or the request path for requests that
+// no route matches. Rejected requests get 401 with a WWW-Authenticate
+// header. Install it after the logging middleware, which then records the
+// principal.
+func (c *Chain) HTTPMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			route = ctx.Request.URL.Path
+		}
+		p, err := c.authenticate(ctx.Request.Context(), ctx.Request.Method, route, &Request{
+			Header: ctx.GetHeader,
+			TLS:    ctx.Request.TLS,
+		})
+		if err != nil {
+			if c.challenge != "" {
+				ctx.Header("WWW-Authenticate", c.challenge)
+			}
+			middleware.WriteError(ctx, err)
+			return
+		}
+		if p != nil {
+			ctx.Request = ctx.Request.WithContext(withPrincipal(ctx.Request.Context(), p))
+		}
+		ctx.Next()
+	}
+}
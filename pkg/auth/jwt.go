@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	// Register the hash functions of the algorithms.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const defaultLeeway = time.Minute
+
+// algorithm is a JWS signature algorithm.
+type algorithm struct {
+	kty  string // key type: oct, RSA or EC
+	hash crypto.Hash
+	crv  string // curve of EC keys
+}
+
+var algorithms = map[string]algorithm{
+	"HS256": {kty: "oct", hash: crypto.SHA256},
+	"HS384": {kty: "oct", hash: crypto.SHA384},
+	"HS512": {kty: "oct", hash: crypto.SHA512},
+	"RS256": {kty: "RSA", hash: crypto.SHA256},
+	"RS384": {kty: "RSA", hash: crypto.SHA384},
+	"RS512": {kty: "RSA", hash: crypto.SHA512},
+	"ES256": {kty: "EC", hash: crypto.SHA256, crv: "P-256"},
+	"ES384": {kty: "EC", hash: crypto.SHA384, crv: "P-384"},
+	"ES512": {kty: "EC", hash: crypto.SHA512, crv: "P-521"},
+}
+
+// JWT authenticates bearer tokens in the Authorization header.
+type JWT struct {
+	keys       []*jwk
+	algorithms []string
+	issuer     string
+	audience   string
+	leeway     time.Duration
+	rolesClaim string
+	allowNoExp bool
+	now        func() time.Time
+}
+
+// NewJWT creates a JWT authenticator from cfg, loading the key set from
+// cfg.JWKSFile if set.
+func NewJWT(cfg *JWTConfig) (*JWT, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	j := &JWT{
+		algorithms: cfg.Algorithms,
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		leeway:     cfg.Leeway,
+		rolesClaim: cfg.RolesClaim,
+		allowNoExp: cfg.AllowMissingExp,
+		now:        time.Now,
+	}
+	if j.leeway == 0 {
+		j.leeway = defaultLeeway
+	}
+	if j.rolesClaim == "" {
+		j.rolesClaim = "roles"
+	}
+	if cfg.Secret != "" {
+		j.keys = append(j.keys, &jwk{kty: "oct", key: []byte(cfg.Secret)})
+	}
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth: jwt: read jwks: %w", err)
+		}
+		keys, err := parseJWKS(data)
+		if err != nil {
+			return nil, fmt.Errorf("auth: jwt: %s: %w", cfg.JWKSFile, err)
+		}
+		j.keys = append(j.keys, keys...)
+	}
+	return j, nil
+}
+
+// Authenticate implements Authenticator.
+func (j *JWT) Authenticate(_ context.Context, req *Request) (*Principal, error) {
+	scheme, token, _ := strings.Cut(req.Header("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, ErrNoCredentials
+	}
+	claims, err := j.verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, invalid(err)
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, invalid(errors.New("jwt: missing sub claim"))
+	}
+	return &Principal{
+		Subject: sub,
+		Method:  MethodJWT,
+		Roles:   stringList(claims[j.rolesClaim]),
+		Claims:  claims,
+	}, nil
+}
+
+// verify checks the signature and the registered claims of token and
+// returns its claims.
+func (j *JWT) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jwt: malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("jwt: header: %w", err)
+	}
+	alg, ok := algorithms[header.Alg]
+	if !ok || (len(j.algorithms) > 0 && !slices.Contains(j.algorithms, header.Alg)) {
+		return nil, fmt.Errorf("jwt: algorithm %q not accepted", header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("jwt: signature: %w", err)
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range j.keys {
+		if key.matches(header.Kid, header.Alg, alg) && key.verify(alg, signed, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("jwt: invalid signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("jwt: claims: %w", err)
+	}
+	if err := j.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (j *JWT) validateClaims(claims map[string]any) error {
+	now := j.now()
+	exp, ok := numericDate(claims["exp"])
+	switch {
+	case !ok && !j.allowNoExp:
+		return errors.New("jwt: missing exp claim")
+	case ok && !now.Before(exp.Add(j.leeway)):
+		return errors.New("jwt: token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(j.leeway).Before(nbf) {
+		return errors.New("jwt: token not valid yet")
+	}
+	if j.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != j.issuer {
+			return fmt.Errorf("jwt: unexpected issuer %q", iss)
+		}
+	}
+	if j.audience != "" && !slices.Contains(stringList(claims["aud"]), j.audience) {
+		return errors.New("jwt: audience not accepted")
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func numericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec, frac := int64(f), f-float64(int64(f))
+	return time.Unix(sec, int64(frac*1e9)), true
+}
+
+// stringList returns v as a list of strings: a JSON array of strings, or a
+// space-separated string as in the "scope" claim.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// jwk is a verification key of a JSON Web Key Set.
+type jwk struct {
+	kid string
+	alg string // algorithm the key is restricted to, if any
+	kty string
+	key any // []byte, *rsa.PublicKey or *ecdsa.PublicKey
+}
+
+func (k *jwk) matches(kid, name string, alg algorithm) bool {
+	if kid != "" && k.kid != "" && kid != k.kid {
+		return false
+	}
+	if k.alg != "" && k.alg != name {
+		return false
+	}
+	return k.kty == alg.kty
+}
+
+func (k *jwk) verify(alg algorithm, signed, sig []byte) bool {
+	h := alg.hash.New()
+	switch key := k.key.(type) {
+	case []byte:
+		mac := hmac.New(alg.hash.New, key)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), sig)
+	case *rsa.PublicKey:
+		h.Write(signed)
+		return rsa.VerifyPKCS1v15(key, alg.hash, h.Sum(nil), sig) == nil
+	case *ecdsa.PublicKey:
+		if key.Curve.Params().Name != alg.crv {
+			return false
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		h.Write(signed)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(key, h.Sum(nil), r, s)
+	}
+	return false
+}
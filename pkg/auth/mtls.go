@@ -0,0 +1,29 @@
+package auth
+
+import "context"
+
+// MTLS authenticates the verified client certificate of a mutual TLS
+// connection. The subject is the first URI SAN, such as a SPIFFE ID, or else
+// the common name.
+type MTLS struct{}
+
+// NewMTLS creates a mutual TLS authenticator.
+func NewMTLS() *MTLS {
+	return &MTLS{}
+}
+
+// Authenticate implements Authenticator.
+func (*MTLS) Authenticate(_ context.Context, req *Request) (*Principal, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+	cert := req.TLS.VerifiedChains[0][0]
+	subject := cert.Subject.CommonName
+	if len(cert.URIs) > 0 {
+		subject = cert.URIs[0].String()
+	}
+	if subject == "" {
+		return nil, ErrNoCredentials
+	}
+	return &Principal{Subject: subject, Method: MethodMTLS}, nil
+}
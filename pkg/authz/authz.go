@@ -0,0 +1,192 @@
+// Package authz authorizes HTTP requests and gRPC calls with declarative
+// rules.
+//
+// A Policy holds rules that match routes or methods and grant or deny access
+// by the roles and subject of the auth.Principal and by request attributes,
+// such as JWT claims, headers, route parameters or fields of the request
+// message. Rules are declared in configuration or in code. Policies are
+// applied as Gin middleware and as gRPC server interceptors after
+// authentication, reject requests with PERMISSION_DENIED (HTTP 403), and log
+// their decisions for audit.
+package authz
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/HorseArcher567/octopus/pkg/internal/route"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+// ErrPermissionDenied is returned for requests a Policy denies.
+var ErrPermissionDenied = errors.New(errors.PermissionDenied, "permission denied").WithReason("PERMISSION_DENIED")
+
+// Decision is the outcome of a Policy for a request.
+type Decision struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+
+	// Rule names the rule that decided, empty for the default effect.
+	Rule string
+
+	// Reason explains the decision in audit logs.
+	Reason string
+}
+
+// Policy evaluates authorization rules.
+type Policy struct {
+	allowByDefault bool
+	audit          string
+	exempt         []string
+
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+// New creates a Policy from cfg.
+func New(cfg *Config) (*Policy, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	p := &Policy{
+		allowByDefault: cfg.Default == EffectAllow,
+		audit:          cfg.Audit,
+		exempt:         slices.Clone(DefaultExempt),
+		rules:          slices.Clone(cfg.Rules),
+	}
+	if p.audit == "" {
+		p.audit = AuditDeny
+	}
+	return p, nil
+}
+
+// AddRules appends rules declared in code. They are evaluated like the
+// configured ones; add them before the server starts.
+func (p *Policy) AddRules(rules ...Rule) error {
+	if err := validateRules(rules); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, rules...)
+	return nil
+}
+
+// Evaluate decides whether req is allowed.
+func (p *Policy) Evaluate(ctx context.Context, req *Request) Decision {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	matched := false
+	var allow *Rule
+	for i := range p.rules {
+		r := &p.rules[i]
+		if len(r.Match) > 0 && !route.MatchAny(r.Match, req.Method, req.Route) {
+			continue
+		}
+		matched = true
+		if !r.applies(ctx, req) {
+			continue
+		}
+		if r.Effect == EffectDeny {
+			return Decision{Rule: r.Name, Reason: "denied by rule"}
+		}
+		if allow == nil {
+			allow = r
+		}
+	}
+	switch {
+	case allow != nil:
+		return Decision{Allowed: true, Rule: allow.Name, Reason: "allowed by rule"}
+	case matched:
+		return Decision{Reason: "no matching rule allows"}
+	case p.allowByDefault:
+		return Decision{Allowed: true, Reason: "allowed by default"}
+	}
+	return Decision{Reason: "denied by default"}
+}
+
+// authorize evaluates req, logs the decision and returns the error of a
+// denied request: auth.ErrUnauthenticated for anonymous requests, so that
+// clients know to authenticate, and ErrPermissionDenied otherwise.
+func (p *Policy) authorize(ctx context.Context, req *Request) error {
+	if route.MatchAny(p.exempt, req.Method, req.Route) {
+		return nil
+	}
+	d := p.Evaluate(ctx, req)
+	if p.audit == AuditAll || (p.audit == AuditDeny && !d.Allowed) {
+		p.log(ctx, req, d)
+	}
+	if d.Allowed {
+		return nil
+	}
+	if req.Principal == nil {
+		return auth.ErrUnauthenticated
+	}
+	return ErrPermissionDenied
+}
+
+func (p *Policy) log(ctx context.Context, req *Request, d Decision) {
+	attrs := []any{"route", req.Route, "rule", d.Rule, "reason", d.Reason}
+	if req.Method != "" {
+		attrs = append(attrs, "http_method", req.Method)
+	}
+	if req.Principal != nil {
+		attrs = append(attrs, "subject", req.Principal.Subject)
+	}
+	log := xlog.Get(ctx)
+	if d.Allowed {
+		log.Info("authz allowed", attrs...)
+	} else {
+		log.Warn("authz denied", attrs...)
+	}
+}
+
+// applies reports whether r applies to req, whose route it matches.
+func (r *Rule) applies(ctx context.Context, req *Request) bool {
+	if len(r.Roles) > 0 || len(r.Subjects) > 0 {
+		pr := req.Principal
+		if pr == nil {
+			return false
+		}
+		ok := slices.Contains(r.Subjects, pr.Subject) || slices.Contains(r.Roles, AnyRole)
+		for _, role := range r.Roles {
+			ok = ok || pr.HasRole(role)
+		}
+		if !ok {
+			return false
+		}
+	}
+	for i := range r.When {
+		if !r.When[i].holds(req) {
+			return false
+		}
+	}
+	return r.Check == nil || r.Check(ctx, req)
+}
+
+func (c *Condition) holds(req *Request) bool {
+	values := req.Attr(c.Attr)
+	switch {
+	case c.Equals != "":
+		return slices.Contains(values, c.Equals)
+	case len(c.In) > 0:
+		for _, v := range values {
+			if slices.Contains(c.In, v) {
+				return true
+			}
+		}
+		return false
+	default:
+		other := req.Attr(c.EqualsAttr)
+		for _, v := range values {
+			if slices.Contains(other, v) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,246 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func principal(subject string, roles ...string) *auth.Principal {
+	return &auth.Principal{Subject: subject, Method: auth.MethodJWT, Roles: roles}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy, err := New(&Config{Rules: []Rule{
+		{Name: "admins", Match: []string{"/admin/*"}, Roles: []string{"admin"}},
+		{Name: "no-mallory", Match: []string{"/admin/*", "/v1/*"}, Effect: EffectDeny, Subjects: []string{"mallory"}},
+		{Name: "own-tenant", Match: []string{"GET /v1/tenants/:tenant"}, Roles: []string{AnyRole}, When: []Condition{
+			{Attr: "claims.tenant", EqualsAttr: "param.tenant"},
+		}},
+		{Name: "catalog", Match: []string{"GET /v1/products"}},
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := policy.AddRules(Rule{
+		Name:  "eu-only",
+		Match: []string{"/v1/reports"},
+		Check: func(_ context.Context, r *Request) bool { return r.Header("X-Region") == "eu" },
+	}); err != nil {
+		t.Fatalf("AddRules() error = %v", err)
+	}
+
+	tenant := principal("bob")
+	tenant.Claims = map[string]any{"tenant": "acme"}
+	params := map[string]string{"tenant": "acme"}
+	tests := []struct {
+		name      string
+		method    string
+		route     string
+		principal *auth.Principal
+		header    string
+		params    map[string]string
+		allowed   bool
+		rule      string
+	}{
+		{"admin", "GET", "/admin/users", principal("alice", "admin"), "", nil, true, "admins"},
+		{"not admin", "GET", "/admin/users", principal("bob", "user"), "", nil, false, ""},
+		{"deny wins", "GET", "/admin/users", principal("mallory", "admin"), "", nil, false, "no-mallory"},
+		{"own tenant", "GET", "/v1/tenants/:tenant", tenant, "", params, true, "own-tenant"},
+		{"other tenant", "GET", "/v1/tenants/:tenant", tenant, "", map[string]string{"tenant": "globex"}, false, ""},
+		{"anonymous tenant", "GET", "/v1/tenants/:tenant", nil, "", params, false, ""},
+		{"public", "GET", "/v1/products", nil, "", nil, true, "catalog"},
+		{"method", "POST", "/v1/products", principal("alice", "admin"), "", nil, false, ""},
+		{"code rule", "GET", "/v1/reports", nil, "eu", nil, true, "eu-only"},
+		{"code rule fails", "GET", "/v1/reports", nil, "us", nil, false, ""},
+		{"default", "GET", "/v1/unknown", principal("alice", "admin"), "", nil, false, ""},
+	}
+	for _, tt := range tests {
+		d := policy.Evaluate(context.Background(), &Request{
+			Method:    tt.method,
+			Route:     tt.route,
+			Principal: tt.principal,
+			Header:    func(string) string { return tt.header },
+			Param:     func(name string) string { return tt.params[name] },
+		})
+		if d.Allowed != tt.allowed || d.Rule != tt.rule {
+			t.Fatalf("%s: Evaluate() = %+v, want allowed=%v rule=%q", tt.name, d, tt.allowed, tt.rule)
+		}
+	}
+
+	open, err := New(&Config{Default: EffectAllow})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if d := open.Evaluate(context.Background(), &Request{Route: "/v1/unknown"}); !d.Allowed {
+		t.Fatalf("Evaluate() with default allow = %+v", d)
+	}
+}
+
+func TestRequestAttr(t *testing.T) {
+	r := &Request{
+		Principal: &auth.Principal{Subject: "alice", Method: auth.MethodJWT, Roles: []string{"a", "b"}, Claims: map[string]any{
+			"org":    map[string]any{"id": "acme"},
+			"groups": []any{"ops", "dev"},
+			"level":  float64(3),
+		}},
+		Header: func(name string) string { return map[string]string{"x-region": "eu"}[name] },
+		Message: &descriptorpb.FileDescriptorProto{
+			Name:       proto.String("orders.proto"),
+			Dependency: []string{"a.proto", "b.proto"},
+			Options:    &descriptorpb.FileOptions{JavaPackage: proto.String("com.acme"), OptimizeFor: descriptorpb.FileOptions_LITE_RUNTIME.Enum()},
+		},
+	}
+	tests := map[string][]string{
+		"subject":                    {"alice"},
+		"auth_method":                {"jwt"},
+		"roles":                      {"a", "b"},
+		"claims.org.id":              {"acme"},
+		"claims.groups":              {"ops", "dev"},
+		"claims.level":               {"3"},
+		"claims.missing":             nil,
+		"header.x-region":            {"eu"},
+		"param.id":                   nil,
+		"field.name":                 {"orders.proto"},
+		"field.dependency":           {"a.proto", "b.proto"},
+		"field.options.java_package": {"com.acme"},
+		"field.options.optimizeFor":  {"LITE_RUNTIME"},
+		"field.package":              nil,
+		"field.options":              nil,
+		"unknown":                    nil,
+	}
+	for name, want := range tests {
+		if got := r.Attr(name); !slices.Equal(got, want) {
+			t.Fatalf("Attr(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"default", Config{Default: "maybe"}, "default must be"},
+		{"audit", Config{Audit: "some"}, "audit must be"},
+		{"name", Config{Rules: []Rule{{}}}, "name is required"},
+		{"effect", Config{Rules: []Rule{{Name: "a", Effect: "permit"}}}, "effect must be"},
+		{"attr", Config{Rules: []Rule{{Name: "a", When: []Condition{{Equals: "x"}}}}}, "attr is required"},
+		{"operator", Config{Rules: []Rule{{Name: "a", When: []Condition{{Attr: "subject", Equals: "x", In: []string{"y"}}}}}}, "exactly one"},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: Validate() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestPolicyHTTPMiddleware(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	policy, err := New(&Config{Audit: AuditAll, Rules: []Rule{
+		{Name: "admins", Match: []string{"/admin/*"}, Roles: []string{"admin"}},
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var buf bytes.Buffer
+	log := &xlog.Logger{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+	var as *auth.Principal
+	engine := gin.New()
+	engine.Use(middleware.LoggerInjector(log), middleware.Errors(), func(c *gin.Context) {
+		if as != nil {
+			c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), as))
+		}
+	}, policy.HTTPMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	engine.GET("/admin/jobs", ok)
+	engine.GET("/healthz", ok)
+
+	do := func(path string, p *auth.Principal) int {
+		as = p
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+	if code := do("/admin/jobs", nil); code != http.StatusUnauthorized {
+		t.Fatalf("anonymous = %d, want 401", code)
+	}
+	if code := do("/admin/jobs", principal("bob", "user")); code != http.StatusForbidden {
+		t.Fatalf("user = %d, want 403", code)
+	}
+	if code := do("/admin/jobs", principal("alice", "admin")); code != http.StatusNoContent {
+		t.Fatalf("admin = %d, want 204", code)
+	}
+	if code := do("/healthz", nil); code != http.StatusNoContent {
+		t.Fatalf("health check = %d, want exempt", code)
+	}
+
+	logs := buf.String()
+	if !strings.Contains(logs, `msg="authz denied" route=/admin/jobs rule="" reason="no matching rule allows" http_method=GET subject=bob`) {
+		t.Fatalf("audit log %q does not record the denial", logs)
+	}
+	if !strings.Contains(logs, `msg="authz allowed" route=/admin/jobs rule=admins`) {
+		t.Fatalf("audit log %q does not record the allowed request", logs)
+	}
+}
+
+func TestPolicyGRPCInterceptors(t *testing.T) {
+	policy, err := New(&Config{Rules: []Rule{
+		{Name: "own-files", Match: []string{"/files.Files/*"}, Roles: []string{AnyRole}, When: []Condition{
+			{Attr: "field.name", EqualsAttr: "subject"},
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	unary := policy.UnaryServerInterceptor()
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+	call := func(p *auth.Principal, name string) error {
+		ctx := context.Background()
+		if p != nil {
+			ctx = auth.NewContext(ctx, p)
+		}
+		req := &descriptorpb.FileDescriptorProto{Name: proto.String(name)}
+		_, err := unary(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/files.Files/Get"}, handler)
+		return err
+	}
+
+	if err := call(principal("alice"), "alice"); err != nil {
+		t.Fatalf("own file error = %v", err)
+	}
+	if err := call(principal("alice"), "bob"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("other file error = %v, want ErrPermissionDenied", err)
+	}
+	if err := call(nil, "alice"); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Fatalf("anonymous error = %v, want auth.ErrUnauthenticated", err)
+	}
+
+	stream := policy.StreamServerInterceptor()
+	err = stream(nil, &testStream{ctx: auth.NewContext(context.Background(), principal("alice"))},
+		&grpc.StreamServerInfo{FullMethod: "/files.Files/Watch"}, func(any, grpc.ServerStream) error { return nil })
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("stream error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,163 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Rule effects.
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Audit levels.
+const (
+	AuditDeny = "deny"
+	AuditAll  = "all"
+	AuditNone = "none"
+)
+
+// AnyRole in Rule.Roles matches every authenticated principal.
+const AnyRole = "*"
+
+// DefaultExempt lists the routes and methods that are never authorized:
+// health probes and gRPC health checks.
+var DefaultExempt = []string{
+	"/healthz",
+	"/readyz",
+	"/grpc.health.v1.Health/*",
+}
+
+// Config configures the authorization of a server.
+//
+// Example:
+//
+//	authz:
+//	  default: deny
+//	  rules:
+//	    - name: admins
+//	      match: ["/admin/*", "/multi.Admin/*"]
+//	      roles: [admin]
+//	    - name: own-orders
+//	      match: ["GET /v1/tenants/:tenant/orders"]
+//	      roles: ["*"]
+//	      when:
+//	        - attr: claims.tenant
+//	          equalsAttr: param.tenant
+//	    - name: public-catalog
+//	      match: ["GET /v1/products/*"]
+type Config struct {
+	// Default is the effect for requests that no rule matches: "deny"
+	// (default) or "allow".
+	Default string `yaml:"default" json:"default" toml:"default"`
+
+	// Audit selects the decisions that are logged: "deny" (default), "all"
+	// or "none".
+	Audit string `yaml:"audit" json:"audit" toml:"audit"`
+
+	// Rules are the authorization rules. A request is denied if a deny rule
+	// applies to it, and otherwise allowed if an allow rule applies. If
+	// rules match its route but none applies, it is denied.
+	Rules []Rule `yaml:"rules" json:"rules" toml:"rules"`
+}
+
+// Rule grants or denies access to routes and methods.
+type Rule struct {
+	// Name identifies the rule in audit logs.
+	Name string `yaml:"name" json:"name" toml:"name"`
+
+	// Match lists the routes or methods the rule covers; empty covers every
+	// request. HTTP patterns are route templates, optionally preceded by a
+	// method, e.g. "GET /v1/users/:id"; gRPC patterns are full method names,
+	// e.g. "/pkg.Service/Method". A trailing * matches any suffix.
+	Match []string `yaml:"match" json:"match" toml:"match"`
+
+	// Effect is "allow" (default) or "deny".
+	Effect string `yaml:"effect" json:"effect" toml:"effect"`
+
+	// Roles and Subjects restrict the rule to principals with one of the
+	// roles or subjects; "*" in Roles stands for any principal. If both are
+	// empty, the rule applies to anonymous requests too.
+	Roles    []string `yaml:"roles" json:"roles" toml:"roles"`
+	Subjects []string `yaml:"subjects" json:"subjects" toml:"subjects"`
+
+	// When lists conditions on request attributes that must all hold.
+	When []Condition `yaml:"when" json:"when" toml:"when"`
+
+	// Check, if set, must also return true. It declares conditions in code.
+	Check func(ctx context.Context, req *Request) bool `yaml:"-" json:"-" toml:"-"`
+}
+
+// Condition tests a request attribute, see Request.Attr. Set one of Equals,
+// In or EqualsAttr; attributes with several values, such as list claims,
+// pass if any value does.
+type Condition struct {
+	// Attr names the attribute, e.g. "claims.tenant" or "header.x-region".
+	Attr string `yaml:"attr" json:"attr" toml:"attr"`
+
+	// Equals is the required value.
+	Equals string `yaml:"equals" json:"equals" toml:"equals"`
+
+	// In lists the accepted values.
+	In []string `yaml:"in" json:"in" toml:"in"`
+
+	// EqualsAttr names another attribute that must share a value, e.g.
+	// "param.tenant" to let principals access their own tenant only.
+	EqualsAttr string `yaml:"equalsAttr" json:"equalsAttr" toml:"equalsAttr"`
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	switch c.Default {
+	case "", EffectAllow, EffectDeny:
+	default:
+		return errors.New("authz: default must be allow or deny")
+	}
+	switch c.Audit {
+	case "", AuditDeny, AuditAll, AuditNone:
+	default:
+		return errors.New("authz: audit must be deny, all or none")
+	}
+	return validateRules(c.Rules)
+}
+
+func validateRules(rules []Rule) error {
+	for i, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("authz: rules[%d]: name is required", i)
+		}
+		switch r.Effect {
+		case "", EffectAllow, EffectDeny:
+		default:
+			return fmt.Errorf("authz: rule %q: effect must be allow or deny", r.Name)
+		}
+		for j, cond := range r.When {
+			if err := cond.validate(); err != nil {
+				return fmt.Errorf("authz: rule %q: when[%d]: %w", r.Name, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Condition) validate() error {
+	if c.Attr == "" {
+		return errors.New("attr is required")
+	}
+	set := 0
+	if c.Equals != "" {
+		set++
+	}
+	if len(c.In) > 0 {
+		set++
+	}
+	if c.EqualsAttr != "" {
+		set++
+	}
+	if set != 1 {
+		return errors.New("set exactly one of equals, in or equalsAttr")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package authz
+
+import (
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPMiddleware authorizes the requests handled by a Gin engine. Rules
+// match the route template, or the request path for requests that no route
+// matches. Denied requests get 403, or 401 if they are anonymous. Install it
+// after the authentication middleware.
+func (p *Policy) HTTPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		principal, _ := auth.FromContext(c.Request.Context())
+		err := p.authorize(c.Request.Context(), &Request{
+			Method:    c.Request.Method,
+			Route:     route,
+			Principal: principal,
+			Header:    c.GetHeader,
+			Param:     c.Param,
+			Query:     c.Query,
+		})
+		if err != nil {
+			middleware.WriteError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,162 @@
+package authz
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Request is what a Policy sees of an HTTP request or gRPC call.
+type Request struct {
+	// Method is the HTTP method, empty for gRPC.
+	Method string
+
+	// Route is the route template, the request path for requests no route
+	// matches, or the full gRPC method name.
+	Route string
+
+	// Principal is the authenticated caller, nil for anonymous requests.
+	Principal *auth.Principal
+
+	// Header returns the first value of an HTTP header or of a gRPC
+	// metadata key.
+	Header func(name string) string
+
+	// Param and Query return HTTP route and query parameters; nil for gRPC.
+	Param func(name string) string
+	Query func(name string) string
+
+	// Message is the request message of unary gRPC calls.
+	Message proto.Message
+}
+
+// Attr returns the values of a request attribute:
+//
+//   - subject, auth_method and roles of the principal
+//   - claims.<name> for a JWT claim; nested claims are separated by dots
+//   - header.<name> for an HTTP header or gRPC metadata value
+//   - param.<name> and query.<name> for HTTP route and query parameters
+//   - field.<path> for a field of the gRPC request message, e.g.
+//     field.order.tenant_id
+//
+// Missing attributes have no values.
+func (r *Request) Attr(name string) []string {
+	kind, key, _ := strings.Cut(name, ".")
+	switch kind {
+	case "subject", "auth_method", "roles":
+		if r.Principal == nil {
+			return nil
+		}
+		switch kind {
+		case "subject":
+			return []string{r.Principal.Subject}
+		case "auth_method":
+			return []string{r.Principal.Method}
+		}
+		return r.Principal.Roles
+	case "claims":
+		if r.Principal == nil {
+			return nil
+		}
+		return claimValues(r.Principal.Claims, key)
+	case "header":
+		return nonEmpty(r.Header, key)
+	case "param":
+		return nonEmpty(r.Param, key)
+	case "query":
+		return nonEmpty(r.Query, key)
+	case "field":
+		if r.Message == nil {
+			return nil
+		}
+		return fieldValues(r.Message.ProtoReflect(), key)
+	}
+	return nil
+}
+
+func nonEmpty(get func(string) string, key string) []string {
+	if get == nil {
+		return nil
+	}
+	if v := get(key); v != "" {
+		return []string{v}
+	}
+	return nil
+}
+
+func claimValues(claims map[string]any, path string) []string {
+	var v any = claims
+	for seg := range strings.SplitSeq(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[seg]
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	}
+	return []string{fmt.Sprint(v)}
+}
+
+// fieldValues returns the values of the field at path in m, matching field
+// names in proto or JSON form.
+func fieldValues(m protoreflect.Message, path string) []string {
+	segs := strings.Split(path, ".")
+	for i, seg := range segs {
+		fields := m.Descriptor().Fields()
+		fd := fields.ByName(protoreflect.Name(seg))
+		if fd == nil {
+			fd = fields.ByJSONName(seg)
+		}
+		if fd == nil || !m.Has(fd) {
+			return nil
+		}
+		v := m.Get(fd)
+		if i < len(segs)-1 {
+			if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+				return nil
+			}
+			m = v.Message()
+			continue
+		}
+		if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			return nil
+		}
+		if fd.IsList() {
+			list := v.List()
+			values := make([]string, 0, list.Len())
+			for j := range list.Len() {
+				values = append(values, scalarString(fd, list.Get(j)))
+			}
+			return values
+		}
+		return []string{scalarString(fd, v)}
+	}
+	return nil
+}
+
+func scalarString(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	if fd.Kind() == protoreflect.EnumKind {
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+	}
+	if fd.Kind() == protoreflect.BytesKind {
+		return string(v.Bytes())
+	}
+	return v.String()
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const defaultPingTimeout = 5 * time.Second
@@ -16,6 +17,8 @@ const defaultPingTimeout = 5 * time.Second
 // It embeds *sqlx.DB so all sqlx methods are directly available.
 type DB struct {
 	*sqlx.DB
+
+	tracer trace.Tracer
 }
 
 // Open creates a new database connection using the provided driver, DSN, and pool settings.
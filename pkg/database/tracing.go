@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/HorseArcher567/octopus/pkg/database"
+
+// EnableTracing makes the context-aware query methods of db record a client
+// span per statement. Methods without a context are not traced.
+func (db *DB) EnableTracing(tp trace.TracerProvider) {
+	db.tracer = tp.Tracer(tracerName)
+}
+
+// ExecContext executes a statement without returning rows.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	ctx, end := db.startSpan(ctx, query)
+	defer func() { end(err) }()
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes a query that returns rows.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	ctx, end := db.startSpan(ctx, query)
+	defer func() { end(err) }()
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executes a query that is expected to return at most one row.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, end := db.startSpan(ctx, query)
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	end(row.Err())
+	return row
+}
+
+// QueryxContext executes a query that returns sqlx.Rows.
+func (db *DB) QueryxContext(ctx context.Context, query string, args ...any) (rows *sqlx.Rows, err error) {
+	ctx, end := db.startSpan(ctx, query)
+	defer func() { end(err) }()
+	return db.DB.QueryxContext(ctx, query, args...)
+}
+
+// QueryRowxContext executes a query that is expected to return at most one sqlx.Row.
+func (db *DB) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	ctx, end := db.startSpan(ctx, query)
+	row := db.DB.QueryRowxContext(ctx, query, args...)
+	end(row.Err())
+	return row
+}
+
+// GetContext scans a single row into dest.
+func (db *DB) GetContext(ctx context.Context, dest any, query string, args ...any) (err error) {
+	ctx, end := db.startSpan(ctx, query)
+	defer func() { end(err) }()
+	return db.DB.GetContext(ctx, dest, query, args...)
+}
+
+// SelectContext scans all rows into dest.
+func (db *DB) SelectContext(ctx context.Context, dest any, query string, args ...any) (err error) {
+	ctx, end := db.startSpan(ctx, query)
+	defer func() { end(err) }()
+	return db.DB.SelectContext(ctx, dest, query, args...)
+}
+
+// NamedExecContext executes a statement with named parameters.
+func (db *DB) NamedExecContext(ctx context.Context, query string, arg any) (res sql.Result, err error) {
+	ctx, end := db.startSpan(ctx, query)
+	defer func() { end(err) }()
+	return db.DB.NamedExecContext(ctx, query, arg)
+}
+
+// startSpan starts a span for query when tracing is enabled. The returned
+// function ends the span, recording err unless it is sql.ErrNoRows.
+func (db *DB) startSpan(ctx context.Context, query string) (context.Context, func(error)) {
+	if db.tracer == nil {
+		return ctx, func(error) {}
+	}
+	op := operation(query)
+	ctx, span := db.tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", db.DriverName()),
+			attribute.String("db.operation.name", op),
+			attribute.String("db.query.text", query),
+		),
+	)
+	return ctx, func(err error) {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// operation returns the leading SQL keyword of query, e.g. SELECT.
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDBTracing(t *testing.T) {
+	raw, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	defer raw.Close()
+	db := &DB{DB: sqlx.NewDb(raw, "mysql")}
+
+	recorder := tracetest.NewSpanRecorder()
+	db.EnableTracing(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	mock.ExpectQuery("SELECT name FROM users").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("alice"))
+	mock.ExpectExec("UPDATE users").WillReturnError(context.DeadlineExceeded)
+
+	var name string
+	if err := db.GetContext(context.Background(), &name, "SELECT name FROM users WHERE id = ?", 1); err != nil {
+		t.Fatalf("GetContext() error = %v", err)
+	}
+	if _, err := db.ExecContext(context.Background(), "update users SET name = ?", "bob"); err == nil {
+		t.Fatalf("ExecContext() expected error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	if spans[0].Name() != "SELECT" || spans[0].Status().Code == codes.Error {
+		t.Fatalf("span[0] = %q %v", spans[0].Name(), spans[0].Status())
+	}
+	if spans[1].Name() != "UPDATE" || spans[1].Status().Code != codes.Error {
+		t.Fatalf("span[1] = %q %v", spans[1].Name(), spans[1].Status())
+	}
+}
@@ -40,7 +40,7 @@ func etcdKey(instance Instance) string {
 
 // Register publishes instance into etcd with a leased key and starts lease keepalive.
 func (r *EtcdRegistrar) Register(ctx context.Context, instance Instance) error {
-	if instance.Name == "" || instance.Host == "" || instance.Port <= 0 {
+	if instance.Name == "" || instance.Host == "" || instance.Port <= 0 || instance.Weight < 0 {
 		return fmt.Errorf("discovery: invalid instance")
 	}
 
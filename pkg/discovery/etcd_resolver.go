@@ -12,7 +12,8 @@ import (
 	grpcresolver "google.golang.org/grpc/resolver"
 )
 
-// EtcdResolver exposes an etcd-backed gRPC resolver builder.
+// EtcdResolver exposes an etcd-backed gRPC resolver builder. Resolved
+// addresses carry their Instance in the attributes, see InstanceFromAddress.
 type EtcdResolver struct {
 	log    *xlog.Logger
 	client *clientv3.Client
@@ -130,7 +131,10 @@ func (r *etcdGRPCResolver) reload() error {
 		if err := json.Unmarshal(kv.Value, &instance); err != nil {
 			continue
 		}
-		addresses[key] = grpcresolver.Address{Addr: instance.Addr(), Metadata: instance}
+		if instance.Weight <= 0 {
+			instance.Weight = DefaultWeight
+		}
+		addresses[key] = SetInstance(grpcresolver.Address{Addr: instance.Addr()}, instance)
 	}
 	r.mu.Lock()
 	r.addresses = addresses
@@ -3,16 +3,29 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	grpcresolver "google.golang.org/grpc/resolver"
 )
 
+// DefaultWeight is the weight of instances registered without one.
+const DefaultWeight = 1
+
 // Instance describes one reachable gRPC service instance.
 type Instance struct {
-	ID       string
-	Name     string
-	Host     string
-	Port     int
+	ID   string
+	Name string
+	Host string
+	Port int
+
+	// Version is the version of the service the instance runs.
+	Version string
+	// Zone is the availability zone or region the instance runs in.
+	Zone string
+	// Weight is the relative share of traffic the instance should receive.
+	// Zero means DefaultWeight.
+	Weight int
+	// Metadata holds arbitrary labels of the instance.
 	Metadata map[string]string
 }
 
@@ -21,6 +34,39 @@ func (i Instance) Addr() string {
 	return fmt.Sprintf("%s:%d", i.Host, i.Port)
 }
 
+// Equal reports whether o is an Instance equal to i. It lets instances be
+// compared as resolver attributes.
+func (i Instance) Equal(o any) bool {
+	other, ok := o.(Instance)
+	if !ok {
+		return false
+	}
+	return i.ID == other.ID &&
+		i.Name == other.Name &&
+		i.Host == other.Host &&
+		i.Port == other.Port &&
+		i.Version == other.Version &&
+		i.Zone == other.Zone &&
+		i.Weight == other.Weight &&
+		maps.Equal(i.Metadata, other.Metadata)
+}
+
+type instanceKey struct{}
+
+// SetInstance returns addr with instance stored in its attributes.
+func SetInstance(addr grpcresolver.Address, instance Instance) grpcresolver.Address {
+	addr.Attributes = addr.Attributes.WithValue(instanceKey{}, instance)
+	return addr
+}
+
+// InstanceFromAddress returns the instance stored in the attributes of an
+// address resolved by the etcd resolver, for balancers and pickers that
+// select by version, zone, weight or labels.
+func InstanceFromAddress(addr grpcresolver.Address) (Instance, bool) {
+	instance, ok := addr.Attributes.Value(instanceKey{}).(Instance)
+	return instance, ok
+}
+
 // Registrar publishes and removes service instances.
 type Registrar interface {
 	Register(ctx context.Context, instance Instance) error
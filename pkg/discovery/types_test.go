@@ -1,6 +1,10 @@
 package discovery
 
-import "testing"
+import (
+	"testing"
+
+	grpcresolver "google.golang.org/grpc/resolver"
+)
 
 func TestInstanceAddr(t *testing.T) {
 	ins := Instance{Host: "127.0.0.1", Port: 9001}
@@ -8,3 +12,29 @@ func TestInstanceAddr(t *testing.T) {
 		t.Fatalf("unexpected addr: %s", got)
 	}
 }
+
+func TestInstanceFromAddress(t *testing.T) {
+	ins := Instance{ID: "a", Name: "demo", Host: "127.0.0.1", Port: 9001, Version: "v2", Zone: "zone-a", Weight: 3, Metadata: map[string]string{"canary": "true"}}
+	addr := SetInstance(grpcresolver.Address{Addr: ins.Addr()}, ins)
+
+	got, ok := InstanceFromAddress(addr)
+	if !ok || !got.Equal(ins) {
+		t.Fatalf("InstanceFromAddress() = %+v, %v", got, ok)
+	}
+	if _, ok := InstanceFromAddress(grpcresolver.Address{Addr: ins.Addr()}); ok {
+		t.Fatal("expected no instance on a plain address")
+	}
+
+	// Reloads decode new maps; equal instances must keep addresses equal so
+	// that gRPC reuses their connections.
+	same := ins
+	same.Metadata = map[string]string{"canary": "true"}
+	if !addr.Equal(SetInstance(grpcresolver.Address{Addr: ins.Addr()}, same)) {
+		t.Fatal("expected addresses of equal instances to be equal")
+	}
+	changed := same
+	changed.Weight = 5
+	if addr.Equal(SetInstance(grpcresolver.Address{Addr: ins.Addr()}, changed)) {
+		t.Fatal("expected a weight change to change the address")
+	}
+}
@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Code is the canonical error code of an Error. The values are the names of
+// the gRPC status codes, which is also how they appear in HTTP error bodies.
+type Code string
+
+// Canonical error codes.
+const (
+	OK                 Code = "OK"
+	Canceled           Code = "CANCELLED"
+	Unknown            Code = "UNKNOWN"
+	InvalidArgument    Code = "INVALID_ARGUMENT"
+	DeadlineExceeded   Code = "DEADLINE_EXCEEDED"
+	NotFound           Code = "NOT_FOUND"
+	AlreadyExists      Code = "ALREADY_EXISTS"
+	PermissionDenied   Code = "PERMISSION_DENIED"
+	ResourceExhausted  Code = "RESOURCE_EXHAUSTED"
+	FailedPrecondition Code = "FAILED_PRECONDITION"
+	Aborted            Code = "ABORTED"
+	OutOfRange         Code = "OUT_OF_RANGE"
+	Unimplemented      Code = "UNIMPLEMENTED"
+	Internal           Code = "INTERNAL"
+	Unavailable        Code = "UNAVAILABLE"
+	DataLoss           Code = "DATA_LOSS"
+	Unauthenticated    Code = "UNAUTHENTICATED"
+)
+
+type codeMapping struct {
+	grpc codes.Code
+	http int
+}
+
+// codeMappings follows the HTTP mapping of google.rpc.Code.
+var codeMappings = map[Code]codeMapping{
+	OK:                 {codes.OK, http.StatusOK},
+	Canceled:           {codes.Canceled, 499},
+	Unknown:            {codes.Unknown, http.StatusInternalServerError},
+	InvalidArgument:    {codes.InvalidArgument, http.StatusBadRequest},
+	DeadlineExceeded:   {codes.DeadlineExceeded, http.StatusGatewayTimeout},
+	NotFound:           {codes.NotFound, http.StatusNotFound},
+	AlreadyExists:      {codes.AlreadyExists, http.StatusConflict},
+	PermissionDenied:   {codes.PermissionDenied, http.StatusForbidden},
+	ResourceExhausted:  {codes.ResourceExhausted, http.StatusTooManyRequests},
+	FailedPrecondition: {codes.FailedPrecondition, http.StatusBadRequest},
+	Aborted:            {codes.Aborted, http.StatusConflict},
+	OutOfRange:         {codes.OutOfRange, http.StatusBadRequest},
+	Unimplemented:      {codes.Unimplemented, http.StatusNotImplemented},
+	Internal:           {codes.Internal, http.StatusInternalServerError},
+	Unavailable:        {codes.Unavailable, http.StatusServiceUnavailable},
+	DataLoss:           {codes.DataLoss, http.StatusInternalServerError},
+	Unauthenticated:    {codes.Unauthenticated, http.StatusUnauthorized},
+}
+
+var codesByGRPC = func() map[codes.Code]Code {
+	m := make(map[codes.Code]Code, len(codeMappings))
+	for code, mapping := range codeMappings {
+		m[mapping.grpc] = code
+	}
+	return m
+}()
+
+// GRPCCode returns the gRPC status code of c. Unknown codes map to
+// codes.Unknown.
+func (c Code) GRPCCode() codes.Code {
+	if m, ok := codeMappings[c]; ok {
+		return m.grpc
+	}
+	return codes.Unknown
+}
+
+// HTTPStatus returns the HTTP status code of c. Unknown codes map to 500.
+func (c Code) HTTPStatus() int {
+	if m, ok := codeMappings[c]; ok {
+		return m.http
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeFromGRPC returns the Code of a gRPC status code.
+func CodeFromGRPC(code codes.Code) Code {
+	if c, ok := codesByGRPC[code]; ok {
+		return c
+	}
+	return Unknown
+}
+
+// CodeFromHTTP returns the Code of an HTTP status. Statuses shared by several
+// codes map to the most general one, e.g. 400 to InvalidArgument and 409 to
+// Aborted; other 4xx statuses map to FailedPrecondition and other 5xx
+// statuses to Internal.
+func CodeFromHTTP(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return InvalidArgument
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return PermissionDenied
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Aborted
+	case http.StatusTooManyRequests:
+		return ResourceExhausted
+	case 499:
+		return Canceled
+	case http.StatusNotImplemented:
+		return Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return Unavailable
+	case http.StatusGatewayTimeout:
+		return DeadlineExceeded
+	}
+	switch {
+	case status >= 200 && status < 300:
+		return OK
+	case status >= 400 && status < 500:
+		return FailedPrecondition
+	case status >= 500:
+		return Internal
+	}
+	return Unknown
+}
@@ -0,0 +1,189 @@
+// Package errors provides the error model shared by the rpc and api servers.
+//
+// An Error carries a canonical Code, a public Message that is safe to return
+// to callers, an optional machine-readable Reason with Metadata, field
+// violations, and an internal cause that is never sent over the wire.
+// Servers convert errors to a gRPC status with error details or to a JSON
+// body with the matching HTTP status; clients convert a gRPC status back into
+// an Error.
+//
+// Domains usually declare sentinel errors and refine them per call:
+//
+//	var ErrNotFound = errors.New(errors.NotFound, "user not found").WithReason("USER_NOT_FOUND")
+//
+//	return fmt.Errorf("user %d: %w", id, ErrNotFound)
+//	return ErrInvalidArgument.WithMessage("username is required")
+//
+// errors.Is matches errors with the same Code and Reason, so both forms match
+// their sentinel, also after a round trip through gRPC.
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is an error with a canonical code and a public message.
+type Error struct {
+	// Code is the canonical error code.
+	Code Code
+
+	// Message is the public, human-readable message.
+	Message string
+
+	// Reason identifies the error within its domain, e.g. USER_NOT_FOUND.
+	Reason string
+
+	// Metadata is additional structured information about the error.
+	Metadata map[string]string
+
+	// FieldViolations describes invalid request fields.
+	FieldViolations []FieldViolation
+
+	cause error
+}
+
+// FieldViolation describes a single invalid request field.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// New returns an Error with the given code and public message.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf returns an Error with the given code and formatted public message.
+func Newf(code Code, format string, args ...any) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap returns an Error with the given code and public message that wraps
+// err as its internal cause. It returns nil if err is nil.
+func Wrap(err error, code Code, message string) *Error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Message: message, cause: err}
+}
+
+// Error returns the message followed by the internal cause, if any.
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the internal cause.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an *Error with the same Code and Reason.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code && e.Reason == t.Reason
+}
+
+// HTTPStatus returns the HTTP status code of the error.
+func (e *Error) HTTPStatus() int {
+	return e.Code.HTTPStatus()
+}
+
+// WithMessage returns a copy of e with the given public message.
+func (e *Error) WithMessage(message string) *Error {
+	c := e.clone()
+	c.Message = message
+	return c
+}
+
+// WithReason returns a copy of e with the given reason.
+func (e *Error) WithReason(reason string) *Error {
+	c := e.clone()
+	c.Reason = reason
+	return c
+}
+
+// WithMetadata returns a copy of e with key set to value in its metadata.
+func (e *Error) WithMetadata(key, value string) *Error {
+	c := e.clone()
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string, 1)
+	}
+	c.Metadata[key] = value
+	return c
+}
+
+// WithFieldViolation returns a copy of e with an additional field violation.
+func (e *Error) WithFieldViolation(field, description string) *Error {
+	c := e.clone()
+	c.FieldViolations = append(c.FieldViolations, FieldViolation{Field: field, Description: description})
+	return c
+}
+
+// WithCause returns a copy of e that wraps err as its internal cause.
+func (e *Error) WithCause(err error) *Error {
+	c := e.clone()
+	c.cause = err
+	return c
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	c.Metadata = maps.Clone(e.Metadata)
+	c.FieldViolations = slices.Clone(e.FieldViolations)
+	return &c
+}
+
+// FromError returns err as an *Error. It returns the *Error in err's chain if
+// there is one, decodes gRPC status errors, maps context errors to Canceled
+// and DeadlineExceeded, and wraps any other error as Internal with a generic
+// public message. It returns nil if err is nil.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e
+	}
+	if st, ok := status.FromError(err); ok && st.Code() != codes.OK {
+		return FromStatus(st)
+	}
+	switch {
+	case stderrors.Is(err, context.Canceled):
+		return Wrap(err, Canceled, "request canceled")
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return Wrap(err, DeadlineExceeded, "deadline exceeded")
+	}
+	return Wrap(err, Internal, "internal error")
+}
+
+// CodeOf returns the Code of err, as FromError would, or OK if err is nil.
+func CodeOf(err error) Code {
+	if err == nil {
+		return OK
+	}
+	return FromError(err).Code
+}
+
+// Is is errors.Is from the standard library.
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As is errors.As from the standard library.
+func As(err error, target any) bool {
+	return stderrors.As(err, target)
+}
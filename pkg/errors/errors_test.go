@@ -0,0 +1,120 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errUserNotFound = New(NotFound, "user not found").WithReason("USER_NOT_FOUND")
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("user 7: %w", errUserNotFound.WithMessage("user 7 not found"))
+	if !stderrors.Is(err, errUserNotFound) {
+		t.Fatalf("Is(%v, errUserNotFound) = false", err)
+	}
+	if stderrors.Is(err, New(NotFound, "user not found")) {
+		t.Fatalf("Is() matched an error with another reason")
+	}
+	if got := err.Error(); got != "user 7: user 7 not found" {
+		t.Fatalf("Error() = %q", got)
+	}
+}
+
+func TestWithCopies(t *testing.T) {
+	base := New(InvalidArgument, "invalid argument")
+	derived := base.WithFieldViolation("name", "is required").WithMetadata("k", "v")
+	if len(base.FieldViolations) != 0 || base.Metadata != nil {
+		t.Fatalf("base error was modified: %+v", base)
+	}
+	if len(derived.FieldViolations) != 1 || derived.Metadata["k"] != "v" {
+		t.Fatalf("derived error = %+v", derived)
+	}
+}
+
+func TestCodeMapping(t *testing.T) {
+	tests := []struct {
+		code Code
+		grpc codes.Code
+		http int
+	}{
+		{InvalidArgument, codes.InvalidArgument, http.StatusBadRequest},
+		{NotFound, codes.NotFound, http.StatusNotFound},
+		{Unauthenticated, codes.Unauthenticated, http.StatusUnauthorized},
+		{ResourceExhausted, codes.ResourceExhausted, http.StatusTooManyRequests},
+		{Code("BOGUS"), codes.Unknown, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := tt.code.GRPCCode(); got != tt.grpc {
+			t.Fatalf("%s.GRPCCode() = %v, want %v", tt.code, got, tt.grpc)
+		}
+		if got := tt.code.HTTPStatus(); got != tt.http {
+			t.Fatalf("%s.HTTPStatus() = %d, want %d", tt.code, got, tt.http)
+		}
+	}
+	for code, m := range codeMappings {
+		if got := CodeFromGRPC(m.grpc); got != code {
+			t.Fatalf("CodeFromGRPC(%v) = %s, want %s", m.grpc, got, code)
+		}
+		if got := CodeFromHTTP(m.http); got.HTTPStatus() != m.http {
+			t.Fatalf("CodeFromHTTP(%d) = %s, which maps back to %d", m.http, got, got.HTTPStatus())
+		}
+	}
+	if got := CodeFromHTTP(http.StatusTeapot); got != FailedPrecondition {
+		t.Fatalf("CodeFromHTTP(418) = %s, want %s", got, FailedPrecondition)
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	sent := Wrap(stderrors.New("sql: no rows"), InvalidArgument, "bad request").
+		WithReason("USER_INVALID").
+		WithMetadata("user_id", "7").
+		WithFieldViolation("email", "is required")
+
+	st := ToStatus(fmt.Errorf("create: %w", sent))
+	if st.Code() != codes.InvalidArgument || st.Message() != "bad request" {
+		t.Fatalf("ToStatus() = %v", st)
+	}
+
+	got := FromError(st.Err())
+	if got.Code != InvalidArgument || got.Message != "bad request" || got.Reason != "USER_INVALID" {
+		t.Fatalf("FromError() = %+v", got)
+	}
+	if got.Metadata["user_id"] != "7" || len(got.FieldViolations) != 1 || got.FieldViolations[0].Field != "email" {
+		t.Fatalf("FromError() details = %+v", got)
+	}
+	if got.Unwrap() != nil {
+		t.Fatalf("internal cause crossed the wire: %v", got.Unwrap())
+	}
+	if status.Code(got) != codes.InvalidArgument {
+		t.Fatalf("status.Code() = %v", status.Code(got))
+	}
+}
+
+func TestFromError(t *testing.T) {
+	if FromError(nil) != nil || ToStatus(nil) != nil || CodeOf(nil) != OK {
+		t.Fatalf("nil error was converted")
+	}
+
+	internal := FromError(stderrors.New("db down"))
+	if internal.Code != Internal || internal.Message != "internal error" || internal.Unwrap() == nil {
+		t.Fatalf("FromError(plain) = %+v", internal)
+	}
+	if got := CodeOf(fmt.Errorf("call: %w", context.DeadlineExceeded)); got != DeadlineExceeded {
+		t.Fatalf("CodeOf(deadline) = %s", got)
+	}
+
+	// Status errors without an Error pass through ToStatus unchanged.
+	st := status.New(codes.Unavailable, "down")
+	if got := ToStatus(st.Err()); got.Code() != codes.Unavailable || got.Message() != "down" {
+		t.Fatalf("ToStatus(status) = %v", got)
+	}
+	if got := CodeOf(st.Err()); got != Unavailable {
+		t.Fatalf("CodeOf(status) = %s", got)
+	}
+}
@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// GRPCStatus returns the gRPC status of the error. The reason and metadata
+// are attached as errdetails.ErrorInfo and the field violations as
+// errdetails.BadRequest. The internal cause is not included.
+//
+// It lets the grpc status package recognise *Error directly.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.Code.GRPCCode(), e.Message)
+	var details []protoadapt.MessageV1
+	if e.Reason != "" || len(e.Metadata) > 0 {
+		details = append(details, &errdetails.ErrorInfo{Reason: e.Reason, Metadata: e.Metadata})
+	}
+	if len(e.FieldViolations) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, v := range e.FieldViolations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+		details = append(details, br)
+	}
+	if len(details) == 0 {
+		return st
+	}
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// FromStatus decodes a gRPC status into an Error, restoring the reason,
+// metadata and field violations from its details. It returns nil if st is nil
+// or OK.
+func FromStatus(st *status.Status) *Error {
+	if st == nil || st.Err() == nil {
+		return nil
+	}
+	e := New(CodeFromGRPC(st.Code()), st.Message())
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			e.Reason = d.GetReason()
+			if len(d.GetMetadata()) > 0 {
+				e.Metadata = d.GetMetadata()
+			}
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				e.FieldViolations = append(e.FieldViolations, FieldViolation{
+					Field:       v.GetField(),
+					Description: v.GetDescription(),
+				})
+			}
+		}
+	}
+	return e
+}
+
+// ToStatus returns the gRPC status to send for err: the status of the *Error
+// in err's chain, err's own status if it already is a status error, or the
+// status of FromError(err) otherwise. It returns nil if err is nil.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if As(err, &e) {
+		return e.GRPCStatus()
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	return FromError(err).GRPCStatus()
+}
@@ -0,0 +1,32 @@
+package health
+
+import (
+	"errors"
+	"time"
+)
+
+// Config configures the health reporting of an application.
+//
+// Example:
+//
+//	health:
+//	  shutdownDelay: 5s
+//	  cacheTTL: 1s
+type Config struct {
+	// ShutdownDelay is how long readiness reports down before services
+	// stop, so that load balancers stop routing new requests first. It
+	// counts toward the shutdown timeout of the app (default: 0).
+	ShutdownDelay time.Duration `yaml:"shutdownDelay" json:"shutdownDelay" toml:"shutdownDelay"`
+
+	// CacheTTL is how long the result of a check is reused by probes
+	// (default: 1s). See WithCacheTTL.
+	CacheTTL time.Duration `yaml:"cacheTTL" json:"cacheTTL" toml:"cacheTTL"`
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	if c.ShutdownDelay < 0 || c.CacheTTL < 0 {
+		return errors.New("health: shutdownDelay and cacheTTL cannot be negative")
+	}
+	return nil
+}
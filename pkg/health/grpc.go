@@ -0,0 +1,101 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const defaultWatchInterval = 5 * time.Second
+
+// GRPCServer implements the standard grpc.health.v1.Health service on top of
+// a Registry. The empty service name reports overall readiness; the name of
+// a registered check reports that check alone.
+type GRPCServer struct {
+	healthpb.UnimplementedHealthServer
+
+	registry      *Registry
+	watchInterval time.Duration
+}
+
+// NewGRPCServer creates a grpc.health.v1 server backed by r. Watch streams
+// re-evaluate the checks every interval (default: 5s).
+func NewGRPCServer(r *Registry, interval time.Duration) *GRPCServer {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	return &GRPCServer{registry: r, watchInterval: interval}
+}
+
+// Register registers the health service on reg.
+func (s *GRPCServer) Register(reg grpc.ServiceRegistrar) {
+	healthpb.RegisterHealthServer(reg, s)
+}
+
+// Check implements healthpb.HealthServer.
+func (s *GRPCServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, ok := s.status(ctx, req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// List implements healthpb.HealthServer.
+func (s *GRPCServer) List(ctx context.Context, _ *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	statuses := make(map[string]*healthpb.HealthCheckResponse)
+	for _, name := range append([]string{""}, s.registry.Names()...) {
+		if st, ok := s.status(ctx, name); ok {
+			statuses[name] = &healthpb.HealthCheckResponse{Status: st}
+		}
+	}
+	return &healthpb.HealthListResponse{Statuses: statuses}, nil
+}
+
+// Watch implements healthpb.HealthServer. It sends the current status and
+// then every change until the client goes away.
+func (s *GRPCServer) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		st, ok := s.status(ctx, req.GetService())
+		if !ok {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *GRPCServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	up := false
+	if service == "" {
+		up = s.registry.Ready(ctx).Up()
+	} else {
+		res, ok := s.registry.CheckNamed(ctx, service)
+		if !ok {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+		}
+		up = res.Status == StatusUp && !s.registry.ShuttingDown()
+	}
+	if up {
+		return healthpb.HealthCheckResponse_SERVING, true
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING, true
+}
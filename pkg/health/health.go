@@ -0,0 +1,272 @@
+// Package health provides a registry of health checks reported through
+// liveness and readiness probes over HTTP and grpc.health.v1.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = time.Second
+)
+
+// Status is the outcome of a check or of a whole probe.
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// ErrShuttingDown is reported by readiness once Shutdown has been called.
+var ErrShuttingDown = errors.New("health: shutting down")
+
+// Check reports the health of one dependency. A nil error means healthy.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of one check.
+type Result struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the outcome of a liveness or readiness probe.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Up reports whether every check passed.
+func (r Report) Up() bool { return r.Status == StatusUp }
+
+// Redacted returns the report without the check errors, which may name
+// internal hosts and ports.
+func (r Report) Redacted() Report {
+	checks := make(map[string]Result, len(r.Checks))
+	for name, res := range r.Checks {
+		checks[name] = Result{Status: res.Status}
+	}
+	return Report{Status: r.Status, Checks: checks}
+}
+
+type check struct {
+	name     string
+	fn       Check
+	liveness bool
+
+	// mu serializes runs of the check, so that concurrent probes share one
+	// run, and guards the cached result.
+	mu     sync.Mutex
+	result Result
+	ranAt  time.Time
+}
+
+// CheckOption customizes a registered check.
+type CheckOption func(*check)
+
+// Liveness makes the check count toward liveness as well as readiness.
+// Use it only for failures that a process restart can fix.
+func Liveness() CheckOption {
+	return func(c *check) {
+		c.liveness = true
+	}
+}
+
+// Registry holds the health checks of an application.
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.RWMutex
+	checks []*check
+
+	shuttingDown atomic.Bool
+}
+
+// Option customizes a Registry.
+type Option func(*Registry)
+
+// WithTimeout bounds each check run by a probe (default: 2s).
+func WithTimeout(d time.Duration) Option {
+	return func(r *Registry) {
+		if d > 0 {
+			r.timeout = d
+		}
+	}
+}
+
+// WithCacheTTL reuses the result of each check for d, so that frequent or
+// anonymous probes do not multiply the load on the checked dependencies
+// (default: 1s). Zero runs the checks on every probe.
+func WithCacheTTL(d time.Duration) Option {
+	return func(r *Registry) {
+		r.cacheTTL = max(d, 0)
+	}
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{timeout: defaultTimeout, cacheTTL: defaultCacheTTL}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(r)
+		}
+	}
+	return r
+}
+
+// Register adds a readiness check under a unique name.
+func (r *Registry) Register(name string, fn Check, opts ...CheckOption) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("health: check name is required")
+	}
+	if fn == nil {
+		return fmt.Errorf("health: check %q: function is required", name)
+	}
+	c := &check{name: name, fn: fn}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.checks {
+		if existing.name == name {
+			return fmt.Errorf("health: check %q already registered", name)
+		}
+	}
+	r.checks = append(r.checks, c)
+	return nil
+}
+
+// Names returns the names of the registered checks in registration order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checks))
+	for _, c := range r.checks {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// Shutdown marks the application as shutting down. From then on readiness
+// reports down regardless of the checks, so load balancers stop routing new
+// traffic while in-flight requests drain.
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether Shutdown has been called.
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.run(ctx, func(c *check) bool { return c.liveness })
+}
+
+// Ready runs every check. It reports down once Shutdown has been called.
+func (r *Registry) Ready(ctx context.Context) Report {
+	if r.ShuttingDown() {
+		return Report{
+			Status: StatusDown,
+			Checks: map[string]Result{"shutdown": {Status: StatusDown, Error: ErrShuttingDown.Error()}},
+		}
+	}
+	return r.run(ctx, func(*check) bool { return true })
+}
+
+// CheckNamed runs the single check registered under name.
+// It returns false if no such check exists.
+func (r *Registry) CheckNamed(ctx context.Context, name string) (Result, bool) {
+	r.mu.RLock()
+	var found *check
+	for _, c := range r.checks {
+		if c.name == name {
+			found = c
+			break
+		}
+	}
+	r.mu.RUnlock()
+	if found == nil {
+		return Result{}, false
+	}
+	return r.runCheck(ctx, found), true
+}
+
+// run executes the selected checks concurrently.
+func (r *Registry) run(ctx context.Context, selected func(*check) bool) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if selected(c) {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := r.runCheck(ctx, c)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = res
+			if res.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// runCheck returns the result of c, running it unless a result younger than
+// the cache TTL is available. Results of runs cut short by ctx are not
+// cached.
+func (r *Registry) runCheck(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.cacheTTL > 0 && !c.ranAt.IsZero() && time.Since(c.ranAt) < r.cacheTTL {
+		return c.result
+	}
+	res := r.execute(ctx, c)
+	if ctx.Err() == nil {
+		c.result, c.ranAt = res, time.Now()
+	}
+	return res
+}
+
+// execute runs one check bounded by the registry timeout. A panicking check
+// is reported as down.
+func (r *Registry) execute(ctx context.Context, c *check) (res Result) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			res = Result{Status: StatusDown, Error: fmt.Sprintf("panic: %v", p)}
+		}
+	}()
+	if err := c.fn(ctx); err != nil {
+		return Result{Status: StatusDown, Error: err.Error()}
+	}
+	return Result{Status: StatusUp}
+}
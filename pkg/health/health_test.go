@@ -0,0 +1,182 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func up(context.Context) error   { return nil }
+func down(context.Context) error { return errors.New("unreachable") }
+
+func TestRegistryRegisterRejectsInvalidChecks(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(" ", up); err == nil {
+		t.Fatalf("Register() expected error for empty name")
+	}
+	if err := r.Register("db", nil); err == nil {
+		t.Fatalf("Register() expected error for nil check")
+	}
+	if err := r.Register("db", up); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register("db", up); err == nil {
+		t.Fatalf("Register() expected error for duplicate name")
+	}
+}
+
+func TestRegistryLiveAndReady(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("db", down); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register("loop", up, Liveness()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register("panicky", func(context.Context) error { panic("boom") }); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	live := r.Live(context.Background())
+	if !live.Up() || len(live.Checks) != 1 {
+		t.Fatalf("Live() = %+v, want only the liveness check up", live)
+	}
+
+	ready := r.Ready(context.Background())
+	if ready.Up() {
+		t.Fatalf("Ready() = %+v, want down", ready)
+	}
+	if got := ready.Checks["db"]; got.Status != StatusDown || got.Error != "unreachable" {
+		t.Fatalf("Ready().Checks[db] = %+v", got)
+	}
+	if got := ready.Checks["panicky"]; got.Status != StatusDown {
+		t.Fatalf("Ready().Checks[panicky] = %+v, want down", got)
+	}
+	if got := ready.Checks["loop"]; got.Status != StatusUp {
+		t.Fatalf("Ready().Checks[loop] = %+v, want up", got)
+	}
+}
+
+func TestRegistryShutdownFailsReadinessOnly(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("loop", up, Liveness()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	r.Shutdown()
+
+	if !r.Live(context.Background()).Up() {
+		t.Fatalf("Live() should stay up while shutting down")
+	}
+	ready := r.Ready(context.Background())
+	if ready.Up() || ready.Checks["shutdown"].Error != ErrShuttingDown.Error() {
+		t.Fatalf("Ready() = %+v, want shutdown down", ready)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("db", down); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		handler http.Handler
+		code    int
+		status  Status
+	}{
+		{r.LivenessHandler(), http.StatusOK, StatusUp},
+		{r.ReadinessHandler(), http.StatusServiceUnavailable, StatusDown},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != tt.code {
+			t.Fatalf("status = %d, want %d", rec.Code, tt.code)
+		}
+		var report Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("decode body error = %v", err)
+		}
+		if report.Status != tt.status {
+			t.Fatalf("report status = %q, want %q", report.Status, tt.status)
+		}
+		if strings.Contains(rec.Body.String(), "unreachable") {
+			t.Fatalf("body %s exposes the check error", rec.Body)
+		}
+	}
+}
+
+func TestRegistryCachesResults(t *testing.T) {
+	var runs atomic.Int32
+	counted := func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+	r := NewRegistry()
+	if err := r.Register("db", counted); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	for range 3 {
+		r.Ready(context.Background())
+	}
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("check ran %d times within the cache TTL, want 1", got)
+	}
+
+	runs.Store(0)
+	r = NewRegistry(WithCacheTTL(0))
+	if err := r.Register("db", counted); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	for range 3 {
+		r.Ready(context.Background())
+	}
+	if got := runs.Load(); got != 3 {
+		t.Fatalf("check ran %d times without cache, want 3", got)
+	}
+}
+
+func TestGRPCServerCheck(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("db", up); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	s := NewGRPCServer(r, 0)
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := s.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check(%q) error = %v", service, err)
+		}
+		return resp.GetStatus()
+	}
+
+	if got := check(""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Check(\"\") = %v, want SERVING", got)
+	}
+	if got := check("db"); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Check(db) = %v, want SERVING", got)
+	}
+	if _, err := s.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Check(missing) error = %v, want NotFound", err)
+	}
+
+	r.Shutdown()
+	if got := check(""); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("Check(\"\") after Shutdown = %v, want NOT_SERVING", got)
+	}
+	if got := check("db"); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("Check(db) after Shutdown = %v, want NOT_SERVING", got)
+	}
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+const (
+	// LivenessPath is the conventional route of the liveness probe.
+	LivenessPath = "/healthz"
+
+	// ReadinessPath is the conventional route of the readiness probe.
+	ReadinessPath = "/readyz"
+)
+
+// LivenessHandler serves the liveness report as JSON, with status 200 when
+// up and 503 when down. The report names the checks and their status only;
+// the errors of failed checks are logged instead, as the probes are usually
+// served to anonymous clients.
+func (r *Registry) LivenessHandler() http.Handler {
+	return reportHandler(r.Live)
+}
+
+// ReadinessHandler serves the readiness report as JSON like
+// LivenessHandler.
+func (r *Registry) ReadinessHandler() http.Handler {
+	return reportHandler(r.Ready)
+}
+
+func reportHandler(probe func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := probe(req.Context())
+		status := http.StatusOK
+		if !report.Up() {
+			status = http.StatusServiceUnavailable
+			log := xlog.Get(req.Context())
+			for name, res := range report.Checks {
+				if res.Status != StatusUp {
+					log.Warn("health check failed", "check", name, "error", res.Error)
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report.Redacted())
+	})
+}
@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/HorseArcher567/octopus/pkg/internal/circuit"
+)
+
+// ErrCircuitOpen is returned for requests rejected by an open circuit
+// breaker.
+var ErrCircuitOpen = errors.New("httpclient: circuit breaker is open")
+
+// breakerTransport fails requests fast while the circuit breaker of the
+// client is open. It sees the outcome of requests after retries.
+type breakerTransport struct {
+	next     http.RoundTripper
+	failures []int
+	breaker  *circuit.Breaker[result]
+}
+
+// result is the outcome of a request.
+type result struct {
+	resp *http.Response
+	err  error
+}
+
+func newBreakerTransport(next http.RoundTripper, client string, cfg *CircuitBreakerConfig) *breakerTransport {
+	t := &breakerTransport{next: next, failures: cfg.FailureStatuses}
+	t.breaker = circuit.New(circuit.Config{
+		Window:           cfg.Window,
+		MinRequests:      cfg.MinRequests,
+		ErrorRatio:       cfg.ErrorRatio,
+		Cooldown:         cfg.Cooldown,
+		HalfOpenRequests: cfg.HalfOpenRequests,
+		Kind:             "http",
+		Attrs:            []any{"client", client},
+	}, t.isFailure)
+	return t
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	state, ok := t.breaker.Allow(ctx)
+	if !ok {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, ErrCircuitOpen
+	}
+	resp, err := t.next.RoundTrip(req)
+	t.breaker.Record(ctx, state, result{resp: resp, err: err})
+	return resp, err
+}
+
+// isFailure reports whether a request failed. Requests canceled by the
+// caller are not failures of the server.
+func (t *breakerTransport) isFailure(ctx context.Context, r result) bool {
+	if r.err != nil {
+		return !errors.Is(ctx.Err(), context.Canceled)
+	}
+	return slices.Contains(t.failures, r.resp.StatusCode)
+}
@@ -0,0 +1,294 @@
+// Package httpclient provides HTTP clients with the resilience and
+// observability of the pkg/rpc clients.
+//
+// A Client resolves relative request paths against a base URL and sends
+// requests through a chain of round trippers: request logging, a circuit
+// breaker, retries of idempotent requests with backoff, tracing, propagation
+// of the incoming request's headers, and balancing over the addresses of a
+// service discovery target resolved by the direct:/// or etcd:/// resolvers
+// registered for gRPC.
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/HorseArcher567/octopus/pkg/propagation"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/HorseArcher567/octopus/pkg/xtls"
+	otelpropagation "go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Client is an HTTP client for one service.
+type Client struct {
+	name    string
+	baseURL *url.URL
+	client  *http.Client
+	balance *balancer
+
+	log         *xlog.Logger
+	transport   http.RoundTripper
+	tp          trace.TracerProvider
+	propagator  otelpropagation.TextMapPropagator
+	propagation bool
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithLogger sets the logger used for requests whose context carries none,
+// and by the service discovery resolver.
+func WithLogger(log *xlog.Logger) Option {
+	return func(c *Client) {
+		c.log = log
+	}
+}
+
+// WithTransport replaces the http.Transport built from the configuration,
+// e.g. in tests. TLS and connection settings of the configuration are then
+// ignored.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = rt
+	}
+}
+
+// WithTracing starts a client span for every attempt and propagates it to
+// the server in the request headers.
+func WithTracing(tp trace.TracerProvider, propagator otelpropagation.TextMapPropagator) Option {
+	return func(c *Client) {
+		c.tp = tp
+		c.propagator = propagator
+	}
+}
+
+// WithPropagation sends the request values captured by a
+// propagation.Propagator in the request context as headers.
+func WithPropagation() Option {
+	return func(c *Client) {
+		c.propagation = true
+	}
+}
+
+// New creates the client described by cfg. Service discovery targets are
+// resolved right away; Close stops resolving.
+func New(cfg *Config, opts ...Option) (*Client, error) {
+	cfg.Normalize()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("client %s: %w", cfg.Name, err)
+	}
+	baseURL, _ := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	c := &Client{name: cfg.Name, baseURL: baseURL}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.log == nil {
+		c.log = xlog.Get(context.Background())
+	}
+
+	rt := c.transport
+	if rt == nil {
+		t, err := newTransport(cfg, baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("client %s: %w", cfg.Name, err)
+		}
+		rt = t
+	}
+	if cfg.Target != "" {
+		b, err := newBalancer(c.log, cfg.Target, cfg.Port)
+		if err != nil {
+			return nil, fmt.Errorf("client %s: %w", cfg.Name, err)
+		}
+		c.balance = b
+		rt = &balancedTransport{next: rt, balancer: b}
+	}
+	if c.propagation {
+		rt = propagation.Transport(rt)
+	}
+	if c.tp != nil {
+		rt = newTracingTransport(rt, c.tp, c.propagator)
+	}
+	if cfg.Retry != nil {
+		rt = &retryTransport{next: rt, cfg: cfg.Retry}
+	}
+	if cfg.CircuitBreaker != nil {
+		rt = newBreakerTransport(rt, cfg.Name, cfg.CircuitBreaker)
+	}
+	if cfg.Logging {
+		rt = &loggingTransport{next: rt, name: cfg.Name, log: c.log}
+	}
+	c.client = &http.Client{Transport: rt, Timeout: cfg.Timeout}
+	return c, nil
+}
+
+func newTransport(cfg *Config, baseURL *url.URL) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: cfg.ConnectTimeout, KeepAlive: 30 * time.Second}
+	t.DialContext = dialer.DialContext
+	t.IdleConnTimeout = cfg.IdleConnTimeout
+	t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	// Resolved requests are sent to addresses, so certificates are verified
+	// against the host of the base URL.
+	var serverName string
+	if cfg.Target != "" {
+		serverName = baseURL.Hostname()
+	}
+	if cfg.TLS != nil {
+		client, err := cfg.TLS.NewClient()
+		if err != nil {
+			return nil, err
+		}
+		// Proxied connections use the files as loaded now; direct ones
+		// build their configuration from the current files.
+		t.TLSClientConfig = client.Config(serverName)
+		t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialTLS(ctx, dialer, client, serverName, network, addr)
+		}
+		return t, nil
+	}
+	if serverName != "" {
+		t.TLSClientConfig = &tls.Config{ServerName: serverName}
+	}
+	return t, nil
+}
+
+// dialTLS dials addr and performs the TLS handshake, verifying the server
+// certificate against serverName, or else the dialed host.
+func dialTLS(ctx context.Context, dialer *net.Dialer, client *xtls.Client, serverName, network, addr string) (net.Conn, error) {
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		serverName = host
+	}
+	raw, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	cfg := client.Config(serverName)
+	cfg.NextProtos = []string{"h2", "http/1.1"}
+	conn := tls.Client(raw, cfg)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Name returns the name of the client.
+func (c *Client) Name() string { return c.name }
+
+// HTTPClient returns the underlying http.Client, for libraries that take
+// one. Its requests need absolute URLs.
+func (c *Client) HTTPClient() *http.Client { return c.client }
+
+// URL resolves path against the base URL. Absolute URLs are returned as is.
+func (c *Client) URL(path string) string {
+	if strings.Contains(path, "://") {
+		return path
+	}
+	return c.baseURL.String() + "/" + strings.TrimLeft(path, "/")
+}
+
+// NewRequest creates a request for path, relative to the base URL.
+func (c *Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, c.URL(path), body)
+}
+
+// Do sends req.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req)
+}
+
+// Get sends a GET request for path.
+func (c *Client) Get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// DoJSON sends in, if not nil, as a JSON body and decodes the JSON response
+// into out, if not nil. Responses with a status other than 2xx are returned
+// as *errors.Error, decoded from the error body of octopus API servers or
+// else derived from the status.
+func (c *Client) DoJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("httpclient: encode request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+	req, err := c.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return ErrorFromResponse(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return fmt.Errorf("httpclient: decode response: %w", err)
+	}
+	return nil
+}
+
+// ErrorFromResponse returns the error described by a failed response: the
+// decoded error body of octopus API servers, or an error with the code of
+// the status. It reads but does not close the body.
+func ErrorFromResponse(resp *http.Response) *errors.Error {
+	payload, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body middleware.ErrorBody
+	if json.Unmarshal(payload, &body) == nil && body.Code != "" {
+		e := errors.New(body.Code, body.Message).WithReason(body.Reason)
+		for k, v := range body.Metadata {
+			e = e.WithMetadata(k, v)
+		}
+		for _, fv := range body.FieldViolations {
+			e = e.WithFieldViolation(fv.Field, fv.Description)
+		}
+		return e
+	}
+	message := strings.TrimSpace(string(payload))
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	return errors.Newf(errors.CodeFromHTTP(resp.StatusCode), "HTTP %d: %s", resp.StatusCode, message)
+}
+
+// Close stops resolving the service discovery target.
+func (c *Client) Close() error {
+	if c.balance != nil {
+		c.balance.close()
+	}
+	c.client.CloseIdleConnections()
+	return nil
+}
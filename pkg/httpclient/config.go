@@ -0,0 +1,302 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/xtls"
+)
+
+// Config declares a named HTTP client.
+//
+// Example:
+//
+//	httpClients:
+//	  - name: users
+//	    baseURL: http://user-service/api
+//	    target: etcd:///user-service
+//	    port: 8090
+//	    timeout: 5s
+//	    logging: true
+//	    retry:
+//	      maxAttempts: 3
+//	    circuitBreaker:
+//	      errorRatio: 0.5
+type Config struct {
+	// Name identifies the client, e.g. the key it is published under.
+	Name string `yaml:"name" json:"name" toml:"name"`
+
+	// BaseURL is the scheme, host and path prefix that relative request
+	// paths are resolved against. With Target, its host is only sent as the
+	// Host header and used to verify TLS certificates.
+	BaseURL string `yaml:"baseURL" json:"baseURL" toml:"baseURL"`
+
+	// Target resolves the addresses requests are sent to with a gRPC
+	// resolver scheme, such as direct:///10.0.0.1:8090,10.0.0.2:8090 or
+	// etcd:///user-service. Requests are balanced round robin. If empty,
+	// requests go to the host of BaseURL.
+	Target string `yaml:"target" json:"target" toml:"target"`
+
+	// Port replaces the port of resolved addresses, for services that
+	// register their gRPC address but serve HTTP on another port.
+	Port int `yaml:"port" json:"port" toml:"port"`
+
+	// Timeout bounds a request including retries and reading the response
+	// body (default: 10s).
+	Timeout time.Duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+
+	// ConnectTimeout bounds establishing a connection (default: 5s).
+	ConnectTimeout time.Duration `yaml:"connectTimeout" json:"connectTimeout" toml:"connectTimeout"`
+
+	// IdleConnTimeout is how long idle connections are kept (default: 90s).
+	IdleConnTimeout time.Duration `yaml:"idleConnTimeout" json:"idleConnTimeout" toml:"idleConnTimeout"`
+
+	// MaxIdleConnsPerHost is the number of idle connections kept per
+	// address (default: 16).
+	MaxIdleConnsPerHost int `yaml:"maxIdleConnsPerHost" json:"maxIdleConnsPerHost" toml:"maxIdleConnsPerHost"`
+
+	// TLS configures the client certificate and the CA bundle of https
+	// base URLs. If nil, the system roots verify the server.
+	TLS *xtls.Config `yaml:"tls" json:"tls" toml:"tls"`
+
+	// Retry retries idempotent requests that fail. If nil, requests are
+	// sent once.
+	Retry *RetryConfig `yaml:"retry" json:"retry" toml:"retry"`
+
+	// CircuitBreaker fails requests fast while the server keeps failing.
+	// If nil, no circuit breaker is used.
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuitBreaker" json:"circuitBreaker" toml:"circuitBreaker"`
+
+	// Logging logs every request through the request logger.
+	Logging bool `yaml:"logging" json:"logging" toml:"logging"`
+}
+
+// Normalize sets default values for the client configuration.
+func (c *Config) Normalize() {
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.ConnectTimeout == 0 {
+		c.ConnectTimeout = 5 * time.Second
+	}
+	if c.IdleConnTimeout == 0 {
+		c.IdleConnTimeout = 90 * time.Second
+	}
+	if c.MaxIdleConnsPerHost == 0 {
+		c.MaxIdleConnsPerHost = 16
+	}
+	if c.Retry != nil {
+		c.Retry.Normalize()
+	}
+	if c.CircuitBreaker != nil {
+		c.CircuitBreaker.Normalize()
+	}
+}
+
+// Validate validates the client configuration.
+func (c *Config) Validate() error {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return fmt.Errorf("baseURL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("baseURL must be an absolute http or https URL")
+	}
+	if c.Target != "" {
+		scheme, _, ok := strings.Cut(c.Target, ":///")
+		if !ok || scheme == "" {
+			return errors.New("target must be scheme:///endpoint, e.g. etcd:///user-service")
+		}
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return errors.New("port must be between 0 and 65535")
+	}
+	if c.Timeout < 0 || c.ConnectTimeout < 0 || c.IdleConnTimeout < 0 {
+		return errors.New("timeouts cannot be negative")
+	}
+	if c.MaxIdleConnsPerHost < 0 {
+		return errors.New("maxIdleConnsPerHost cannot be negative")
+	}
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.CircuitBreaker != nil {
+		if err := c.CircuitBreaker.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RetryConfig configures retries. Only idempotent requests are retried:
+// GET, HEAD, OPTIONS, TRACE, PUT and DELETE, and requests with an
+// Idempotency-Key header. Requests whose body cannot be replayed are sent
+// once.
+//
+// Example:
+//
+//	retry:
+//	  maxAttempts: 3
+//	  initialBackoff: 100ms
+//	  maxBackoff: 1s
+//	  retryableStatuses: [502, 503, 504]
+type RetryConfig struct {
+	// MaxAttempts is the number of attempts, including the first one
+	// (default: 3).
+	MaxAttempts int `yaml:"maxAttempts" json:"maxAttempts" toml:"maxAttempts"`
+
+	// InitialBackoff is the delay before the first retry (default: 100ms).
+	InitialBackoff time.Duration `yaml:"initialBackoff" json:"initialBackoff" toml:"initialBackoff"`
+
+	// MaxBackoff caps the delay between attempts (default: 1s). A
+	// Retry-After response header longer than MaxBackoff stops retrying.
+	MaxBackoff time.Duration `yaml:"maxBackoff" json:"maxBackoff" toml:"maxBackoff"`
+
+	// BackoffMultiplier grows the delay after each retry (default: 2).
+	BackoffMultiplier float64 `yaml:"backoffMultiplier" json:"backoffMultiplier" toml:"backoffMultiplier"`
+
+	// RetryableStatuses are the response statuses that are retried
+	// (default: [502, 503, 504]). Connection errors are always retried.
+	RetryableStatuses []int `yaml:"retryableStatuses" json:"retryableStatuses" toml:"retryableStatuses"`
+}
+
+// Normalize sets default values for the retry configuration.
+func (c *RetryConfig) Normalize() {
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = 3
+	}
+	if c.InitialBackoff == 0 {
+		c.InitialBackoff = 100 * time.Millisecond
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = time.Second
+	}
+	if c.BackoffMultiplier == 0 {
+		c.BackoffMultiplier = 2
+	}
+	if len(c.RetryableStatuses) == 0 {
+		c.RetryableStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+}
+
+// Validate validates the retry configuration.
+func (c *RetryConfig) Validate() error {
+	if c.MaxAttempts < 0 {
+		return errors.New("retry maxAttempts cannot be negative")
+	}
+	if c.InitialBackoff < 0 || c.MaxBackoff < 0 {
+		return errors.New("retry backoff cannot be negative")
+	}
+	if c.BackoffMultiplier != 0 && c.BackoffMultiplier < 1 {
+		return errors.New("retry backoffMultiplier must be at least 1")
+	}
+	if err := validateStatuses(c.RetryableStatuses); err != nil {
+		return fmt.Errorf("retry retryableStatuses: %w", err)
+	}
+	return nil
+}
+
+// CircuitBreakerConfig configures the circuit breaker of a client.
+//
+// The breaker opens when, within Window, at least MinRequests requests were
+// made and the ratio of failures reaches ErrorRatio. While open, requests
+// fail fast with ErrCircuitOpen. After Cooldown it half-opens and lets
+// HalfOpenRequests probe requests through: if they all succeed it closes,
+// and any failure opens it again.
+//
+// Example:
+//
+//	circuitBreaker:
+//	  window: 10s
+//	  minRequests: 20
+//	  errorRatio: 0.5
+//	  cooldown: 5s
+type CircuitBreakerConfig struct {
+	// Window is the period over which the error ratio is measured
+	// (default: 10s).
+	Window time.Duration `yaml:"window" json:"window" toml:"window"`
+
+	// MinRequests is the number of requests in a window below which the
+	// breaker never opens (default: 20).
+	MinRequests int `yaml:"minRequests" json:"minRequests" toml:"minRequests"`
+
+	// ErrorRatio is the failure ratio, in (0, 1], that opens the breaker
+	// (default: 0.5).
+	ErrorRatio float64 `yaml:"errorRatio" json:"errorRatio" toml:"errorRatio"`
+
+	// Cooldown is how long the breaker stays open before half-opening
+	// (default: 5s).
+	Cooldown time.Duration `yaml:"cooldown" json:"cooldown" toml:"cooldown"`
+
+	// HalfOpenRequests is the number of probe requests allowed while
+	// half-open (default: 1).
+	HalfOpenRequests int `yaml:"halfOpenRequests" json:"halfOpenRequests" toml:"halfOpenRequests"`
+
+	// FailureStatuses are the response statuses counted as failures, in
+	// addition to connection errors (default: [500, 502, 503, 504]).
+	FailureStatuses []int `yaml:"failureStatuses" json:"failureStatuses" toml:"failureStatuses"`
+}
+
+// Normalize sets default values for the circuit breaker configuration.
+func (c *CircuitBreakerConfig) Normalize() {
+	if c.Window == 0 {
+		c.Window = 10 * time.Second
+	}
+	if c.MinRequests == 0 {
+		c.MinRequests = 20
+	}
+	if c.ErrorRatio == 0 {
+		c.ErrorRatio = 0.5
+	}
+	if c.Cooldown == 0 {
+		c.Cooldown = 5 * time.Second
+	}
+	if c.HalfOpenRequests == 0 {
+		c.HalfOpenRequests = 1
+	}
+	if len(c.FailureStatuses) == 0 {
+		c.FailureStatuses = []int{
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+}
+
+// Validate validates the circuit breaker configuration.
+func (c *CircuitBreakerConfig) Validate() error {
+	if c.Window < 0 || c.Cooldown < 0 {
+		return errors.New("circuit breaker window and cooldown cannot be negative")
+	}
+	if c.MinRequests < 0 || c.HalfOpenRequests < 0 {
+		return errors.New("circuit breaker request counts cannot be negative")
+	}
+	if c.ErrorRatio < 0 || c.ErrorRatio > 1 {
+		return errors.New("circuit breaker errorRatio must be between 0 and 1")
+	}
+	if err := validateStatuses(c.FailureStatuses); err != nil {
+		return fmt.Errorf("circuit breaker failureStatuses: %w", err)
+	}
+	return nil
+}
+
+func validateStatuses(statuses []int) error {
+	for _, s := range statuses {
+		if s < 100 || s > 599 {
+			return fmt.Errorf("invalid HTTP status %d", s)
+		}
+	}
+	return nil
+}
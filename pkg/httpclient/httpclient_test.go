@@ -0,0 +1,261 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/discovery"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/HorseArcher567/octopus/pkg/rpc"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/HorseArcher567/octopus/pkg/xtls"
+	"github.com/HorseArcher567/octopus/pkg/xtls/xtlstest"
+)
+
+var errUserNotFound = errors.New(errors.NotFound, "user not found").WithReason("USER_NOT_FOUND")
+
+func TestClientDoJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users":
+			var in map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": "1", "name": in["name"]})
+		case "/api/users/2":
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(middleware.ErrorBody{Code: errors.NotFound, Message: "user 2 not found", Reason: "USER_NOT_FOUND"})
+		default:
+			http.Error(w, "upstream down", http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(&Config{Name: "users", BaseURL: server.URL + "/api/"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	var out struct{ ID, Name string }
+	if err := c.DoJSON(context.Background(), http.MethodPost, "/users", map[string]string{"name": "alice"}, &out); err != nil {
+		t.Fatalf("DoJSON() error = %v", err)
+	}
+	if out.ID != "1" || out.Name != "alice" {
+		t.Fatalf("DoJSON() decoded %+v", out)
+	}
+
+	err = c.DoJSON(context.Background(), http.MethodGet, "users/2", nil, &out)
+	if !errors.Is(err, errUserNotFound) {
+		t.Fatalf("DoJSON() error = %v, want errUserNotFound", err)
+	}
+
+	err = c.DoJSON(context.Background(), http.MethodGet, "/other", nil, nil)
+	if errors.CodeOf(err) != errors.Unavailable || !strings.Contains(err.Error(), "upstream down") {
+		t.Fatalf("DoJSON() error = %v, want UNAVAILABLE", err)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if calls.Add(1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c, err := New(&Config{Name: "flaky", BaseURL: server.URL, Retry: &RetryConfig{InitialBackoff: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.Get(context.Background(), "/")
+	if err != nil || resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("Get() = %v, %v after %d calls, want 200 after 3", resp, err, calls.Load())
+	}
+	resp.Body.Close()
+
+	calls.Store(0)
+	req, _ := c.NewRequest(context.Background(), http.MethodPost, "/", strings.NewReader("order"))
+	resp, err = c.Do(req)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("POST = %v, %v after %d calls, want one 503", resp, err, calls.Load())
+	}
+	resp.Body.Close()
+
+	calls.Store(0)
+	bodies = nil
+	req, _ = c.NewRequest(context.Background(), http.MethodPost, "/", strings.NewReader("order"))
+	req.Header.Set("Idempotency-Key", "k1")
+	resp, err = c.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("POST with Idempotency-Key = %v, %v after %d calls", resp, err, calls.Load())
+	}
+	resp.Body.Close()
+	for _, b := range bodies {
+		if b != "order" {
+			t.Fatalf("retried bodies = %q, want the body replayed", bodies)
+		}
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c, err := New(&Config{Name: "broken", BaseURL: server.URL, CircuitBreaker: &CircuitBreakerConfig{MinRequests: 2, Cooldown: time.Hour}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	for range 2 {
+		resp, err := c.Get(context.Background(), "/")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := c.Get(context.Background(), "/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Get() error = %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("server calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestClientTarget(t *testing.T) {
+	rpc.RegisterResolver(discovery.NewDirectResolver(xlog.Get(context.Background())).Builder())
+
+	var hosts sync.Map
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			hosts.Store(name, r.Host)
+			_, _ = io.WriteString(w, name)
+		}
+	}
+	a := httptest.NewServer(handler("a"))
+	defer a.Close()
+	b := httptest.NewServer(handler("b"))
+	defer b.Close()
+
+	var buf bytes.Buffer
+	log := &xlog.Logger{Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+	c, err := New(&Config{
+		Name:    "users",
+		BaseURL: "http://users.internal",
+		Target:  "direct:///" + a.Listener.Addr().String() + "," + b.Listener.Addr().String(),
+		Logging: true,
+	}, WithLogger(log))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	var got []string
+	for range 4 {
+		resp, err := c.Get(context.Background(), "/ping")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		got = append(got, string(body))
+	}
+	if strings.Join(got, "") != "abab" {
+		t.Fatalf("responses = %v, want round robin", got)
+	}
+	if host, _ := hosts.Load("a"); host != "users.internal" {
+		t.Fatalf("Host header = %v, want the base URL host", host)
+	}
+	if !strings.Contains(buf.String(), `msg="http client request completed" client=users method=GET url=http://users.internal/ping`) {
+		t.Fatalf("log %q does not record the request", buf.String())
+	}
+
+	bl := &balancer{port: "8090"}
+	if got := bl.address("10.0.0.1:9001"); got != "10.0.0.1:8090" {
+		t.Fatalf("address() = %s, want the port replaced", got)
+	}
+}
+
+func TestClientTLSVerifiesHost(t *testing.T) {
+	dir := t.TempDir()
+	ca := xtlstest.NewCA(t)
+	caFile := ca.WriteCA(t, dir)
+	certFile, keyFile := ca.Issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tlsCfg, err := (&xtls.Config{CertFile: certFile, KeyFile: keyFile, ClientAuth: xtls.ClientAuthNone}).ServerTLSConfig("h2", "http/1.1")
+	if err != nil {
+		t.Fatalf("ServerTLSConfig() error = %v", err)
+	}
+	server.TLS = tlsCfg
+	server.StartTLS()
+	defer server.Close()
+
+	// The certificate is valid for localhost only.
+	get := func(cfg *xtls.Config) error {
+		c, err := New(&Config{Name: "secure", BaseURL: server.URL, TLS: cfg})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		defer c.Close()
+		resp, err := c.Get(context.Background(), "/")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	if err := get(&xtls.Config{CAFile: caFile}); err == nil {
+		t.Fatalf("Get() of %s should fail for a localhost certificate", server.URL)
+	}
+	if err := get(&xtls.Config{CAFile: caFile, ServerName: "localhost"}); err != nil {
+		t.Fatalf("Get() with serverName localhost error = %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{BaseURL: "users/api"}, "absolute http or https URL"},
+		{Config{BaseURL: "http://users", Target: "users:8080"}, "scheme:///endpoint"},
+		{Config{BaseURL: "http://users", Port: 70000}, "port"},
+		{Config{BaseURL: "http://users", Retry: &RetryConfig{BackoffMultiplier: 0.5}}, "backoffMultiplier"},
+		{Config{BaseURL: "http://users", CircuitBreaker: &CircuitBreakerConfig{FailureStatuses: []int{1000}}}, "invalid HTTP status"},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("Validate(%+v) error = %v, want %q", tt.cfg, err, tt.want)
+		}
+	}
+	if _, err := New(&Config{BaseURL: "http://users", Target: "nope:///users"}); err == nil || !strings.Contains(err.Error(), `no resolver registered for scheme "nope"`) {
+		t.Fatalf("New() error = %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+// loggingTransport logs requests through the logger of the request context,
+// or the client logger. Failed requests and 5xx responses are logged as
+// errors, others at debug level.
+type loggingTransport struct {
+	next http.RoundTripper
+	name string
+	log  *xlog.Logger
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	log := xlog.GetOr(req.Context(), t.log).With(
+		"client", t.name,
+		"method", req.Method,
+		"url", req.URL.Redacted(),
+	)
+
+	resp, err := t.next.RoundTrip(req)
+
+	duration := time.Since(start)
+	switch {
+	case err != nil:
+		if !errors.Is(err, context.Canceled) {
+			log.Error("http client request failed", "duration", duration, "error", err)
+		}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		log.Error("http client request failed", "duration", duration, "status", resp.StatusCode)
+	default:
+		log.Debug("http client request completed", "duration", duration, "status", resp.StatusCode)
+	}
+	return resp, err
+}
@@ -0,0 +1,152 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	grpcresolver "google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// ErrNoAddress is returned for requests to a target that resolved to no
+// address.
+var ErrNoAddress = errors.New("httpclient: no address resolved for target")
+
+// balancer resolves a target with the gRPC resolver registered for its
+// scheme, e.g. by rpc.RegisterResolver, and picks addresses round robin.
+type balancer struct {
+	log    *xlog.Logger
+	target string
+	port   string
+
+	resolver grpcresolver.Resolver
+	ready    chan struct{}
+	once     sync.Once
+
+	mu    sync.RWMutex
+	addrs []string
+	next  atomic.Uint64
+}
+
+func newBalancer(log *xlog.Logger, target string, port int) (*balancer, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+	builder := grpcresolver.Get(u.Scheme)
+	if builder == nil {
+		return nil, fmt.Errorf("target: no resolver registered for scheme %q", u.Scheme)
+	}
+	b := &balancer{log: log, target: target, ready: make(chan struct{})}
+	if port > 0 {
+		b.port = strconv.Itoa(port)
+	}
+	r, err := builder.Build(grpcresolver.Target{URL: *u}, &resolverConn{b: b}, grpcresolver.BuildOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+	b.resolver = r
+	return b, nil
+}
+
+// pick returns the next address, waiting for the first resolution until
+// the request is done.
+func (b *balancer) pick(req *http.Request) (string, error) {
+	select {
+	case <-b.ready:
+	case <-req.Context().Done():
+		return "", req.Context().Err()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.addrs) == 0 {
+		return "", fmt.Errorf("%w %s", ErrNoAddress, b.target)
+	}
+	return b.addrs[(b.next.Add(1)-1)%uint64(len(b.addrs))], nil
+}
+
+func (b *balancer) update(state grpcresolver.State) {
+	var addrs []string
+	for _, a := range state.Addresses {
+		addrs = append(addrs, b.address(a.Addr))
+	}
+	if len(state.Addresses) == 0 {
+		for _, e := range state.Endpoints {
+			if len(e.Addresses) > 0 {
+				addrs = append(addrs, b.address(e.Addresses[0].Addr))
+			}
+		}
+	}
+	b.mu.Lock()
+	b.addrs = addrs
+	b.mu.Unlock()
+	b.once.Do(func() { close(b.ready) })
+}
+
+// address replaces the port of addr with the configured one.
+func (b *balancer) address(addr string) string {
+	if b.port == "" {
+		return addr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.JoinHostPort(host, b.port)
+}
+
+func (b *balancer) close() {
+	b.resolver.Close()
+}
+
+// resolverConn receives the updates of a gRPC resolver.
+type resolverConn struct {
+	b *balancer
+}
+
+func (c *resolverConn) UpdateState(state grpcresolver.State) error {
+	c.b.update(state)
+	return nil
+}
+
+func (c *resolverConn) ReportError(err error) {
+	c.b.log.Warn("http client resolver error", "target", c.b.target, "error", err)
+}
+
+func (c *resolverConn) NewAddress(addrs []grpcresolver.Address) {
+	c.b.update(grpcresolver.State{Addresses: addrs})
+}
+
+func (c *resolverConn) ParseServiceConfig(string) *serviceconfig.ParseResult {
+	return &serviceconfig.ParseResult{Err: errors.New("httpclient: service config is not supported")}
+}
+
+// balancedTransport sends requests to the addresses of a balancer. The Host
+// header keeps the host of the request URL.
+type balancedTransport struct {
+	next     http.RoundTripper
+	balancer *balancer
+}
+
+func (t *balancedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	addr, err := t.balancer.pick(req)
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	out := req.Clone(req.Context())
+	if out.Host == "" {
+		out.Host = req.URL.Host
+	}
+	out.URL.Host = addr
+	return t.next.RoundTrip(out)
+}
@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/HorseArcher567/octopus/pkg/httpclient"
+
+// tracingTransport starts a client span for each attempt and injects it into
+// the request headers.
+type tracingTransport struct {
+	next       http.RoundTripper
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func newTracingTransport(next http.RoundTripper, tp trace.TracerProvider, propagator propagation.TextMapPropagator) *tracingTransport {
+	return &tracingTransport{next: next, tracer: tp.Tracer(tracerName), propagator: propagator}
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.Redacted()),
+			attribute.String("server.address", req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	out := req.Clone(ctx)
+	if t.propagator != nil {
+		t.propagator.Inject(ctx, propagation.HeaderCarrier(out.Header))
+	}
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,152 @@
+// Package circuit implements the circuit breaker state machine shared by the
+// rpc and httpclient packages, which adapt it to interceptors and
+// transports and decide which outcomes are failures.
+package circuit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+// State is the state of a Breaker.
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Config configures a Breaker. The durations and counts are the normalized
+// values of the rpc and httpclient circuit breaker configurations.
+type Config struct {
+	Window           time.Duration
+	MinRequests      int
+	ErrorRatio       float64
+	Cooldown         time.Duration
+	HalfOpenRequests int
+
+	// Kind prefixes the log messages of state changes, e.g. "rpc".
+	Kind string
+	// Attrs are log attributes identifying the breaker.
+	Attrs []any
+	// Now returns the current time (default: time.Now).
+	Now func() time.Time
+}
+
+// Breaker opens when, within Window, at least MinRequests calls were made
+// and the ratio of failures reaches ErrorRatio. While open, calls are
+// rejected. After Cooldown it half-opens and lets HalfOpenRequests probe
+// calls through: if they all succeed it closes, and any failure opens it
+// again. Outcomes of type T are classified by the isFailure func.
+type Breaker[T any] struct {
+	cfg       Config
+	isFailure func(context.Context, T) bool
+
+	mu          sync.Mutex
+	state       State
+	windowStart time.Time
+	requests    int
+	failed      int
+	openedAt    time.Time
+	probes      int
+	probeOK     int
+}
+
+// New creates a closed breaker that classifies outcomes with isFailure.
+func New[T any](cfg Config, isFailure func(context.Context, T) bool) *Breaker[T] {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Breaker[T]{cfg: cfg, isFailure: isFailure, windowStart: cfg.Now()}
+}
+
+// Allow reports whether a call may proceed. It returns the state the call
+// was admitted in so that its outcome can be recorded against it.
+func (b *Breaker[T]) Allow(ctx context.Context) (State, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.cfg.Now()
+	switch b.state {
+	case Open:
+		if now.Sub(b.openedAt) < b.cfg.Cooldown {
+			return Open, false
+		}
+		b.transition(ctx, HalfOpen, now)
+		fallthrough
+	case HalfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			return HalfOpen, false
+		}
+		b.probes++
+		return HalfOpen, true
+	default:
+		if now.Sub(b.windowStart) >= b.cfg.Window {
+			b.windowStart, b.requests, b.failed = now, 0, 0
+		}
+		return Closed, true
+	}
+}
+
+// Record records the outcome of a call admitted in state admitted.
+func (b *Breaker[T]) Record(ctx context.Context, admitted State, outcome T) {
+	failed := b.isFailure(ctx, outcome)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.cfg.Now()
+	switch {
+	case admitted == HalfOpen && b.state == HalfOpen:
+		if failed {
+			b.transition(ctx, Open, now)
+			return
+		}
+		b.probeOK++
+		if b.probeOK >= b.cfg.HalfOpenRequests {
+			b.transition(ctx, Closed, now)
+		}
+	case admitted == Closed && b.state == Closed:
+		b.requests++
+		if failed {
+			b.failed++
+		}
+		if b.requests >= b.cfg.MinRequests && float64(b.failed)/float64(b.requests) >= b.cfg.ErrorRatio {
+			b.transition(ctx, Open, now)
+		}
+	}
+}
+
+// transition moves the breaker to state and logs the change. The caller
+// holds b.mu.
+func (b *Breaker[T]) transition(ctx context.Context, to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.probes, b.probeOK = 0, 0
+	log := xlog.Get(ctx).With(b.cfg.Attrs...).With("from", from.String(), "to", to.String())
+	switch to {
+	case Open:
+		b.openedAt = now
+		log.Warn(b.cfg.Kind+" circuit breaker opened",
+			"requests", b.requests,
+			"failures", b.failed,
+			"cooldown", b.cfg.Cooldown)
+	case Closed:
+		b.windowStart, b.requests, b.failed = now, 0, 0
+		log.Info(b.cfg.Kind + " circuit breaker closed")
+	default:
+		log.Info(b.cfg.Kind + " circuit breaker half-open")
+	}
+}
@@ -0,0 +1,54 @@
+package circuit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := New(Config{
+		Window:           time.Minute,
+		MinRequests:      2,
+		ErrorRatio:       0.5,
+		Cooldown:         time.Second,
+		HalfOpenRequests: 1,
+		Kind:             "test",
+		Now:              func() time.Time { return now },
+	}, func(_ context.Context, failed bool) bool { return failed })
+	ctx := context.Background()
+
+	call := func(failed bool) bool {
+		state, ok := b.Allow(ctx)
+		if ok {
+			b.Record(ctx, state, failed)
+		}
+		return ok
+	}
+
+	if !call(false) || !call(true) {
+		t.Fatal("closed breaker rejected a call")
+	}
+	if call(false) {
+		t.Fatal("breaker did not open at the error ratio")
+	}
+
+	now = now.Add(time.Second)
+	state, ok := b.Allow(ctx)
+	if !ok || state != HalfOpen {
+		t.Fatalf("Allow() after cooldown = %s, %v, want a half-open probe", state, ok)
+	}
+	if _, ok := b.Allow(ctx); ok {
+		t.Fatal("half-open breaker admitted more than HalfOpenRequests probes")
+	}
+	b.Record(ctx, state, true)
+	if call(false) {
+		t.Fatal("failed probe did not reopen the breaker")
+	}
+
+	now = now.Add(time.Second)
+	if !call(false) || !call(false) {
+		t.Fatal("successful probe did not close the breaker")
+	}
+}
@@ -0,0 +1,50 @@
+// Package route matches requests against the route patterns of the server
+// middleware configurations: auth public routes, authorization rules, rate
+// limit rules and load shedding priorities.
+//
+// A pattern is an HTTP route template, optionally preceded by a method, e.g.
+// "GET /v1/users/:id", or a full gRPC method name, e.g.
+// "/user.v1.UserService/GetUser". A trailing * matches any suffix, e.g.
+// "/user.v1.UserService/*".
+package route
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Match reports whether route, requested with method, matches pattern.
+// Method is empty for gRPC calls, which only match patterns without one.
+func Match(pattern, method, route string) bool {
+	m, path, ok := strings.Cut(strings.TrimSpace(pattern), " ")
+	if !ok {
+		m, path = "", m
+	}
+	if m != "" && !strings.EqualFold(m, method) {
+		return false
+	}
+	path = strings.TrimSpace(path)
+	if prefix, ok := strings.CutSuffix(path, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return path == route
+}
+
+// MatchAny reports whether route, requested with method, matches one of
+// patterns.
+func MatchAny(patterns []string, method, route string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, method, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryAfterSeconds formats d as the Retry-After value of a rejected
+// request, rounded up to whole seconds and at least 1.
+func RetryAfterSeconds(d time.Duration) string {
+	secs := int64((d + time.Second - 1) / time.Second)
+	return strconv.FormatInt(max(secs, 1), 10)
+}
@@ -0,0 +1,38 @@
+package route
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, method, route string
+		want                   bool
+	}{
+		{"/healthz", "GET", "/healthz", true},
+		{"/healthz", "GET", "/healthz/x", false},
+		{"GET /v1/users/:id", "get", "/v1/users/:id", true},
+		{"GET /v1/users/:id", "POST", "/v1/users/:id", false},
+		{"  POST  /v1/*", "POST", "/v1/orders", true},
+		{"/user.v1.UserService/*", "", "/user.v1.UserService/GetUser", true},
+		{"GET /user.v1.UserService/*", "", "/user.v1.UserService/GetUser", false},
+		{"*", "DELETE", "/anything", true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.method, tt.route); got != tt.want {
+			t.Fatalf("Match(%q, %q, %q) = %v, want %v", tt.pattern, tt.method, tt.route, got, tt.want)
+		}
+	}
+	if MatchAny(nil, "GET", "/") {
+		t.Fatal("MatchAny() with no patterns should not match")
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	for d, want := range map[time.Duration]string{0: "1", 1500 * time.Millisecond: "2", time.Minute: "60"} {
+		if got := RetryAfterSeconds(d); got != want {
+			t.Fatalf("RetryAfterSeconds(%s) = %s, want %s", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package job
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultAdminPath is the route prefix of the admin routes.
+const DefaultAdminPath = "/admin/jobs"
+
+// DefaultAdminRoles are the roles allowed to use the admin routes.
+var DefaultAdminRoles = []string{"admin"}
+
+// AdminConfig configures the admin HTTP routes of the scheduler, which the
+// assembled app mounts on the API server.
+type AdminConfig struct {
+	// Enabled mounts the admin routes on the API server. The API server
+	// must authenticate requests.
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+
+	// Path is the route prefix of the admin routes (default: /admin/jobs).
+	Path string `yaml:"path" json:"path" toml:"path"`
+
+	// Roles are the principal roles allowed to use the admin routes
+	// (default: [admin]).
+	Roles []string `yaml:"roles" json:"roles" toml:"roles"`
+}
+
+// Validate validates the admin configuration.
+func (c *AdminConfig) Validate() error {
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		return errors.New("job: admin.path must start with /")
+	}
+	for _, role := range c.Roles {
+		if strings.TrimSpace(role) == "" {
+			return errors.New("job: admin.roles cannot contain empty roles")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package job
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SchedulerConfig is the configuration for the job scheduler.
+//
+// Example:
+//
+//	jobScheduler:
+//	  logger: jobs
+//	  timezone: Asia/Shanghai
+//	  leaderElection:
+//	    etcd: default
+//	    ttl: 15s
+//	  lock:
+//	    redis: default
+//	    ttl: 1m
+//	  admin:
+//	    enabled: true
+//	    path: /admin/jobs
+//	  jobs:
+//	    report.daily:
+//	      schedule: "0 3 * * *"
+//	      singleton: true
+//	    cache.refresh:
+//	      schedule: "@every 30s"
+//	      timezone: UTC
+//	      overlap: skip
+//	      timeout: 20s
+//	      lock: true
+//	      retry:
+//	        maxAttempts: 3
+//	        initialBackoff: 1s
+//	    events.consumer:
+//	      onFailure: restart
+//	      restart:
+//	        initialBackoff: 1s
+//	        maxBackoff: 1m
+type SchedulerConfig struct {
+	// Logger is the name of the logger to use for the scheduler.
+	// If empty, the app logger will be used.
+	Logger string `yaml:"logger" json:"logger" toml:"logger"`
+
+	// Timezone is the default IANA time zone used to evaluate job schedules.
+	// If empty, the local time zone is used.
+	Timezone string `yaml:"timezone" json:"timezone" toml:"timezone"`
+
+	// LeaderElection enables singleton jobs. If nil, singleton jobs are rejected.
+	LeaderElection *LeaderElectionConfig `yaml:"leaderElection" json:"leaderElection" toml:"leaderElection"`
+
+	// Lock enables lock-protected jobs. If nil, lock-protected jobs are rejected.
+	Lock *LockConfig `yaml:"lock" json:"lock" toml:"lock"`
+
+	// Admin mounts the job admin routes on the API server.
+	Admin AdminConfig `yaml:"admin" json:"admin" toml:"admin"`
+
+	// Jobs declares or overrides per-job settings keyed by job name.
+	// Values set here take precedence over options given at registration.
+	Jobs map[string]JobConfig `yaml:"jobs" json:"jobs" toml:"jobs"`
+}
+
+// LeaderElectionConfig configures cluster-wide leader election for singleton jobs.
+type LeaderElectionConfig struct {
+	// Etcd is the name of the etcd client used for leader election.
+	Etcd string `yaml:"etcd" json:"etcd" toml:"etcd"`
+
+	// Prefix is the etcd key prefix of election keys (default: /octopus/jobs/leader/).
+	// Replicas that share a prefix and job name compete for the same leadership.
+	Prefix string `yaml:"prefix" json:"prefix" toml:"prefix"`
+
+	// TTL is the lease TTL; a crashed leader is replaced after at most TTL (default: 15s).
+	TTL time.Duration `yaml:"ttl" json:"ttl" toml:"ttl"`
+}
+
+// LockConfig configures the Redis locks that let each activation of a
+// lock-protected job run on only one replica.
+type LockConfig struct {
+	// Redis is the name of the Redis client used for locks.
+	Redis string `yaml:"redis" json:"redis" toml:"redis"`
+
+	// Prefix is the Redis key prefix of activation locks (default: octopus:lock:).
+	Prefix string `yaml:"prefix" json:"prefix" toml:"prefix"`
+
+	// TTL is how long an activation stays claimed (default: 1m). It must
+	// exceed the clock skew between replicas.
+	TTL time.Duration `yaml:"ttl" json:"ttl" toml:"ttl"`
+}
+
+// JobConfig holds the config-level settings of one job.
+type JobConfig struct {
+	// Schedule is a cron expression or descriptor such as "@every 30s".
+	Schedule string `yaml:"schedule" json:"schedule" toml:"schedule"`
+
+	// Timezone overrides the scheduler timezone for this job.
+	Timezone string `yaml:"timezone" json:"timezone" toml:"timezone"`
+
+	// Overlap is one of "skip", "queue" or "replace".
+	Overlap OverlapPolicy `yaml:"overlap" json:"overlap" toml:"overlap"`
+
+	// Timeout bounds each attempt of the job.
+	Timeout time.Duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+
+	// Retry retries failed runs with exponential backoff.
+	Retry *RetryPolicy `yaml:"retry" json:"retry" toml:"retry"`
+
+	// OnFailure is one of "continue", "fatal" or "restart".
+	OnFailure FailurePolicy `yaml:"onFailure" json:"onFailure" toml:"onFailure"`
+
+	// Restart configures the backoff used when OnFailure is "restart".
+	Restart *RestartPolicy `yaml:"restart" json:"restart" toml:"restart"`
+
+	// Singleton runs the job on only one replica, elected through LeaderElection.
+	Singleton bool `yaml:"singleton" json:"singleton" toml:"singleton"`
+
+	// Lock runs each activation on only one replica, claimed through a Redis lock.
+	Lock bool `yaml:"lock" json:"lock" toml:"lock"`
+}
+
+// Validate validates the scheduler configuration.
+func (c *SchedulerConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	loc, err := loadLocation(c.Timezone)
+	if err != nil {
+		return err
+	}
+	if c.LeaderElection != nil {
+		if strings.TrimSpace(c.LeaderElection.Etcd) == "" {
+			return fmt.Errorf("job: leaderElection.etcd is required")
+		}
+		if c.LeaderElection.TTL < 0 {
+			return fmt.Errorf("job: leaderElection.ttl cannot be negative")
+		}
+	}
+	if err := c.Admin.Validate(); err != nil {
+		return err
+	}
+	if c.Lock != nil {
+		if strings.TrimSpace(c.Lock.Redis) == "" {
+			return fmt.Errorf("job: lock.redis is required")
+		}
+		if c.Lock.TTL < 0 {
+			return fmt.Errorf("job: lock.ttl cannot be negative")
+		}
+	}
+	for name, jc := range c.Jobs {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("job: jobs: name is required")
+		}
+		jobLoc := loc
+		if jc.Timezone != "" {
+			if jobLoc, err = loadLocation(jc.Timezone); err != nil {
+				return fmt.Errorf("job: jobs[%s]: %w", name, err)
+			}
+		}
+		if jc.Schedule != "" {
+			if _, err := ParseSchedule(jc.Schedule, jobLoc); err != nil {
+				return fmt.Errorf("job: jobs[%s]: %w", name, err)
+			}
+		}
+		if err := jc.Overlap.Validate(); err != nil {
+			return fmt.Errorf("job: jobs[%s]: %w", name, err)
+		}
+		if jc.Timeout < 0 {
+			return fmt.Errorf("job: jobs[%s]: timeout cannot be negative", name)
+		}
+		if err := jc.Retry.Validate(); err != nil {
+			return fmt.Errorf("job: jobs[%s]: %w", name, err)
+		}
+		if err := jc.OnFailure.Validate(); err != nil {
+			return fmt.Errorf("job: jobs[%s]: %w", name, err)
+		}
+		if err := jc.Restart.Validate(); err != nil {
+			return fmt.Errorf("job: jobs[%s]: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// loadLocation resolves an IANA zone name. An empty name means time.Local.
+func loadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("job: invalid timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
@@ -3,6 +3,9 @@ package job
 import (
 	"context"
 	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
 
 	"github.com/HorseArcher567/octopus/pkg/store"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
@@ -10,9 +13,8 @@ import (
 
 type Func func(*Context) error
 
-type SchedulerConfig struct {
-	Logger string `yaml:"logger" json:"logger" toml:"logger"`
-}
+// ErrPanicked is wrapped by the error returned from Job.Run when the job panics.
+var ErrPanicked = errors.New("job panicked")
 
 type Context struct {
 	store.Reader
@@ -34,6 +36,126 @@ func (c *Context) Name() string { return c.name }
 type Job struct {
 	Name string `yaml:"name" json:"name" toml:"name"`
 	Func Func   `yaml:"func" json:"func" toml:"func"`
+
+	// Schedule is a cron expression or descriptor (see ParseSchedule).
+	// If empty, the job runs once when the scheduler starts.
+	Schedule string `yaml:"schedule" json:"schedule" toml:"schedule"`
+
+	// Timezone is the IANA time zone used to evaluate Schedule.
+	// If empty, the scheduler timezone is used.
+	Timezone string `yaml:"timezone" json:"timezone" toml:"timezone"`
+
+	// Overlap controls activations that fire while a previous run is still in progress.
+	Overlap OverlapPolicy `yaml:"overlap" json:"overlap" toml:"overlap"`
+
+	// Timeout bounds each attempt through the deadline of Context.Context().
+	// Zero means no timeout.
+	Timeout time.Duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+
+	// Retry retries failed runs with exponential backoff. Nil disables retries.
+	Retry *RetryPolicy `yaml:"retry" json:"retry" toml:"retry"`
+
+	// OnFailure controls how the scheduler reacts to a failed or panicking run.
+	OnFailure FailurePolicy `yaml:"onFailure" json:"onFailure" toml:"onFailure"`
+
+	// Restart configures the backoff used by FailureRestart.
+	Restart *RestartPolicy `yaml:"restart" json:"restart" toml:"restart"`
+
+	// Singleton runs the job only on the replica holding its cluster-wide
+	// leadership. It requires a scheduler configured with an Elector.
+	Singleton bool `yaml:"singleton" json:"singleton" toml:"singleton"`
+
+	// Lock claims each activation through the scheduler's Locker so that it
+	// runs on only one replica. It requires a schedule.
+	Lock bool `yaml:"lock" json:"lock" toml:"lock"`
+
+	schedule Schedule
+}
+
+// clone returns a copy of j that shares no policies with it.
+func (j *Job) clone() *Job {
+	c := *j
+	if j.Retry != nil {
+		retry := *j.Retry
+		c.Retry = &retry
+	}
+	if j.Restart != nil {
+		restart := *j.Restart
+		c.Restart = &restart
+	}
+	return &c
+}
+
+// Option customizes a Job at registration time.
+type Option func(*Job)
+
+// WithSchedule runs the job on a cron expression or descriptor such as
+// "*/5 * * * *", "0 0 3 * * *", "@daily" or "@every 30s".
+func WithSchedule(spec string) Option {
+	return func(j *Job) {
+		j.Schedule = spec
+	}
+}
+
+// WithTimezone evaluates the job schedule in the given IANA time zone.
+func WithTimezone(name string) Option {
+	return func(j *Job) {
+		j.Timezone = name
+	}
+}
+
+// WithOverlap sets the policy applied when an activation fires while the
+// previous run is still in progress.
+func WithOverlap(p OverlapPolicy) Option {
+	return func(j *Job) {
+		j.Overlap = p
+	}
+}
+
+// WithTimeout bounds each attempt of the job to d.
+func WithTimeout(d time.Duration) Option {
+	return func(j *Job) {
+		j.Timeout = d
+	}
+}
+
+// WithRetry retries failed runs according to p.
+func WithRetry(p RetryPolicy) Option {
+	return func(j *Job) {
+		j.Retry = &p
+	}
+}
+
+// WithOnFailure sets how the scheduler reacts when the job fails.
+func WithOnFailure(p FailurePolicy) Option {
+	return func(j *Job) {
+		j.OnFailure = p
+	}
+}
+
+// WithRestart restarts the job with backoff after a failure. It implies
+// FailureRestart.
+func WithRestart(p RestartPolicy) Option {
+	return func(j *Job) {
+		j.OnFailure = FailureRestart
+		j.Restart = &p
+	}
+}
+
+// WithSingleton runs the job on only one replica across the cluster, elected
+// through the scheduler's leader elector.
+func WithSingleton() Option {
+	return func(j *Job) {
+		j.Singleton = true
+	}
+}
+
+// WithLock runs each activation of the job on only one replica, claimed
+// through the scheduler's Redis locker.
+func WithLock() Option {
+	return func(j *Job) {
+		j.Lock = true
+	}
 }
 
 func (j *Job) Validate() error {
@@ -43,10 +165,35 @@ func (j *Job) Validate() error {
 	if j.Func == nil {
 		return errors.New("job function is required")
 	}
-	return nil
+	if err := j.Overlap.Validate(); err != nil {
+		return err
+	}
+	if j.Timeout < 0 {
+		return errors.New("job timeout cannot be negative")
+	}
+	if err := j.Retry.Validate(); err != nil {
+		return err
+	}
+	if err := j.OnFailure.Validate(); err != nil {
+		return err
+	}
+	return j.Restart.Validate()
+}
+
+// Recurring reports whether the job has a schedule.
+func (j *Job) Recurring() bool {
+	return j.schedule != nil
 }
 
-func (j *Job) Run(ctx *Context) error {
+// Run calls the job function once. A panic in the job function is recovered
+// and returned as an error wrapping ErrPanicked.
+func (j *Job) Run(ctx *Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ctx.Logger().Error("panic recovered in job", "name", j.Name, "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("%w: %v", ErrPanicked, r)
+		}
+	}()
 	ctx.Logger().Info("running job", "name", j.Name)
 	return j.Func(ctx)
 }
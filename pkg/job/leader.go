@@ -0,0 +1,136 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+	defaultLeaderPrefix = "/octopus/jobs/leader/"
+	defaultLeaderTTL    = 15 * time.Second
+	leaderRetryInterval = time.Second
+	leaderResignTimeout = 3 * time.Second
+)
+
+// Elector grants cluster-wide leadership for singleton jobs.
+type Elector interface {
+	// Lead blocks until leadership for name is acquired or ctx is done.
+	// The returned context is cancelled when leadership is lost, and release
+	// gives leadership up so another replica can take over.
+	Lead(ctx context.Context, name string) (leaderCtx context.Context, release func(), err error)
+}
+
+// EtcdElector elects job leaders through etcd leases and elections.
+type EtcdElector struct {
+	log    *xlog.Logger
+	client *clientv3.Client
+	prefix string
+	ttl    int
+	id     string
+}
+
+// NewEtcdElector creates an etcd-backed elector. Election keys are created
+// under prefix, and leadership is held by a lease of the given TTL.
+func NewEtcdElector(log *xlog.Logger, client *clientv3.Client, prefix string, ttl time.Duration) *EtcdElector {
+	if prefix == "" {
+		prefix = defaultLeaderPrefix
+	}
+	if ttl <= 0 {
+		ttl = defaultLeaderTTL
+	}
+	host, _ := os.Hostname()
+	return &EtcdElector{
+		log:    log,
+		client: client,
+		prefix: prefix,
+		ttl:    max(int(ttl/time.Second), 1),
+		id:     fmt.Sprintf("%s/%d", host, os.Getpid()),
+	}
+}
+
+// Lead campaigns for leadership of name. It returns when ctx is done, even
+// while etcd is unreachable.
+func (e *EtcdElector) Lead(ctx context.Context, name string) (context.Context, func(), error) {
+	session, err := concurrency.NewSession(e.client, concurrency.WithTTL(e.ttl), concurrency.WithContext(ctx))
+	if err != nil {
+		return nil, nil, fmt.Errorf("job: create etcd session: %w", err)
+	}
+	election := concurrency.NewElection(session, path.Join(e.prefix, name))
+	if err := election.Campaign(ctx, e.id); err != nil {
+		_ = session.Close()
+		return nil, nil, err
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-session.Done():
+			cancel()
+		case <-leaderCtx.Done():
+		}
+	}()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			cancel()
+			resignCtx, resignCancel := context.WithTimeout(context.Background(), leaderResignTimeout)
+			defer resignCancel()
+			if err := election.Resign(resignCtx); err != nil {
+				e.log.Warn("job: resign leadership failed", "name", name, "error", err)
+			}
+			_ = session.Close()
+		})
+	}
+	return leaderCtx, release, nil
+}
+
+// runSingleton runs job only while this replica holds its leadership, and
+// campaigns again when leadership is lost.
+func (s *Scheduler) runSingleton(ctx context.Context, job *Job) error {
+	for {
+		leaderCtx, release, err := s.elector.Lead(ctx, job.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			s.log.Warn("job leader election failed, retrying", "name", job.Name, "error", err)
+			if !sleepContext(ctx, leaderRetryInterval) {
+				return nil
+			}
+			continue
+		}
+		s.log.Info("job leadership acquired", "name", job.Name)
+		st := s.states[job.Name]
+		st.setLeader(leaderCtx)
+
+		err = s.runJob(leaderCtx, job)
+		completed := !job.Recurring() && leaderCtx.Err() == nil
+		if err == nil && completed {
+			// Keep leadership after a one-shot run so other replicas do not
+			// run the job again while this one is alive.
+			<-leaderCtx.Done()
+		}
+		st.setLeader(nil)
+		release()
+
+		switch {
+		case err != nil:
+			return err
+		case ctx.Err() != nil:
+			s.log.Info("job leadership released", "name", job.Name)
+			return nil
+		case completed:
+			return nil
+		}
+		s.log.Warn("job leadership lost", "name", job.Name)
+	}
+}
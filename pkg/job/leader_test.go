@@ -0,0 +1,187 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/store"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeElector grants leadership on demand and lets tests revoke it.
+type fakeElector struct {
+	grant chan struct{}
+
+	mu       sync.Mutex
+	revoke   context.CancelFunc
+	released atomic.Int32
+}
+
+func newFakeElector() *fakeElector {
+	return &fakeElector{grant: make(chan struct{})}
+}
+
+func (e *fakeElector) Lead(ctx context.Context, name string) (context.Context, func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-e.grant:
+	}
+	leaderCtx, cancel := context.WithCancel(ctx)
+	e.mu.Lock()
+	e.revoke = cancel
+	e.mu.Unlock()
+	return leaderCtx, func() {
+		cancel()
+		e.released.Add(1)
+	}, nil
+}
+
+func (e *fakeElector) lose() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.revoke()
+}
+
+func TestSchedulerSingletonRunsOnlyWhileLeader(t *testing.T) {
+	log := xlog.MustNew(nil)
+	t.Cleanup(func() { _ = log.Close() })
+	elector := newFakeElector()
+	s := NewScheduler(log, store.New(), WithElector(elector))
+
+	var runs atomic.Int32
+	if err := s.Add("sweep", func(*Context) error {
+		runs.Add(1)
+		return nil
+	}, WithSchedule("@every 1s"), WithSingleton()); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+
+	time.Sleep(1500 * time.Millisecond)
+	if got := runs.Load(); got != 0 {
+		t.Fatalf("runs = %d before leadership, want 0", got)
+	}
+
+	elector.grant <- struct{}{}
+	deadline := time.Now().Add(3 * time.Second)
+	for runs.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if runs.Load() < 1 {
+		t.Fatal("job did not run after leadership was acquired")
+	}
+
+	elector.lose()
+	deadline = time.Now().Add(2 * time.Second)
+	for elector.released.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := elector.released.Load(); got != 1 {
+		t.Fatalf("released = %d after leadership loss, want 1", got)
+	}
+	after := runs.Load()
+	time.Sleep(1500 * time.Millisecond)
+	if got := runs.Load(); got != after {
+		t.Fatalf("runs = %d after leadership loss, want %d", got, after)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.Stop(stopCtx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestSchedulerTriggerSingletonOnlyOnLeader(t *testing.T) {
+	log := xlog.MustNew(nil)
+	t.Cleanup(func() { _ = log.Close() })
+	elector := newFakeElector()
+	s := NewScheduler(log, store.New(), WithElector(elector))
+	var runs atomic.Int32
+	fn := func(*Context) error {
+		runs.Add(1)
+		return nil
+	}
+	if err := s.Add("once", fn, WithSingleton()); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("daily", fn, WithSingleton(), WithSchedule("@daily")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+	waitFor(t, func() bool { return s.Trigger("daily") != ErrSchedulerNotRunning })
+
+	for _, name := range []string{"once", "daily"} {
+		if err := s.Trigger(name); !errors.Is(err, ErrNotLeader) {
+			t.Fatalf("Trigger(%s) on a follower error = %v, want %v", name, err, ErrNotLeader)
+		}
+	}
+	if got := runs.Load(); got != 0 {
+		t.Fatalf("runs = %d on a follower, want 0", got)
+	}
+
+	// Lead both jobs; the one-shot job runs once on acquiring leadership.
+	elector.grant <- struct{}{}
+	elector.grant <- struct{}{}
+	waitFor(t, func() bool {
+		state, _ := s.State("daily")
+		return runs.Load() == 1 && !state.NextFire.IsZero()
+	})
+	for _, name := range []string{"once", "daily"} {
+		if err := s.Trigger(name); err != nil {
+			t.Fatalf("Trigger(%s) on the leader error = %v", name, err)
+		}
+	}
+	waitFor(t, func() bool { return runs.Load() == 3 })
+}
+
+func TestSchedulerSingletonRequiresElector(t *testing.T) {
+	s := newTestScheduler(t, &SchedulerConfig{
+		Jobs: map[string]JobConfig{"sweep": {Singleton: true}},
+	})
+	if err := s.Add("sweep", func(*Context) error { return nil }); err == nil {
+		t.Fatal("expected singleton without elector error")
+	}
+}
+
+func TestEtcdElectorLeadHonorsContext(t *testing.T) {
+	// Nothing listens on the endpoint, so the lease grant cannot complete.
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{"127.0.0.1:1"}, DialTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("clientv3.New() error = %v", err)
+	}
+	defer client.Close()
+	log := xlog.MustNew(nil)
+	t.Cleanup(func() { _ = log.Close() })
+	elector := NewEtcdElector(log, client, "", 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := elector.Lead(ctx, "sweep")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Lead() with a cancelled context succeeded")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Lead() ignored the cancelled context")
+	}
+}
@@ -0,0 +1,36 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/lock"
+)
+
+const defaultLockTTL = time.Minute
+
+// claim takes the lock of the activation of job at tick so that only one
+// replica runs it. The lock is not released after the run: it expires after
+// the lock TTL, so replicas whose clocks lag behind cannot claim the same
+// activation once the run has finished.
+func (s *Scheduler) claim(ctx context.Context, job *Job, tick time.Time) bool {
+	// Interval schedules are not aligned across replicas started at different
+	// times, so their activations are keyed by interval slot instead.
+	if is, ok := job.schedule.(*intervalSchedule); ok {
+		tick = tick.Truncate(is.every)
+	}
+	key := fmt.Sprintf("%s:%d", job.Name, tick.Unix())
+
+	_, err := s.locker.TryAcquire(ctx, key, s.lockTTL)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, lock.ErrNotAcquired):
+		s.log.Debug("job activation claimed by another replica", "name", job.Name, "tick", tick)
+	case ctx.Err() == nil:
+		s.log.Warn("job activation lock failed, activation skipped", "name", job.Name, "tick", tick, "error", err)
+	}
+	return false
+}
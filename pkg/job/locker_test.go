@@ -0,0 +1,78 @@
+package job
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/lock"
+	"github.com/HorseArcher567/octopus/pkg/redis"
+	"github.com/HorseArcher567/octopus/pkg/store"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestSchedulerLockRunsEachActivationOnce(t *testing.T) {
+	mr := miniredis.RunT(t)
+	log := xlog.MustNew(nil)
+	t.Cleanup(func() { _ = log.Close() })
+
+	var runs atomic.Int32
+	replicas := make([]*Scheduler, 3)
+	for i := range replicas {
+		client, err := redis.New(&redis.Config{Addr: mr.Addr()})
+		if err != nil {
+			t.Fatalf("redis.New() error = %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+
+		s := NewScheduler(log, store.New(), WithLocker(lock.New(client)))
+		if err := s.Add("sweep", func(*Context) error {
+			runs.Add(1)
+			return nil
+		}, WithSchedule("* * * * * *"), WithLock()); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		replicas[i] = s
+	}
+
+	done := make(chan error, len(replicas))
+	for _, s := range replicas {
+		go func() { done <- s.Run(context.Background()) }()
+	}
+	time.Sleep(2500 * time.Millisecond)
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, s := range replicas {
+		if err := s.Stop(stopCtx); err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+	}
+	for range replicas {
+		if err := <-done; err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	claimed := len(mr.Keys())
+	if claimed == 0 {
+		t.Fatal("no activation was claimed")
+	}
+	if got := runs.Load(); int(got) != claimed {
+		t.Fatalf("runs = %d, want one per claimed activation (%d)", got, claimed)
+	}
+}
+
+func TestSchedulerLockRequiresLockerAndSchedule(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	if err := s.Add("sweep", func(*Context) error { return nil }, WithSchedule("@every 1s"), WithLock()); err == nil {
+		t.Fatal("expected lock without locker error")
+	}
+
+	s.locker = lock.New(nil)
+	if err := s.Add("once", func(*Context) error { return nil }, WithLock()); err == nil {
+		t.Fatal("expected lock without schedule error")
+	}
+}
@@ -0,0 +1,209 @@
+package job
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// OverlapPolicy controls what happens when a scheduled activation fires while
+// the previous run of the same job is still in progress.
+type OverlapPolicy string
+
+const (
+	// OverlapSkip drops the new activation. This is the default.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue runs the new activation after the current run finishes.
+	OverlapQueue OverlapPolicy = "queue"
+	// OverlapReplace cancels the current run and starts the new activation.
+	OverlapReplace OverlapPolicy = "replace"
+)
+
+// Validate reports whether p is a known overlap policy. Empty means OverlapSkip.
+func (p OverlapPolicy) Validate() error {
+	switch p {
+	case "", OverlapSkip, OverlapQueue, OverlapReplace:
+		return nil
+	default:
+		return fmt.Errorf("job: invalid overlap policy %q", string(p))
+	}
+}
+
+// FailurePolicy controls how the scheduler reacts when a run fails after all
+// retries, or panics.
+type FailurePolicy string
+
+const (
+	// FailureContinue logs the failure and keeps the scheduler running.
+	// Scheduled jobs fire again on their next activation. This is the default.
+	FailureContinue FailurePolicy = "continue"
+	// FailureFatal stops the scheduler and returns the error from Run, which
+	// shuts down the app.
+	FailureFatal FailurePolicy = "fatal"
+	// FailureRestart runs the job again after a backoff delay.
+	FailureRestart FailurePolicy = "restart"
+)
+
+// Validate reports whether p is a known failure policy. Empty means FailureContinue.
+func (p FailurePolicy) Validate() error {
+	switch p {
+	case "", FailureContinue, FailureFatal, FailureRestart:
+		return nil
+	default:
+		return fmt.Errorf("job: invalid failure policy %q", string(p))
+	}
+}
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMultiplier     = 2.0
+	defaultJitter         = 0.2
+)
+
+// Backoff describes an exponential backoff with jitter.
+type Backoff struct {
+	// InitialBackoff is the first delay (default: 1s).
+	InitialBackoff time.Duration `yaml:"initialBackoff" json:"initialBackoff" toml:"initialBackoff"`
+
+	// MaxBackoff caps the delay (default: 30s).
+	MaxBackoff time.Duration `yaml:"maxBackoff" json:"maxBackoff" toml:"maxBackoff"`
+
+	// Multiplier is the factor applied to the delay after each step (default: 2).
+	Multiplier float64 `yaml:"multiplier" json:"multiplier" toml:"multiplier"`
+
+	// Jitter randomizes each delay by up to +/- this fraction (default: 0.2).
+	// Set a negative value to disable jitter.
+	Jitter float64 `yaml:"jitter" json:"jitter" toml:"jitter"`
+}
+
+// Normalize sets default values for unset fields.
+func (b *Backoff) Normalize() {
+	if b == nil {
+		return
+	}
+	if b.InitialBackoff <= 0 {
+		b.InitialBackoff = defaultInitialBackoff
+	}
+	if b.MaxBackoff <= 0 {
+		b.MaxBackoff = defaultMaxBackoff
+	}
+	if b.MaxBackoff < b.InitialBackoff {
+		b.MaxBackoff = b.InitialBackoff
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = defaultMultiplier
+	}
+	if b.Jitter == 0 {
+		b.Jitter = defaultJitter
+	}
+	if b.Jitter < 0 {
+		b.Jitter = 0
+	}
+	if b.Jitter > 1 {
+		b.Jitter = 1
+	}
+}
+
+// Validate validates the backoff.
+func (b *Backoff) Validate() error {
+	if b.InitialBackoff < 0 || b.MaxBackoff < 0 {
+		return fmt.Errorf("job: backoff cannot be negative")
+	}
+	return nil
+}
+
+// Delay returns the delay for the given step (1-based).
+func (b *Backoff) Delay(step int) time.Duration {
+	if step < 1 {
+		step = 1
+	}
+	d := float64(b.InitialBackoff) * math.Pow(b.Multiplier, float64(step-1))
+	if d > float64(b.MaxBackoff) {
+		d = float64(b.MaxBackoff)
+	}
+	if b.Jitter > 0 {
+		d *= 1 + b.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// RetryPolicy retries a failed run with exponential backoff and jitter.
+//
+// Example:
+//
+//	retry:
+//	  maxAttempts: 5
+//	  initialBackoff: 1s
+//	  maxBackoff: 30s
+//	  multiplier: 2
+//	  jitter: 0.2
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int `yaml:"maxAttempts" json:"maxAttempts" toml:"maxAttempts"`
+
+	Backoff
+}
+
+// Normalize sets default values for unset fields.
+func (p *RetryPolicy) Normalize() {
+	if p == nil {
+		return
+	}
+	p.Backoff.Normalize()
+}
+
+// Validate validates the retry policy.
+func (p *RetryPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("job: retry maxAttempts cannot be negative")
+	}
+	return p.Backoff.Validate()
+}
+
+// attempts returns the total number of attempts allowed by p.
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// RestartPolicy configures FailureRestart.
+//
+// Example:
+//
+//	restart:
+//	  maxRestarts: 10
+//	  initialBackoff: 1s
+//	  maxBackoff: 1m
+type RestartPolicy struct {
+	// MaxRestarts caps consecutive restarts. Zero means unlimited.
+	MaxRestarts int `yaml:"maxRestarts" json:"maxRestarts" toml:"maxRestarts"`
+
+	Backoff
+}
+
+// Normalize sets default values for unset fields.
+func (p *RestartPolicy) Normalize() {
+	if p == nil {
+		return
+	}
+	p.Backoff.Normalize()
+}
+
+// Validate validates the restart policy.
+func (p *RestartPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxRestarts < 0 {
+		return fmt.Errorf("job: restart maxRestarts cannot be negative")
+	}
+	return p.Backoff.Validate()
+}
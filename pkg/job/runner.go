@@ -0,0 +1,171 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// runner drives the activations of one scheduled job and applies its
+// overlap policy.
+type runner struct {
+	s   *Scheduler
+	job *Job
+
+	errs chan error
+	wg   sync.WaitGroup
+
+	mu      sync.Mutex
+	running bool
+	pending int
+	cancel  context.CancelFunc
+}
+
+func newRunner(s *Scheduler, job *Job) *runner {
+	return &runner{s: s, job: job, errs: make(chan error, 1)}
+}
+
+// fire handles one activation of the schedule.
+func (r *runner) fire(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.running {
+		r.start(ctx)
+		return
+	}
+	switch r.job.Overlap {
+	case OverlapQueue:
+		r.pending++
+		r.s.log.Info("job still running, activation queued", "name", r.job.Name, "pending", r.pending)
+	case OverlapReplace:
+		r.pending = 1
+		r.s.log.Info("job still running, replacing current run", "name", r.job.Name)
+		r.cancel()
+	default:
+		r.s.log.Info("job still running, activation skipped", "name", r.job.Name)
+	}
+}
+
+// start launches one run. The caller must hold r.mu.
+func (r *runner) start(ctx context.Context) {
+	runCtx, cancel := context.WithCancel(ctx)
+	r.running = true
+	r.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		err := r.s.run(runCtx, r.job)
+		replaced := runCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.running = false
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !replaced {
+			select {
+			case r.errs <- err:
+			default:
+			}
+			return
+		}
+		if r.pending > 0 {
+			r.pending--
+			r.start(ctx)
+		}
+	}()
+}
+
+// wait blocks until all runs started by r have returned.
+func (r *runner) wait() {
+	r.wg.Wait()
+}
+
+// run executes job and applies its failure policy. It returns an error only
+// when the failure must stop the scheduler.
+func (s *Scheduler) run(ctx context.Context, job *Job) error {
+	for restarts := 0; ; restarts++ {
+		err := s.execute(ctx, job)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+
+		switch job.OnFailure {
+		case FailureFatal:
+			s.log.Error("job failed, stopping scheduler", "name", job.Name, "error", err)
+			return fmt.Errorf("job %q: %w", job.Name, err)
+		case FailureRestart:
+			if job.Restart.MaxRestarts > 0 && restarts >= job.Restart.MaxRestarts {
+				s.log.Error("job failed, restart limit reached", "name", job.Name, "restarts", restarts, "error", err)
+				return nil
+			}
+			delay := job.Restart.Delay(restarts + 1)
+			s.log.Error("job failed, restarting", "name", job.Name, "restart", restarts+1, "backoff", delay, "error", err)
+			if !sleepContext(ctx, delay) {
+				return nil
+			}
+		default:
+			s.log.Error("job failed", "name", job.Name, "error", err)
+			return nil
+		}
+	}
+}
+
+// execute runs job once, applying its timeout and retry policy, and records
+// the run in the job state.
+func (s *Scheduler) execute(ctx context.Context, job *Job) (err error) {
+	st := s.states[job.Name]
+	st.begin()
+	start := time.Now()
+	defer func() {
+		st.finish(err)
+		if s.observer != nil {
+			s.observer.ObserveRun(job.Name, time.Since(start), err)
+		}
+	}()
+
+	attempts := job.Retry.attempts()
+	for attempt := 1; ; attempt++ {
+		err := s.attempt(ctx, job)
+		if err == nil || ctx.Err() != nil || attempt >= attempts {
+			return err
+		}
+		delay := job.Retry.Delay(attempt)
+		s.log.Warn("job attempt failed, retrying",
+			"name", job.Name,
+			"attempt", attempt,
+			"maxAttempts", attempts,
+			"backoff", delay,
+			"error", err,
+		)
+		if !sleepContext(ctx, delay) {
+			return err
+		}
+	}
+}
+
+// sleepContext waits for d and reports false if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// attempt runs job once, bounded by its timeout.
+func (s *Scheduler) attempt(ctx context.Context, job *Job) error {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	return job.Run(NewContext(ctx, s.log, s.store, job.Name))
+}
@@ -0,0 +1,165 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 5, Backoff: Backoff{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: -1}}
+	p.Normalize()
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, w := range want {
+		if got := p.Delay(i + 1); got != w {
+			t.Fatalf("Delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	p = &RetryPolicy{Backoff: Backoff{InitialBackoff: time.Second, Jitter: 0.5}}
+	p.Normalize()
+	for i := 0; i < 100; i++ {
+		if got := p.Delay(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("Delay(1) = %v, want within jitter bounds", got)
+		}
+	}
+}
+
+func TestSchedulerExecuteRetriesUntilSuccess(t *testing.T) {
+	s := newTestScheduler(t, nil)
+
+	var calls atomic.Int32
+	err := s.Add("flaky", func(*Context) error {
+		if calls.Add(1) < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: Backoff{InitialBackoff: time.Millisecond, Jitter: -1}}))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := s.execute(context.Background(), s.jobs[0]); err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("calls = %d, want 3", got)
+	}
+}
+
+func TestSchedulerExecuteReturnsLastErrorAfterRetries(t *testing.T) {
+	s := newTestScheduler(t, nil)
+
+	var calls atomic.Int32
+	boom := errors.New("boom")
+	if err := s.Add("broken", func(*Context) error {
+		calls.Add(1)
+		return boom
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: Backoff{InitialBackoff: time.Millisecond}})); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := s.execute(context.Background(), s.jobs[0]); !errors.Is(err, boom) {
+		t.Fatalf("execute() error = %v, want %v", err, boom)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("calls = %d, want 2", got)
+	}
+}
+
+func TestSchedulerExecuteAppliesTimeout(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	if err := s.Add("slow", func(c *Context) error {
+		<-c.Context().Done()
+		return c.Context().Err()
+	}, WithTimeout(20*time.Millisecond)); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	start := time.Now()
+	err := s.execute(context.Background(), s.jobs[0])
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("execute() error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("execute() took %v, want timeout to apply", elapsed)
+	}
+}
+
+func TestRunnerOverlapPolicies(t *testing.T) {
+	tests := []struct {
+		policy    OverlapPolicy
+		wantRuns  int32
+		wantCanc  int32
+		fireCount int
+	}{
+		{policy: OverlapSkip, wantRuns: 1, wantCanc: 0, fireCount: 3},
+		{policy: OverlapQueue, wantRuns: 3, wantCanc: 0, fireCount: 3},
+		{policy: OverlapReplace, wantRuns: 2, wantCanc: 1, fireCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			s := newTestScheduler(t, nil)
+
+			var runs, cancelled atomic.Int32
+			release := make(chan struct{})
+			if err := s.Add("overlap", func(c *Context) error {
+				runs.Add(1)
+				select {
+				case <-release:
+					return nil
+				case <-c.Context().Done():
+					cancelled.Add(1)
+					return c.Context().Err()
+				}
+			}, WithOverlap(tt.policy)); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r := newRunner(s, s.jobs[0])
+			for i := 0; i < tt.fireCount; i++ {
+				r.fire(ctx)
+				waitFor(t, func() bool { return runs.Load() >= 1 })
+			}
+			if tt.policy == OverlapReplace {
+				waitFor(t, func() bool { return runs.Load() == 2 })
+			}
+			close(release)
+			waitFor(t, func() bool {
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				return !r.running && r.pending == 0
+			})
+			r.wait()
+
+			if got := runs.Load(); got != tt.wantRuns {
+				t.Fatalf("runs = %d, want %d", got, tt.wantRuns)
+			}
+			if got := cancelled.Load(); got != tt.wantCanc {
+				t.Fatalf("cancelled = %d, want %d", got, tt.wantCanc)
+			}
+			select {
+			case err := <-r.errs:
+				t.Fatalf("unexpected run error: %v", err)
+			default:
+			}
+		})
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,350 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes the activation times of a recurring job.
+type Schedule interface {
+	// Next returns the first activation time strictly after t.
+	// A zero time means the schedule will never fire again.
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule parses a schedule spec evaluated in loc.
+//
+// Supported forms:
+//   - standard 5-field cron expressions: "minute hour dom month dow"
+//   - 6-field cron expressions with a leading seconds field
+//   - descriptors: @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly
+//   - fixed intervals: "@every 30s"
+//
+// A spec may start with "CRON_TZ=<zone> " or "TZ=<zone> " to override loc.
+// If loc is nil, time.Local is used.
+func ParseSchedule(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("job: empty schedule spec")
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.IndexAny(spec, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("job: schedule %q: missing expression after timezone", spec)
+		}
+		zone := spec[strings.Index(spec, "=")+1 : i]
+		l, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("job: schedule %q: invalid timezone %q: %w", spec, zone, err)
+		}
+		loc = l
+		spec = strings.TrimSpace(spec[i:])
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		return parseDescriptor(spec, loc)
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("job: schedule %q: expected 5 or 6 fields, got %d", spec, len(fields))
+	}
+
+	s := &cronSchedule{loc: loc}
+	var err error
+	if s.second, err = parseField(fields[0], secondBounds); err != nil {
+		return nil, fmt.Errorf("job: schedule %q: second: %w", spec, err)
+	}
+	if s.minute, err = parseField(fields[1], minuteBounds); err != nil {
+		return nil, fmt.Errorf("job: schedule %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseField(fields[2], hourBounds); err != nil {
+		return nil, fmt.Errorf("job: schedule %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseField(fields[3], domBounds); err != nil {
+		return nil, fmt.Errorf("job: schedule %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseField(fields[4], monthBounds); err != nil {
+		return nil, fmt.Errorf("job: schedule %q: month: %w", spec, err)
+	}
+	if s.dow, err = parseField(fields[5], dowBounds); err != nil {
+		return nil, fmt.Errorf("job: schedule %q: day of week: %w", spec, err)
+	}
+	// Cron treats 7 as an alias of Sunday.
+	if s.dow&(1<<7) != 0 {
+		s.dow = (s.dow | 1) &^ (1 << 7)
+	}
+	return s, nil
+}
+
+// MustParseSchedule is like ParseSchedule but panics on error.
+func MustParseSchedule(spec string, loc *time.Location) Schedule {
+	s, err := ParseSchedule(spec, loc)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func parseDescriptor(spec string, loc *time.Location) (Schedule, error) {
+	if rest, ok := strings.CutPrefix(spec, "@every"); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("job: schedule %q: %w", spec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("job: schedule %q: interval must be at least 1s", spec)
+		}
+		return &intervalSchedule{every: d.Truncate(time.Second)}, nil
+	}
+
+	var expr string
+	switch spec {
+	case "@yearly", "@annually":
+		expr = "0 0 0 1 1 *"
+	case "@monthly":
+		expr = "0 0 0 1 * *"
+	case "@weekly":
+		expr = "0 0 0 * * 0"
+	case "@daily", "@midnight":
+		expr = "0 0 0 * * *"
+	case "@hourly":
+		expr = "0 0 * * * *"
+	default:
+		return nil, fmt.Errorf("job: schedule %q: unknown descriptor", spec)
+	}
+	return ParseSchedule(expr, loc)
+}
+
+// intervalSchedule fires at a fixed interval measured from the previous activation.
+type intervalSchedule struct {
+	every time.Duration
+}
+
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.every - time.Duration(t.Nanosecond()))
+}
+
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondBounds = bounds{min: 0, max: 59}
+	minuteBounds = bounds{min: 0, max: 59}
+	hourBounds   = bounds{min: 0, max: 23}
+	domBounds    = bounds{min: 1, max: 31}
+	monthBounds  = bounds{min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// starBit marks a field written as "*" or "?", which matters for the
+// day-of-month / day-of-week union rule.
+const starBit = 1 << 63
+
+// parseField parses one comma-separated cron field into a bit set.
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		bitsForPart, err := parseRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		set |= bitsForPart
+	}
+	return set, nil
+}
+
+func parseRange(expr string, b bounds) (uint64, error) {
+	rangeAndStep := strings.Split(expr, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, fmt.Errorf("invalid expression %q", expr)
+	}
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	if len(lowAndHigh) > 2 {
+		return 0, fmt.Errorf("invalid expression %q", expr)
+	}
+
+	var (
+		start, end uint
+		extra      uint64
+		err        error
+	)
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		if len(lowAndHigh) > 1 {
+			return 0, fmt.Errorf("invalid expression %q", expr)
+		}
+		start, end = b.min, b.max
+		extra = starBit
+	} else {
+		if start, err = parseValue(lowAndHigh[0], b); err != nil {
+			return 0, err
+		}
+		end = start
+		if len(lowAndHigh) == 2 {
+			if end, err = parseValue(lowAndHigh[1], b); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	step := uint(1)
+	if len(rangeAndStep) == 2 {
+		n, err := strconv.ParseUint(rangeAndStep[1], 10, 32)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("invalid step in %q", expr)
+		}
+		step = uint(n)
+		// "N/step" means "N-max/step".
+		if len(lowAndHigh) == 1 && extra == 0 {
+			end = b.max
+		}
+		if step > 1 {
+			extra = 0
+		}
+	}
+
+	if start < b.min || end > b.max {
+		return 0, fmt.Errorf("%q out of range [%d, %d]", expr, b.min, b.max)
+	}
+	if start > end {
+		return 0, fmt.Errorf("invalid range %q: start is after end", expr)
+	}
+
+	var set uint64
+	for i := start; i <= end; i += step {
+		set |= 1 << i
+	}
+	return set | extra, nil
+}
+
+func parseValue(s string, b bounds) (uint, error) {
+	if b.names != nil {
+		if v, ok := b.names[strings.ToLower(s)]; ok {
+			return v, nil
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return uint(n), nil
+}
+
+// cronSchedule is a parsed cron expression. Each field is a bit set of the
+// permitted values, with starBit recording a wildcard field.
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	loc                                   *time.Location
+}
+
+// searchLimit bounds the search for the next activation so that expressions
+// that can never match (e.g. "0 0 30 2 *") terminate.
+const searchLimit = 5
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+
+	// Start at the next whole second.
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	added := false
+	yearLimit := t.Year() + searchLimit
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for 1<<uint(t.Month())&s.month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// Guard against DST transitions that move midnight.
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(-time.Duration(t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Second())&s.second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t.In(origLoc)
+}
+
+// dayMatches applies the cron rule that, when both day-of-month and
+// day-of-week are restricted, a day matching either field is accepted.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom != 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow != 0
+	if s.dom&starBit != 0 || s.dow&starBit != 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,71 @@
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	utc := time.UTC
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("load location: %v", err)
+	}
+
+	tests := []struct {
+		spec string
+		loc  *time.Location
+		from time.Time
+		want time.Time
+	}{
+		{"*/5 * * * *", utc, time.Date(2026, 1, 1, 10, 2, 30, 0, utc), time.Date(2026, 1, 1, 10, 5, 0, 0, utc)},
+		{"30 * * * * *", utc, time.Date(2026, 1, 1, 10, 2, 30, 0, utc), time.Date(2026, 1, 1, 10, 3, 30, 0, utc)},
+		{"0 3 * * *", utc, time.Date(2026, 1, 1, 4, 0, 0, 0, utc), time.Date(2026, 1, 2, 3, 0, 0, 0, utc)},
+		{"0 9 * * MON-FRI", utc, time.Date(2026, 10, 16, 10, 0, 0, 0, utc), time.Date(2026, 10, 19, 9, 0, 0, 0, utc)},
+		{"0 0 1 JAN *", utc, time.Date(2026, 3, 1, 0, 0, 0, 0, utc), time.Date(2027, 1, 1, 0, 0, 0, 0, utc)},
+		{"0 0 * * 7", utc, time.Date(2026, 10, 16, 0, 0, 0, 0, utc), time.Date(2026, 10, 18, 0, 0, 0, 0, utc)},
+		{"@daily", utc, time.Date(2026, 1, 1, 10, 0, 0, 0, utc), time.Date(2026, 1, 2, 0, 0, 0, 0, utc)},
+		{"@hourly", utc, time.Date(2026, 1, 1, 10, 0, 0, 0, utc), time.Date(2026, 1, 1, 11, 0, 0, 0, utc)},
+		{"@every 30s", utc, time.Date(2026, 1, 1, 10, 0, 0, 500, utc), time.Date(2026, 1, 1, 10, 0, 30, 0, utc)},
+		{"0 3 * * *", shanghai, time.Date(2026, 1, 1, 0, 0, 0, 0, utc), time.Date(2026, 1, 1, 19, 0, 0, 0, utc)},
+		{"CRON_TZ=Asia/Shanghai 0 3 * * *", utc, time.Date(2026, 1, 1, 0, 0, 0, 0, utc), time.Date(2026, 1, 1, 19, 0, 0, 0, utc)},
+		// Restricted day-of-month and day-of-week match either field.
+		{"0 0 13 * FRI", utc, time.Date(2026, 2, 1, 0, 0, 0, 0, utc), time.Date(2026, 2, 6, 0, 0, 0, 0, utc)},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec, tt.loc)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) error = %v", tt.spec, err)
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Fatalf("ParseSchedule(%q).Next(%v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* * 0 * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"@every 10ms",
+		"@sometimes",
+		"TZ=Nowhere/City * * * * *",
+	}
+	for _, spec := range specs {
+		if _, err := ParseSchedule(spec, time.UTC); err == nil {
+			t.Fatalf("ParseSchedule(%q) expected error", spec)
+		}
+	}
+}
+
+func TestParseScheduleNeverMatches(t *testing.T) {
+	s := MustParseSchedule("0 0 30 2 *", time.UTC)
+	if got := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Fatalf("Next() = %v, want zero time", got)
+	}
+}
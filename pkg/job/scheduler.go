@@ -2,43 +2,211 @@ package job
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/HorseArcher567/octopus/pkg/lock"
 	"github.com/HorseArcher567/octopus/pkg/store"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
 	"golang.org/x/sync/errgroup"
 )
 
 type Scheduler struct {
-	log    *xlog.Logger
-	jobs   []*Job
-	g      *errgroup.Group
-	ctx    context.Context
-	cancel context.CancelFunc
-	store  store.Reader
+	log      *xlog.Logger
+	config   *SchedulerConfig
+	loc      *time.Location
+	jobs     []*Job
+	store    store.Reader
+	elector  Elector
+	locker   *lock.Locker
+	lockTTL  time.Duration
+	observer Observer
+	states   map[string]*jobState
+
+	mu        sync.Mutex
+	g         *errgroup.Group
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+	runErr    error
+	triggered sync.WaitGroup
+}
+
+// SchedulerOption customizes a Scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithElector sets the elector used by singleton jobs.
+func WithElector(e Elector) SchedulerOption {
+	return func(s *Scheduler) {
+		s.elector = e
+	}
 }
 
-func NewScheduler(log *xlog.Logger, reader store.Reader) *Scheduler {
-	return &Scheduler{
-		log:   log,
-		jobs:  make([]*Job, 0),
-		store: reader,
+// WithLocker sets the Redis locker used by lock-protected jobs.
+func WithLocker(l *lock.Locker) SchedulerOption {
+	return func(s *Scheduler) {
+		s.locker = l
 	}
 }
 
-func (s *Scheduler) Add(name string, fn Func) error {
+// Observer receives the outcome of every job run, e.g. to export metrics.
+// The duration covers all retry attempts of the run.
+type Observer interface {
+	ObserveRun(name string, duration time.Duration, err error)
+}
+
+// WithObserver sets the observer notified after each job run.
+func WithObserver(o Observer) SchedulerOption {
+	return func(s *Scheduler) {
+		s.observer = o
+	}
+}
+
+// NewScheduler creates a new Scheduler without configuration. Use
+// NewSchedulerWithConfig to apply a SchedulerConfig.
+func NewScheduler(log *xlog.Logger, reader store.Reader, opts ...SchedulerOption) *Scheduler {
+	s, _ := NewSchedulerWithConfig(log, reader, nil, opts...)
+	return s
+}
+
+// NewSchedulerWithConfig creates a new Scheduler from config. A nil config is
+// treated as empty.
+func NewSchedulerWithConfig(log *xlog.Logger, reader store.Reader, config *SchedulerConfig, opts ...SchedulerOption) (*Scheduler, error) {
+	if config == nil {
+		config = &SchedulerConfig{}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	loc, err := loadLocation(config.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scheduler{
+		log:     log,
+		config:  config,
+		loc:     loc,
+		jobs:    make([]*Job, 0),
+		store:   reader,
+		lockTTL: defaultLockTTL,
+		states:  make(map[string]*jobState),
+	}
+	if config.Lock != nil && config.Lock.TTL > 0 {
+		s.lockTTL = config.Lock.TTL
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	return s, nil
+}
+
+// Add registers fn under name. Options configure the job schedule; settings
+// declared under the scheduler config for the same name take precedence.
+func (s *Scheduler) Add(name string, fn Func, opts ...Option) error {
 	job := &Job{Name: name, Func: fn}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(job)
+		}
+	}
+	return s.AddJob(job)
+}
+
+// AddJob registers a copy of a fully described job, to which the scheduler
+// config overrides are applied; job itself is not modified.
+func (s *Scheduler) AddJob(job *Job) error {
+	if job == nil {
+		return errors.New("job cannot be nil")
+	}
+	job = job.clone()
 	if err := job.Validate(); err != nil {
 		return err
 	}
+	for _, existing := range s.jobs {
+		if existing.Name == job.Name {
+			return fmt.Errorf("job %q already registered", job.Name)
+		}
+	}
+	if err := s.prepare(job); err != nil {
+		return err
+	}
 	s.jobs = append(s.jobs, job)
+	s.states[job.Name] = newJobState(job)
 	return nil
 }
 
-func (s *Scheduler) AddJob(job *Job) {
-	if job == nil {
-		return
+// prepare applies config overrides and resolves the job schedule.
+func (s *Scheduler) prepare(job *Job) error {
+	if jc, ok := s.config.Jobs[job.Name]; ok {
+		if jc.Schedule != "" {
+			job.Schedule = jc.Schedule
+		}
+		if jc.Timezone != "" {
+			job.Timezone = jc.Timezone
+		}
+		if jc.Overlap != "" {
+			job.Overlap = jc.Overlap
+		}
+		if jc.Timeout > 0 {
+			job.Timeout = jc.Timeout
+		}
+		if jc.Retry != nil {
+			retry := *jc.Retry
+			job.Retry = &retry
+		}
+		if jc.OnFailure != "" {
+			job.OnFailure = jc.OnFailure
+		}
+		if jc.Restart != nil {
+			restart := *jc.Restart
+			job.Restart = &restart
+		}
+		if jc.Singleton {
+			job.Singleton = true
+		}
+		if jc.Lock {
+			job.Lock = true
+		}
 	}
-	s.jobs = append(s.jobs, job)
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+	if job.Singleton && s.elector == nil {
+		return fmt.Errorf("job %q: singleton requires a leader elector", job.Name)
+	}
+	if job.Lock && s.locker == nil {
+		return fmt.Errorf("job %q: lock requires a locker", job.Name)
+	}
+	job.Retry.Normalize()
+	if job.OnFailure == FailureRestart && job.Restart == nil {
+		job.Restart = &RestartPolicy{}
+	}
+	job.Restart.Normalize()
+	if job.Schedule == "" {
+		if job.Lock {
+			return fmt.Errorf("job %q: lock requires a schedule", job.Name)
+		}
+		job.schedule = nil
+		return nil
+	}
+	loc := s.loc
+	if job.Timezone != "" {
+		l, err := loadLocation(job.Timezone)
+		if err != nil {
+			return fmt.Errorf("job %q: %w", job.Name, err)
+		}
+		loc = l
+	}
+	schedule, err := ParseSchedule(job.Schedule, loc)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", job.Name, err)
+	}
+	job.schedule = schedule
+	return nil
 }
 
 // HasJobs returns true if there are registered jobs.
@@ -47,6 +215,9 @@ func (s *Scheduler) HasJobs() bool {
 }
 
 // Run starts all jobs and blocks until ctx is cancelled or all jobs complete.
+// Jobs without a schedule run once; scheduled jobs fire on each activation
+// until the scheduler is stopped. Run returns an error only when a job with
+// FailureFatal fails; other failures are handled by the job's failure policy.
 // If no jobs are registered, returns nil immediately (does not block).
 func (s *Scheduler) Run(ctx context.Context) error {
 	if len(s.jobs) == 0 {
@@ -54,40 +225,143 @@ func (s *Scheduler) Run(ctx context.Context) error {
 		return nil // No jobs, return immediately, do not block
 	}
 
+	s.mu.Lock()
 	s.ctx, s.cancel = context.WithCancel(ctx)
 	s.g, _ = errgroup.WithContext(s.ctx)
+	s.done = make(chan struct{})
+	runCtx, g, done := s.ctx, s.g, s.done
+	s.mu.Unlock()
+	defer close(done)
 
 	s.log.Info("starting job scheduler", "jobCount", len(s.jobs))
+	s.warnUnknownJobs()
 
 	for _, job := range s.jobs {
 		job := job
-		s.g.Go(func() error {
-			return job.Run(NewContext(s.ctx, s.log, s.store, job.Name))
+		g.Go(func() error {
+			if job.Singleton {
+				return s.runSingleton(runCtx, job)
+			}
+			return s.runJob(runCtx, job)
 		})
 	}
 
-	return s.g.Wait()
+	err := g.Wait()
+	if err != nil {
+		s.mu.Lock()
+		s.runErr = err
+		s.mu.Unlock()
+	}
+	return err
 }
 
-// Stop stops all jobs by cancelling the context.
+// Check reports whether the scheduler is healthy. It fails once a job has
+// stopped the scheduler with a fatal failure, or once the scheduler is stopped.
+func (s *Scheduler) Check(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runErr != nil {
+		return s.runErr
+	}
+	if s.ctx != nil && s.ctx.Err() != nil {
+		return ErrSchedulerNotRunning
+	}
+	return nil
+}
+
+// runJob runs a one-shot job once, or a scheduled job until ctx is done.
+func (s *Scheduler) runJob(ctx context.Context, job *Job) error {
+	if job.Recurring() {
+		return s.runScheduled(ctx, job)
+	}
+	return s.run(ctx, job)
+}
+
+// runScheduled fires job on each activation of its schedule until ctx is done.
+// Activations that overlap a running run are handled by the job's overlap policy.
+func (s *Scheduler) runScheduled(ctx context.Context, job *Job) error {
+	r := newRunner(s, job)
+	defer r.wait()
+	st := s.states[job.Name]
+	st.setRunner(ctx, r)
+	defer st.setRunner(nil, nil)
+	for {
+		now := time.Now()
+		next := job.schedule.Next(now)
+		if next.IsZero() {
+			s.log.Warn("job schedule has no further activations", "name", job.Name, "schedule", job.Schedule)
+			return nil
+		}
+		s.log.Debug("job scheduled", "name", job.Name, "next", next)
+		st.setNext(next)
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case err := <-r.errs:
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		if st.paused() {
+			s.log.Info("job paused, activation skipped", "name", job.Name)
+			continue
+		}
+		if job.Lock && !s.claim(ctx, job, next) {
+			continue
+		}
+		r.fire(ctx)
+	}
+}
+
+// warnUnknownJobs logs config entries that do not match any registered job.
+func (s *Scheduler) warnUnknownJobs() {
+	for name := range s.config.Jobs {
+		found := false
+		for _, job := range s.jobs {
+			if job.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.log.Warn("job configured but not registered", "name", name)
+		}
+	}
+}
+
+// Stop stops all jobs by cancelling the context and waits for running jobs
+// to return or ctx to expire.
 // The caller (App) is responsible for calling this method.
-// g.Wait() is called in Run(), so we don't need to wait here.
 func (s *Scheduler) Stop(ctx context.Context) error {
 	s.log.Info("stopping job scheduler")
 
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+
 	// If Run has never been called (no jobs, or scheduler not started),
-	// s.ctx will be nil and there's nothing to wait for.
-	if s.ctx == nil {
+	// there's nothing to wait for.
+	if done == nil {
 		return nil
 	}
 
-	if s.cancel != nil {
-		s.cancel()
+	if cancel != nil {
+		cancel()
 	}
+	stopped := make(chan struct{})
+	go func() {
+		<-done
+		s.triggered.Wait()
+		close(stopped)
+	}()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-s.ctx.Done():
+	case <-stopped:
 		return nil
 	}
 }
@@ -0,0 +1,231 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/store"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+func newTestScheduler(t *testing.T, cfg *SchedulerConfig) *Scheduler {
+	t.Helper()
+	log := xlog.MustNew(nil)
+	t.Cleanup(func() { _ = log.Close() })
+	s, err := NewSchedulerWithConfig(log, store.New(), cfg)
+	if err != nil {
+		t.Fatalf("NewSchedulerWithConfig() error = %v", err)
+	}
+	return s
+}
+
+func TestSchedulerRunsScheduledJobUntilStopped(t *testing.T) {
+	s := newTestScheduler(t, nil)
+
+	var runs atomic.Int32
+	if err := s.Add("tick", func(*Context) error {
+		runs.Add(1)
+		return nil
+	}, WithSchedule("@every 1s")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runs.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if got := runs.Load(); got < 2 {
+		t.Fatalf("runs = %d, want at least 2", got)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.Stop(stopCtx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestSchedulerConfigOverridesSchedule(t *testing.T) {
+	s := newTestScheduler(t, &SchedulerConfig{
+		Timezone: "UTC",
+		Jobs: map[string]JobConfig{
+			"report": {Schedule: "0 3 * * *", Timezone: "Asia/Shanghai"},
+		},
+	})
+
+	if err := s.Add("report", func(*Context) error { return nil }, WithSchedule("@every 1s")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	job := s.jobs[0]
+	if job.Schedule != "0 3 * * *" || job.Timezone != "Asia/Shanghai" {
+		t.Fatalf("job = %+v, want config override", job)
+	}
+	next := job.schedule.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 1, 1, 19, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("next = %v, want %v", next, want)
+	}
+}
+
+func TestSchedulerAddJobKeepsCallerJob(t *testing.T) {
+	s := newTestScheduler(t, &SchedulerConfig{
+		Jobs: map[string]JobConfig{"report": {Schedule: "0 3 * * *"}},
+	})
+	retry := &RetryPolicy{MaxAttempts: 3}
+	job := &Job{Name: "report", Func: func(*Context) error { return nil }, Schedule: "@every 1s", Retry: retry}
+	if err := s.AddJob(job); err != nil {
+		t.Fatalf("AddJob() error = %v", err)
+	}
+	if job.Schedule != "@every 1s" || job.schedule != nil || *retry != (RetryPolicy{MaxAttempts: 3}) {
+		t.Fatalf("AddJob() modified the caller's job: %+v, retry %+v", job, retry)
+	}
+	if got := s.jobs[0]; got == job || got.Schedule != "0 3 * * *" || got.Retry == retry {
+		t.Fatalf("registered job = %+v, want an overridden copy", got)
+	}
+
+	if NewScheduler(xlog.MustNew(nil), store.New()) == nil {
+		t.Fatal("NewScheduler() returned nil")
+	}
+}
+
+func TestSchedulerRejectsInvalidSchedule(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	if err := s.Add("bad", func(*Context) error { return nil }, WithSchedule("not a cron")); err == nil {
+		t.Fatal("expected invalid schedule error")
+	}
+
+	if _, err := NewSchedulerWithConfig(xlog.MustNew(nil), store.New(), &SchedulerConfig{
+		Jobs: map[string]JobConfig{"bad": {Schedule: "* *"}},
+	}); err == nil {
+		t.Fatal("expected invalid config schedule error")
+	}
+}
+
+func TestSchedulerRejectsDuplicateJob(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	fn := func(*Context) error { return nil }
+	if err := s.Add("dup", fn); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("dup", fn); err == nil {
+		t.Fatal("expected duplicate job error")
+	}
+}
+
+func TestSchedulerFailureContinueKeepsRunning(t *testing.T) {
+	s := newTestScheduler(t, nil)
+
+	var healthy atomic.Int32
+	if err := s.Add("broken", func(*Context) error { return errors.New("boom") }); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("panicky", func(*Context) error { panic("boom") }); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("healthy", func(*Context) error {
+		healthy.Add(1)
+		return nil
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v, want failures isolated", err)
+	}
+	if got := healthy.Load(); got != 1 {
+		t.Fatalf("healthy runs = %d, want 1", got)
+	}
+}
+
+func TestSchedulerFailureFatalStopsRun(t *testing.T) {
+	s := newTestScheduler(t, nil)
+
+	if err := s.Add("panicky", func(*Context) error { panic("boom") }, WithOnFailure(FailureFatal)); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := s.Check(context.Background()); err != nil {
+		t.Fatalf("Check() before Run error = %v", err)
+	}
+	err := s.Run(context.Background())
+	if !errors.Is(err, ErrPanicked) {
+		t.Fatalf("Run() error = %v, want %v", err, ErrPanicked)
+	}
+	if err := s.Check(context.Background()); !errors.Is(err, ErrPanicked) {
+		t.Fatalf("Check() error = %v, want %v", err, ErrPanicked)
+	}
+}
+
+func TestSchedulerFailureRestartWithBackoff(t *testing.T) {
+	s := newTestScheduler(t, &SchedulerConfig{
+		Jobs: map[string]JobConfig{
+			"consumer": {OnFailure: FailureRestart, Restart: &RestartPolicy{
+				MaxRestarts: 2,
+				Backoff:     Backoff{InitialBackoff: time.Millisecond, Jitter: -1},
+			}},
+		},
+	})
+
+	var calls atomic.Int32
+	if err := s.Add("consumer", func(*Context) error {
+		calls.Add(1)
+		return errors.New("connection lost")
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("calls = %d, want 3 (1 run + 2 restarts)", got)
+	}
+}
+
+type recordingObserver struct {
+	mu   sync.Mutex
+	runs []string
+}
+
+func (o *recordingObserver) ObserveRun(name string, _ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.runs = append(o.runs, fmt.Sprintf("%s:%v", name, err))
+}
+
+func TestSchedulerNotifiesObserver(t *testing.T) {
+	log := xlog.MustNew(nil)
+	t.Cleanup(func() { _ = log.Close() })
+	obs := &recordingObserver{}
+	s := NewScheduler(log, store.New(), WithObserver(obs))
+	if err := s.Add("ok", func(*Context) error { return nil }); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("bad", func(*Context) error { return errors.New("boom") }); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	sort.Strings(obs.runs)
+	want := []string{"bad:boom", "ok:<nil>"}
+	if !reflect.DeepEqual(obs.runs, want) {
+		t.Fatalf("observed runs = %v, want %v", obs.runs, want)
+	}
+}
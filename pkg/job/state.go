@@ -0,0 +1,241 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned when no job is registered under the given name.
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrSchedulerNotRunning is returned when a job is triggered before Run
+	// or after Stop.
+	ErrSchedulerNotRunning = errors.New("job scheduler is not running")
+
+	// ErrNotLeader is returned when a singleton job is triggered on a
+	// replica that does not hold its leadership.
+	ErrNotLeader = errors.New("job is led by another replica")
+
+	// ErrJobRunning is returned when a one-shot job is triggered while it is
+	// still running.
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// State is a snapshot of the runtime state of one job.
+type State struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule,omitempty"`
+
+	// Paused reports whether scheduled activations are skipped.
+	Paused bool `json:"paused"`
+
+	// Running is the number of runs in progress.
+	Running int `json:"running"`
+
+	// RunCount and FailureCount count finished runs since the scheduler started.
+	RunCount     int64 `json:"runCount"`
+	FailureCount int64 `json:"failureCount"`
+
+	LastStart  time.Time `json:"lastStart,omitzero"`
+	LastFinish time.Time `json:"lastFinish,omitzero"`
+	LastError  string    `json:"lastError,omitempty"`
+
+	// NextFire is the next scheduled activation, zero if none is pending.
+	NextFire time.Time `json:"nextFire,omitzero"`
+}
+
+// jobState tracks the runtime state of one job.
+type jobState struct {
+	mu    sync.Mutex
+	state State
+
+	// ctx and r are set while the job's schedule is being driven, so that
+	// triggered runs go through the job's overlap policy.
+	ctx context.Context
+	r   *runner
+
+	// leaderCtx is set while this replica leads a singleton job, and is
+	// done when the leadership is lost.
+	leaderCtx context.Context
+
+	// triggered is set while a triggered run of a one-shot job is starting
+	// or running.
+	triggered bool
+}
+
+func newJobState(job *Job) *jobState {
+	return &jobState{state: State{Name: job.Name, Schedule: job.Schedule}}
+}
+
+func (st *jobState) snapshot() State {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.state
+}
+
+func (st *jobState) paused() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.state.Paused
+}
+
+func (st *jobState) setPaused(paused bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.state.Paused = paused
+}
+
+func (st *jobState) setNext(next time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.state.NextFire = next
+}
+
+func (st *jobState) setRunner(ctx context.Context, r *runner) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.ctx, st.r = ctx, r
+	if r == nil {
+		st.state.NextFire = time.Time{}
+	}
+}
+
+func (st *jobState) setLeader(ctx context.Context) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.leaderCtx = ctx
+}
+
+func (st *jobState) begin() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.state.Running++
+	st.state.LastStart = time.Now()
+}
+
+func (st *jobState) finish(err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.state.Running--
+	st.state.RunCount++
+	st.state.LastFinish = time.Now()
+	if err != nil {
+		st.state.FailureCount++
+		st.state.LastError = err.Error()
+	} else {
+		st.state.LastError = ""
+	}
+}
+
+// States returns a snapshot of every registered job, sorted by name.
+func (s *Scheduler) States() []State {
+	states := make([]State, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		states = append(states, s.states[job.Name].snapshot())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// State returns a snapshot of the job registered under name.
+func (s *Scheduler) State(name string) (State, error) {
+	st, ok := s.states[name]
+	if !ok {
+		return State{}, fmt.Errorf("%w: %q", ErrJobNotFound, name)
+	}
+	return st.snapshot(), nil
+}
+
+// Pause skips the scheduled activations of the job until Resume is called.
+// Runs in progress and runs started by Trigger are not affected.
+func (s *Scheduler) Pause(name string) error {
+	st, ok := s.states[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, name)
+	}
+	st.setPaused(true)
+	s.log.Info("job paused", "name", name)
+	return nil
+}
+
+// Resume re-enables the scheduled activations of a paused job.
+func (s *Scheduler) Resume(name string) error {
+	st, ok := s.states[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, name)
+	}
+	st.setPaused(false)
+	s.log.Info("job resumed", "name", name)
+	return nil
+}
+
+// Trigger runs the job on this replica now, regardless of its schedule,
+// pause state or lock. While the job's schedule is active the run is subject
+// to its overlap policy; a one-shot job is not triggered again while it runs
+// and fails with ErrJobRunning. A singleton job is triggered only on the
+// replica leading it, and fails with ErrNotLeader elsewhere.
+func (s *Scheduler) Trigger(name string) error {
+	st, ok := s.states[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil || s.ctx.Err() != nil {
+		return ErrSchedulerNotRunning
+	}
+	job, runCtx := s.jobByName(name), s.ctx
+
+	// Holding st.mu keeps runScheduled from detaching the runner and waiting
+	// on it while this run is being started.
+	st.mu.Lock()
+	if st.r != nil {
+		s.log.Info("job triggered", "name", name)
+		st.r.fire(st.ctx)
+		st.mu.Unlock()
+		return nil
+	}
+	if job.Singleton {
+		if st.leaderCtx == nil || st.leaderCtx.Err() != nil {
+			st.mu.Unlock()
+			return fmt.Errorf("%w: %q", ErrNotLeader, name)
+		}
+		runCtx = st.leaderCtx
+	}
+	if st.triggered || st.state.Running > 0 {
+		st.mu.Unlock()
+		return fmt.Errorf("%w: %q", ErrJobRunning, name)
+	}
+	st.triggered = true
+	st.mu.Unlock()
+
+	s.log.Info("job triggered", "name", name)
+	s.triggered.Add(1)
+	go func() {
+		defer s.triggered.Done()
+		defer func() {
+			st.mu.Lock()
+			st.triggered = false
+			st.mu.Unlock()
+		}()
+		if err := s.run(runCtx, job); err != nil {
+			s.log.Error("triggered job failed", "name", name, "error", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Scheduler) jobByName(name string) *Job {
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRecordsJobState(t *testing.T) {
+	s := newTestScheduler(t, nil)
+
+	var fail atomic.Bool
+	fail.Store(true)
+	if err := s.Add("report", func(*Context) error {
+		if fail.Load() {
+			return errors.New("boom")
+		}
+		return nil
+	}, WithSchedule("@daily")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	_ = s.execute(context.Background(), s.jobs[0])
+	state, err := s.State("report")
+	if err != nil {
+		t.Fatalf("State() error = %v", err)
+	}
+	if state.RunCount != 1 || state.FailureCount != 1 || state.LastError != "boom" {
+		t.Fatalf("state = %+v, want one failed run", state)
+	}
+	if state.LastStart.IsZero() || state.LastFinish.Before(state.LastStart) || state.Running != 0 {
+		t.Fatalf("state = %+v, want finished run timestamps", state)
+	}
+
+	fail.Store(false)
+	_ = s.execute(context.Background(), s.jobs[0])
+	state, _ = s.State("report")
+	if state.RunCount != 2 || state.FailureCount != 1 || state.LastError != "" {
+		t.Fatalf("state = %+v, want last run succeeded", state)
+	}
+
+	if _, err := s.State("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("State() error = %v, want %v", err, ErrJobNotFound)
+	}
+}
+
+func TestSchedulerPauseTriggerAndNextFire(t *testing.T) {
+	s := newTestScheduler(t, nil)
+
+	var runs atomic.Int32
+	if err := s.Add("tick", func(*Context) error {
+		runs.Add(1)
+		return nil
+	}, WithSchedule("@every 1s")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Trigger("tick"); !errors.Is(err, ErrSchedulerNotRunning) {
+		t.Fatalf("Trigger() before Run error = %v, want %v", err, ErrSchedulerNotRunning)
+	}
+	if err := s.Pause("tick"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+
+	waitFor(t, func() bool {
+		state, _ := s.State("tick")
+		return !state.NextFire.IsZero()
+	})
+	time.Sleep(1200 * time.Millisecond)
+	if got := runs.Load(); got != 0 {
+		t.Fatalf("runs = %d while paused, want 0", got)
+	}
+
+	if err := s.Trigger("tick"); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	waitFor(t, func() bool { return runs.Load() == 1 })
+
+	if err := s.Resume("tick"); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for runs.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := runs.Load(); got < 2 {
+		t.Fatalf("runs = %d after Resume, want scheduled runs", got)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.Stop(stopCtx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if err := s.Trigger("tick"); !errors.Is(err, ErrSchedulerNotRunning) {
+		t.Fatalf("Trigger() after Stop error = %v, want %v", err, ErrSchedulerNotRunning)
+	}
+}
+
+func TestSchedulerTriggerOneShotWhileRunning(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	release := make(chan struct{})
+	if err := s.Add("migrate", func(*Context) error {
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+	waitFor(t, func() bool {
+		state, _ := s.State("migrate")
+		return state.Running == 1
+	})
+	if err := s.Trigger("migrate"); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("Trigger() while running error = %v, want %v", err, ErrJobRunning)
+	}
+	release <- struct{}{}
+	waitFor(t, func() bool {
+		state, _ := s.State("migrate")
+		return state.RunCount == 1
+	})
+
+	if err := s.Trigger("migrate"); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if err := s.Trigger("migrate"); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("second Trigger() error = %v, want %v", err, ErrJobRunning)
+	}
+	close(release)
+	waitFor(t, func() bool {
+		state, _ := s.State("migrate")
+		return state.RunCount == 2 && state.Running == 0
+	})
+}
@@ -0,0 +1,130 @@
+package loadshed
+
+import (
+	"errors"
+	"time"
+)
+
+// Limit algorithms.
+const (
+	AlgorithmGradient = "gradient"
+	AlgorithmAIMD     = "aimd"
+)
+
+// DefaultCritical lists the routes and methods that are never shed: health
+// probes, pprof, admin routes, gRPC health checks and reflection.
+var DefaultCritical = []string{
+	"/healthz",
+	"/readyz",
+	"/debug/pprof/*",
+	"/admin/*",
+	"/grpc.health.v1.Health/*",
+	"/grpc.reflection.*",
+}
+
+// Config configures the load shedding of a server.
+//
+// Example:
+//
+//	loadShed:
+//	  algorithm: gradient
+//	  initialLimit: 50
+//	  maxLimit: 500
+//	  critical: ["/internal/*"]
+//	  low: ["GET /v1/reports/*", "/multi.Report/*"]
+type Config struct {
+	// Algorithm adjusts the limit: "gradient" (default) or "aimd".
+	Algorithm string `yaml:"algorithm" json:"algorithm" toml:"algorithm"`
+
+	// InitialLimit is the concurrency limit at startup (default: 20, within
+	// MinLimit and MaxLimit).
+	InitialLimit int `yaml:"initialLimit" json:"initialLimit" toml:"initialLimit"`
+
+	// MinLimit is the lowest limit (default: 1).
+	MinLimit int `yaml:"minLimit" json:"minLimit" toml:"minLimit"`
+
+	// MaxLimit is the highest limit (default: 1000).
+	MaxLimit int `yaml:"maxLimit" json:"maxLimit" toml:"maxLimit"`
+
+	// Tolerance is how many times the long-term latency the gradient
+	// algorithm accepts before it shrinks the limit (default: 2).
+	Tolerance float64 `yaml:"tolerance" json:"tolerance" toml:"tolerance"`
+
+	// Backoff multiplies the aimd limit when a request is dropped
+	// (default: 0.9).
+	Backoff float64 `yaml:"backoff" json:"backoff" toml:"backoff"`
+
+	// Timeout is the latency above which aimd counts a request as dropped
+	// (default: 5s). Requests whose deadline expires are always dropped.
+	Timeout time.Duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+
+	// RetryAfter is the delay suggested to shed clients (default: 1s).
+	RetryAfter time.Duration `yaml:"retryAfter" json:"retryAfter" toml:"retryAfter"`
+
+	// Critical lists routes and methods that are never shed, in addition to
+	// DefaultCritical. Patterns are those of ratelimit rules: HTTP route
+	// templates, optionally preceded by a method, or full gRPC method names;
+	// a trailing * matches any suffix.
+	Critical []string `yaml:"critical" json:"critical" toml:"critical"`
+
+	// Low lists routes and methods that are shed first.
+	Low []string `yaml:"low" json:"low" toml:"low"`
+}
+
+// Normalize fills default values.
+func (c *Config) Normalize() {
+	if c.Algorithm == "" {
+		c.Algorithm = AlgorithmGradient
+	}
+	if c.MinLimit == 0 {
+		c.MinLimit = 1
+	}
+	if c.MaxLimit == 0 {
+		c.MaxLimit = max(1000, c.InitialLimit, c.MinLimit)
+	}
+	// The default initial limit follows the limits that were set, so that
+	// only explicit values can conflict.
+	if c.InitialLimit == 0 {
+		c.InitialLimit = min(max(20, c.MinLimit), c.MaxLimit)
+	}
+	if c.Tolerance == 0 {
+		c.Tolerance = 2
+	}
+	if c.Backoff == 0 {
+		c.Backoff = 0.9
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.RetryAfter == 0 {
+		c.RetryAfter = time.Second
+	}
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	switch c.Algorithm {
+	case "", AlgorithmGradient, AlgorithmAIMD:
+	default:
+		return errors.New("loadshed: algorithm must be gradient or aimd")
+	}
+	if c.InitialLimit < 0 || c.MinLimit < 0 || c.MaxLimit < 0 {
+		return errors.New("loadshed: limits cannot be negative")
+	}
+	if c.MaxLimit > 0 && (c.MinLimit > c.MaxLimit || c.InitialLimit > c.MaxLimit) {
+		return errors.New("loadshed: minLimit and initialLimit cannot exceed maxLimit")
+	}
+	if c.InitialLimit > 0 && c.MinLimit > c.InitialLimit {
+		return errors.New("loadshed: minLimit cannot exceed initialLimit")
+	}
+	if c.Tolerance != 0 && c.Tolerance < 1 {
+		return errors.New("loadshed: tolerance must be at least 1")
+	}
+	if c.Backoff < 0 || c.Backoff >= 1 {
+		return errors.New("loadshed: backoff must be between 0 and 1")
+	}
+	if c.Timeout < 0 || c.RetryAfter < 0 {
+		return errors.New("loadshed: timeout and retryAfter cannot be negative")
+	}
+	return nil
+}
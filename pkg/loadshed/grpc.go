@@ -0,0 +1,56 @@
+package loadshed
+
+import (
+	"context"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/internal/route"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor sheds the unary RPCs the server cannot take.
+// Priorities match the full method name. Shed calls fail with ErrOverloaded
+// and a retry-after header in seconds. Calls that end with
+// codes.DeadlineExceeded or panic count as dropped.
+func (s *Shedder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		p := s.priority("", info.FullMethod)
+		if p == PriorityCritical {
+			return handler(ctx, req)
+		}
+		if !s.limiter.Acquire(p) {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", route.RetryAfterSeconds(s.retryAfter)))
+			return nil, s.overloaded()
+		}
+
+		start := time.Now()
+		completed := false
+		defer func() {
+			dropped := !completed || status.Code(err) == codes.DeadlineExceeded
+			s.limiter.Release(time.Since(start), dropped)
+		}()
+		resp, err = handler(ctx, req)
+		completed = true
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor sheds streaming RPCs when they start. Streams may
+// last arbitrarily long, so they are checked against the limit but neither
+// held in flight nor used as latency samples.
+func (s *Shedder) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		p := s.priority("", info.FullMethod)
+		if p != PriorityCritical {
+			if !s.limiter.Acquire(p) {
+				_ = ss.SetHeader(metadata.Pairs("retry-after", route.RetryAfterSeconds(s.retryAfter)))
+				return s.overloaded()
+			}
+			s.limiter.Release(0, false)
+		}
+		return handler(srv, ss)
+	}
+}
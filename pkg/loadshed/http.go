@@ -0,0 +1,47 @@
+package loadshed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/internal/route"
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPMiddleware sheds the requests a Gin engine cannot take. Priorities
+// match the route template, or the request path for requests that no route
+// matches. Shed requests get 503 with a Retry-After header and an
+// ErrOverloaded body. Requests whose deadline expires, that end with 504 or
+// that panic count as dropped.
+func (s *Shedder) HTTPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		p := s.priority(c.Request.Method, path)
+		if p == PriorityCritical {
+			c.Next()
+			return
+		}
+		if !s.limiter.Acquire(p) {
+			c.Header("Retry-After", route.RetryAfterSeconds(s.retryAfter))
+			middleware.WriteErrorStatus(c, http.StatusServiceUnavailable, s.overloaded())
+			return
+		}
+
+		start := time.Now()
+		completed := false
+		defer func() {
+			dropped := !completed ||
+				errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) ||
+				c.Writer.Status() == http.StatusGatewayTimeout
+			s.limiter.Release(time.Since(start), dropped)
+		}()
+		c.Next()
+		completed = true
+	}
+}
@@ -0,0 +1,188 @@
+// Package loadshed protects servers from overload with an adaptive
+// concurrency limit.
+//
+// A Limiter bounds the number of requests handled at once and adjusts the
+// bound from the observed latency: it grows while latency stays flat and
+// shrinks when latency rises, with a gradient or an AIMD algorithm. A Shedder
+// applies a Limiter as Gin middleware and as gRPC server interceptors and
+// rejects the excess requests with RESOURCE_EXHAUSTED (HTTP 503) and a
+// Retry-After hint.
+//
+// Requests have a priority. Critical requests, such as health checks and
+// admin routes, are never shed; low priority requests are shed before the
+// limit is reached, leaving room for normal ones.
+package loadshed
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/errors"
+)
+
+// ErrOverloaded is returned for requests shed by a Shedder. errors.Is matches
+// it for every shed request.
+var ErrOverloaded = errors.New(errors.ResourceExhausted, "server overloaded").WithReason("OVERLOADED")
+
+// Priority is the class of a request.
+type Priority int
+
+const (
+	// PriorityNormal requests are admitted up to the limit.
+	PriorityNormal Priority = iota
+
+	// PriorityLow requests are admitted up to lowShare of the limit, so that
+	// they are shed first.
+	PriorityLow
+
+	// PriorityCritical requests are always admitted.
+	PriorityCritical
+)
+
+// lowShare is the share of the limit low priority requests may use.
+const lowShare = 0.8
+
+// algorithm computes the next limit from a sample.
+type algorithm interface {
+	update(limit float64, s sample) float64
+}
+
+// sample is the outcome of one request.
+type sample struct {
+	rtt      time.Duration
+	inflight int  // requests in flight when it completed, including itself
+	dropped  bool // it timed out or was otherwise lost to overload
+}
+
+// Limiter is an adaptive concurrency limit. Every admitted request must be
+// released once it completes.
+type Limiter struct {
+	alg      algorithm
+	min, max float64
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+}
+
+func newLimiter(alg algorithm, initial, min, max int) *Limiter {
+	return &Limiter{alg: alg, limit: float64(initial), min: float64(min), max: float64(max)}
+}
+
+// NewGradient returns a Limiter that compares the latency of the last
+// requests with the long-term latency and shrinks the limit once it exceeds
+// tolerance times the long-term latency, e.g. 2 for twice as slow.
+func NewGradient(initial, min, max int, tolerance float64) *Limiter {
+	return newLimiter(&gradient{tolerance: tolerance}, initial, min, max)
+}
+
+// NewAIMD returns a Limiter that adds one to the limit for every successful
+// request while the limit is in use, and multiplies it by backoff when a
+// request is dropped or takes longer than timeout.
+func NewAIMD(initial, min, max int, backoff float64, timeout time.Duration) *Limiter {
+	return newLimiter(&aimd{backoff: backoff, timeout: timeout}, initial, min, max)
+}
+
+// Acquire reports whether a request of priority p may proceed, counting it
+// in flight if so.
+func (l *Limiter) Acquire(p Priority) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limit
+	if p == PriorityLow {
+		limit *= lowShare
+	}
+	if p != PriorityCritical && float64(l.inflight) >= math.Floor(limit) {
+		return false
+	}
+	l.inflight++
+	return true
+}
+
+// Release marks an admitted request complete after rtt and updates the limit.
+// dropped reports that the request timed out or otherwise failed because of
+// overload. A zero rtt releases the request without updating the limit.
+func (l *Limiter) Release(rtt time.Duration, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := sample{rtt: rtt, inflight: l.inflight, dropped: dropped}
+	l.inflight--
+	if rtt <= 0 {
+		return
+	}
+	l.limit = min(l.max, max(l.min, l.alg.update(l.limit, s)))
+}
+
+// Limit returns the current concurrency limit.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of admitted requests that are not released.
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+// Exponential moving average weights of the short-term latency, about the
+// last 10 requests, and of the long-term one, about the last 600.
+const (
+	shortWeight = 0.1
+	longWeight  = 1.0 / 600
+)
+
+// smoothing is the weight of a new gradient limit against the current one.
+const smoothing = 0.2
+
+// gradient follows the Gradient2 algorithm: the limit is scaled by the ratio
+// of the long-term to the short-term latency, plus a small queue so that it
+// can grow.
+type gradient struct {
+	tolerance float64
+
+	short, long float64 // latency averages in seconds
+}
+
+func (g *gradient) update(limit float64, s sample) float64 {
+	rtt := s.rtt.Seconds()
+	if g.long == 0 {
+		g.short, g.long = rtt, rtt
+	} else {
+		g.short += (rtt - g.short) * shortWeight
+		g.long += (rtt - g.long) * longWeight
+	}
+	// Recover quickly from a long-term average inflated by a past overload.
+	if g.long > 2*g.short {
+		g.long *= 0.95
+	}
+	grad := max(0.5, min(1, g.tolerance*g.long/g.short))
+	next := limit*grad + math.Sqrt(limit)
+	next = limit*(1-smoothing) + next*smoothing
+	// Do not grow a limit that is mostly unused.
+	if float64(s.inflight) < limit/2 {
+		return min(limit, next)
+	}
+	return next
+}
+
+// aimd is additive increase, multiplicative decrease.
+type aimd struct {
+	backoff float64
+	timeout time.Duration
+}
+
+func (a *aimd) update(limit float64, s sample) float64 {
+	if s.dropped || s.rtt > a.timeout {
+		return limit * a.backoff
+	}
+	if float64(s.inflight) >= limit/2 {
+		return limit + 1
+	}
+	return limit
+}
@@ -0,0 +1,248 @@
+package loadshed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLimiterPriorities(t *testing.T) {
+	l := NewAIMD(5, 1, 10, 0.5, time.Second)
+	for i := range 4 {
+		if !l.Acquire(PriorityLow) {
+			t.Fatalf("low request %d rejected", i)
+		}
+	}
+	if l.Acquire(PriorityLow) {
+		t.Fatal("low request admitted above 80% of the limit")
+	}
+	if !l.Acquire(PriorityNormal) {
+		t.Fatal("normal request rejected below the limit")
+	}
+	if l.Acquire(PriorityNormal) {
+		t.Fatal("normal request admitted at the limit")
+	}
+	if !l.Acquire(PriorityCritical) {
+		t.Fatal("critical request rejected")
+	}
+	if got := l.InFlight(); got != 6 {
+		t.Fatalf("InFlight() = %d, want 6", got)
+	}
+}
+
+func TestLimiterAIMD(t *testing.T) {
+	l := NewAIMD(4, 2, 6, 0.5, time.Second)
+	for range 4 {
+		l.Acquire(PriorityNormal)
+	}
+	l.Release(10*time.Millisecond, false)
+	if got := l.Limit(); got != 5 {
+		t.Fatalf("Limit() after success = %d, want 5", got)
+	}
+	l.Release(2*time.Second, false)
+	if got := l.Limit(); got != 2 {
+		t.Fatalf("Limit() after slow request = %d, want 2", got)
+	}
+	l.Release(10*time.Millisecond, true)
+	if got := l.Limit(); got != 2 {
+		t.Fatalf("Limit() after drop = %d, want the minimum 2", got)
+	}
+}
+
+func TestLimiterGradient(t *testing.T) {
+	l := NewGradient(20, 1, 100, 2)
+	// Keep 15 requests in flight, completing one at a time.
+	inflight := 0
+	run := func(n int, rtt time.Duration) {
+		for range n {
+			for inflight < 15 && l.Acquire(PriorityNormal) {
+				inflight++
+			}
+			l.Release(rtt, false)
+			inflight--
+		}
+	}
+
+	run(300, 10*time.Millisecond)
+	grown := l.Limit()
+	if grown <= 20 {
+		t.Fatalf("Limit() = %d with steady latency, want above 20", grown)
+	}
+	run(50, 100*time.Millisecond)
+	if got := l.Limit(); got >= grown/2 {
+		t.Fatalf("Limit() = %d with 10x latency, want below %d", got, grown/2)
+	}
+
+	// An unused limit does not move.
+	idle := NewGradient(20, 1, 100, 2)
+	idle.Acquire(PriorityNormal)
+	idle.Release(time.Second, false)
+	if got := idle.Limit(); got != 20 {
+		t.Fatalf("Limit() = %d when mostly unused, want 20", got)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"algorithm", Config{Algorithm: "vegas"}, "algorithm"},
+		{"negative", Config{MinLimit: -1}, "negative"},
+		{"order", Config{MinLimit: 10, MaxLimit: 5}, "exceed maxLimit"},
+		{"initial", Config{MinLimit: 10, InitialLimit: 5}, "exceed initialLimit"},
+		{"tolerance", Config{Tolerance: 0.5}, "tolerance"},
+		{"backoff", Config{Backoff: 1}, "backoff"},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: Validate() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if err := (&Config{Algorithm: AlgorithmAIMD, MaxLimit: 10}).Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, cfg := range []Config{{MaxLimit: 10}, {MinLimit: 30}, {MinLimit: 5, MaxLimit: 5}} {
+		if _, err := New(&cfg); err != nil {
+			t.Fatalf("New(%+v) error = %v", cfg, err)
+		}
+	}
+	if _, err := New(&Config{InitialLimit: 50, MaxLimit: 10}); err == nil || !strings.Contains(err.Error(), "exceed maxLimit") {
+		t.Fatalf("New() with explicit initialLimit above maxLimit error = %v", err)
+	}
+}
+
+func TestShedderHTTPMiddleware(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	shedder, err := New(&Config{InitialLimit: 1, MaxLimit: 1, RetryAfter: 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	engine := gin.New()
+	engine.Use(middleware.Errors(), shedder.HTTPMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	engine.GET("/users/:id", ok)
+	engine.GET("/healthz", ok)
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := do("/users/1"); w.Code != http.StatusNoContent {
+		t.Fatalf("idle request = %d", w.Code)
+	}
+
+	// Hold the only slot.
+	shedder.Limiter().Acquire(PriorityNormal)
+	w := do("/users/1")
+	var body middleware.ErrorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "2" ||
+		body.Code != errors.ResourceExhausted || body.Reason != "OVERLOADED" {
+		t.Fatalf("overloaded request = %d %v %+v", w.Code, w.Header(), body)
+	}
+	if w := do("/healthz"); w.Code != http.StatusNoContent {
+		t.Fatalf("health check = %d, want never shed", w.Code)
+	}
+}
+
+type headerStream struct {
+	grpc.ServerStream
+	header metadata.MD
+}
+
+func (s *headerStream) Context() context.Context { return context.Background() }
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestShedderGRPCInterceptors(t *testing.T) {
+	shedder, err := New(&Config{
+		InitialLimit: 1,
+		MaxLimit:     1,
+		Low:          []string{"/multi.Report/*"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	unary := shedder.UnaryServerInterceptor()
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+	call := func(method string) error {
+		_, err := unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	if err := call("/multi.User/GetUser"); err != nil {
+		t.Fatalf("idle call error = %v", err)
+	}
+	if err := call("/multi.Report/Export"); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("low priority call error = %v, want ErrOverloaded", err)
+	}
+
+	shedder.Limiter().Acquire(PriorityNormal)
+	if err := call("/multi.User/GetUser"); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("overloaded call error = %v, want ErrOverloaded", err)
+	}
+	if err := call("/grpc.health.v1.Health/Check"); err != nil {
+		t.Fatalf("health check error = %v, want never shed", err)
+	}
+
+	stream := shedder.StreamServerInterceptor()
+	ss := &headerStream{}
+	err = stream(nil, ss, &grpc.StreamServerInfo{FullMethod: "/multi.User/Watch"}, func(any, grpc.ServerStream) error { return nil })
+	if !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("stream error = %v, want ErrOverloaded", err)
+	}
+	if got := ss.header.Get("retry-after"); len(got) != 1 || got[0] != "1" {
+		t.Fatalf("retry-after header = %v", got)
+	}
+	if got := shedder.Limiter().InFlight(); got != 1 {
+		t.Fatalf("InFlight() = %d, want 1", got)
+	}
+}
+
+func TestShedderReleasesOnPanic(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	shedder, err := New(&Config{InitialLimit: 4, MaxLimit: 4})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	engine := gin.New()
+	engine.Use(middleware.Recovery(), shedder.HTTPMiddleware())
+	engine.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking request = %d, want 500", w.Code)
+	}
+	if got := shedder.Limiter().InFlight(); got != 0 {
+		t.Fatalf("InFlight() after HTTP panic = %d, want 0", got)
+	}
+
+	unary := shedder.UnaryServerInterceptor()
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/multi.User/GetUser"},
+			func(context.Context, any) (any, error) { panic("boom") })
+	}()
+	if got := shedder.Limiter().InFlight(); got != 0 {
+		t.Fatalf("InFlight() after RPC panic = %d, want 0", got)
+	}
+}
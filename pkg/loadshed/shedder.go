@@ -0,0 +1,61 @@
+package loadshed
+
+import (
+	"slices"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/internal/route"
+)
+
+// Shedder applies the Limiter of a Config to requests by priority.
+type Shedder struct {
+	limiter    *Limiter
+	critical   []string
+	low        []string
+	retryAfter time.Duration
+}
+
+// New creates a Shedder from cfg.
+func New(cfg *Config) (*Shedder, error) {
+	c := *cfg
+	c.Normalize()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var limiter *Limiter
+	switch c.Algorithm {
+	case AlgorithmAIMD:
+		limiter = NewAIMD(c.InitialLimit, c.MinLimit, c.MaxLimit, c.Backoff, c.Timeout)
+	default:
+		limiter = NewGradient(c.InitialLimit, c.MinLimit, c.MaxLimit, c.Tolerance)
+	}
+	return &Shedder{
+		limiter:    limiter,
+		critical:   slices.Concat(DefaultCritical, c.Critical),
+		low:        slices.Clone(c.Low),
+		retryAfter: c.RetryAfter,
+	}, nil
+}
+
+// Limiter returns the limiter of s.
+func (s *Shedder) Limiter() *Limiter {
+	return s.limiter
+}
+
+// priority returns the priority of a request to path with the HTTP method,
+// empty for gRPC.
+func (s *Shedder) priority(method, path string) Priority {
+	switch {
+	case route.MatchAny(s.critical, method, path):
+		return PriorityCritical
+	case route.MatchAny(s.low, method, path):
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// overloaded returns the error of a shed request.
+func (s *Shedder) overloaded() error {
+	return ErrOverloaded.WithMetadata("retry_after", s.retryAfter.String())
+}
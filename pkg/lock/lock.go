@@ -0,0 +1,214 @@
+// Package lock provides distributed mutual exclusion on top of Redis.
+//
+// A lock is a Redis key set with SET NX PX to a random token. Only the holder
+// of the token can refresh or release it, so a replica whose lock expired
+// cannot remove a lock that another replica acquired in the meantime.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultPrefix        = "octopus:lock:"
+	defaultRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrNotAcquired is returned when the lock is held by someone else.
+	ErrNotAcquired = errors.New("lock: not acquired")
+
+	// ErrNotHeld is returned when the lock expired or is now held by someone else.
+	ErrNotHeld = errors.New("lock: not held")
+)
+
+var (
+	refreshScript = goredis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseScript = goredis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+)
+
+// Locker creates locks backed by a Redis client.
+type Locker struct {
+	client        *redis.Client
+	prefix        string
+	retryInterval time.Duration
+}
+
+// Option customizes a Locker.
+type Option func(*Locker)
+
+// WithPrefix sets the prefix prepended to every lock key (default: "octopus:lock:").
+func WithPrefix(prefix string) Option {
+	return func(l *Locker) {
+		if prefix != "" {
+			l.prefix = prefix
+		}
+	}
+}
+
+// WithRetryInterval sets how often Acquire retries a held lock (default: 100ms).
+func WithRetryInterval(d time.Duration) Option {
+	return func(l *Locker) {
+		if d > 0 {
+			l.retryInterval = d
+		}
+	}
+}
+
+// New creates a Locker that stores locks through client.
+func New(client *redis.Client, opts ...Option) *Locker {
+	l := &Locker{
+		client:        client,
+		prefix:        defaultPrefix,
+		retryInterval: defaultRetryInterval,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(l)
+		}
+	}
+	return l
+}
+
+// TryAcquire takes the lock on key for ttl without waiting.
+// It returns ErrNotAcquired if the lock is already held.
+func (l *Locker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if key == "" {
+		return nil, errors.New("lock: key is required")
+	}
+	if ttl < time.Millisecond {
+		return nil, errors.New("lock: ttl must be at least 1ms")
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	fullKey := l.prefix + key
+	err = l.client.Do(ctx, "set", fullKey, token, "px", ttl.Milliseconds(), "nx").Err()
+	switch {
+	case errors.Is(err, goredis.Nil):
+		return nil, ErrNotAcquired
+	case err != nil:
+		return nil, fmt.Errorf("lock: acquire %q: %w", key, err)
+	}
+	return &Lock{client: l.client, key: fullKey, token: token, ttl: ttl}, nil
+}
+
+// Acquire takes the lock on key for ttl, retrying until it is free or ctx is done.
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	for {
+		lk, err := l.TryAcquire(ctx, key, ttl)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lk, err
+		}
+		timer := time.NewTimer(l.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Lock is an acquired lock.
+type Lock struct {
+	client *redis.Client
+	key    string
+	token  string
+	ttl    time.Duration
+}
+
+// Key returns the full Redis key of the lock.
+func (lk *Lock) Key() string { return lk.key }
+
+// Token returns the random value identifying this holder.
+func (lk *Lock) Token() string { return lk.token }
+
+// Refresh resets the lock expiry to ttl. It returns ErrNotHeld if the lock
+// expired or was taken by someone else.
+func (lk *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return errors.New("lock: ttl must be at least 1ms")
+	}
+	n, err := refreshScript.Run(ctx, lk.client, []string{lk.key}, lk.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("lock: refresh %q: %w", lk.key, err)
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Release deletes the lock. It returns ErrNotHeld if the lock expired or was
+// taken by someone else, in which case nothing is deleted.
+func (lk *Lock) Release(ctx context.Context) error {
+	n, err := releaseScript.Run(ctx, lk.client, []string{lk.key}, lk.token).Int()
+	if err != nil {
+		return fmt.Errorf("lock: release %q: %w", lk.key, err)
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// KeepAlive refreshes the lock to its acquired TTL every third of that TTL
+// until the returned cancel function is called or ctx is done. The returned
+// context is cancelled as soon as the lock is known to be lost: when a refresh
+// reports ErrNotHeld, or when refreshes keep failing until the lock expired.
+func (lk *Lock) KeepAlive(ctx context.Context) (context.Context, context.CancelFunc) {
+	heldCtx, cancel := context.WithCancel(ctx)
+	ttl := lk.ttl
+	go func() {
+		defer cancel()
+		interval := max(ttl/3, time.Millisecond)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		expiry := time.Now().Add(ttl)
+		for {
+			select {
+			case <-heldCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			refreshCtx, refreshCancel := context.WithTimeout(heldCtx, interval)
+			err := lk.Refresh(refreshCtx, ttl)
+			refreshCancel()
+			switch {
+			case err == nil:
+				expiry = time.Now().Add(ttl)
+			case errors.Is(err, ErrNotHeld), time.Now().After(expiry):
+				return
+			}
+		}
+	}()
+	return heldCtx, cancel
+}
+
+func newToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("lock: generate token: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
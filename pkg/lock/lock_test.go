@@ -0,0 +1,143 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestLocker(t *testing.T, opts ...Option) (*Locker, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client, err := redis.New(&redis.Config{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("redis.New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return New(client, opts...), mr
+}
+
+func TestLockerTryAcquireIsExclusive(t *testing.T) {
+	l, mr := newTestLocker(t)
+	ctx := context.Background()
+
+	lk, err := l.TryAcquire(ctx, "report", time.Second)
+	if err != nil {
+		t.Fatalf("TryAcquire() error = %v", err)
+	}
+	if got, _ := mr.Get("octopus:lock:report"); got != lk.Token() {
+		t.Fatalf("stored token = %q, want %q", got, lk.Token())
+	}
+	if ttl := mr.TTL("octopus:lock:report"); ttl != time.Second {
+		t.Fatalf("ttl = %v, want 1s", ttl)
+	}
+	if _, err := l.TryAcquire(ctx, "report", time.Second); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("TryAcquire() error = %v, want %v", err, ErrNotAcquired)
+	}
+
+	mr.FastForward(time.Second)
+	if _, err := l.TryAcquire(ctx, "report", time.Second); err != nil {
+		t.Fatalf("TryAcquire() after expiry error = %v", err)
+	}
+}
+
+func TestLockReleaseChecksToken(t *testing.T) {
+	l, mr := newTestLocker(t, WithPrefix("app:"))
+	ctx := context.Background()
+
+	stale, err := l.TryAcquire(ctx, "report", time.Second)
+	if err != nil {
+		t.Fatalf("TryAcquire() error = %v", err)
+	}
+	mr.FastForward(time.Second)
+	current, err := l.TryAcquire(ctx, "report", time.Second)
+	if err != nil {
+		t.Fatalf("TryAcquire() error = %v", err)
+	}
+
+	if err := stale.Release(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("stale Release() error = %v, want %v", err, ErrNotHeld)
+	}
+	if err := stale.Refresh(ctx, time.Second); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("stale Refresh() error = %v, want %v", err, ErrNotHeld)
+	}
+	if !mr.Exists("app:report") {
+		t.Fatal("stale holder removed the current lock")
+	}
+	if err := current.Release(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if mr.Exists("app:report") {
+		t.Fatal("lock still exists after Release()")
+	}
+}
+
+func TestLockRefreshExtendsExpiry(t *testing.T) {
+	l, mr := newTestLocker(t)
+	ctx := context.Background()
+
+	lk, err := l.TryAcquire(ctx, "report", time.Second)
+	if err != nil {
+		t.Fatalf("TryAcquire() error = %v", err)
+	}
+	if err := lk.Refresh(ctx, 5*time.Second); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if ttl := mr.TTL(lk.Key()); ttl != 5*time.Second {
+		t.Fatalf("ttl = %v, want 5s", ttl)
+	}
+}
+
+func TestLockerAcquireWaitsForRelease(t *testing.T) {
+	l, _ := newTestLocker(t, WithRetryInterval(5*time.Millisecond))
+	ctx := context.Background()
+
+	held, err := l.TryAcquire(ctx, "report", time.Minute)
+	if err != nil {
+		t.Fatalf("TryAcquire() error = %v", err)
+	}
+	time.AfterFunc(20*time.Millisecond, func() { _ = held.Release(ctx) })
+
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if _, err := l.Acquire(waitCtx, "report", time.Minute); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	shortCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(shortCtx, "report", time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestLockKeepAliveCancelsWhenLost(t *testing.T) {
+	l, mr := newTestLocker(t)
+
+	lk, err := l.TryAcquire(context.Background(), "report", 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("TryAcquire() error = %v", err)
+	}
+	heldCtx, cancel := lk.KeepAlive(context.Background())
+	defer cancel()
+
+	// Refreshes keep the lock alive well past its original TTL.
+	time.Sleep(100 * time.Millisecond)
+	if heldCtx.Err() != nil {
+		t.Fatal("lock lost while being kept alive")
+	}
+	if ttl := mr.TTL(lk.Key()); ttl <= 0 {
+		t.Fatalf("ttl = %v, want refreshed expiry", ttl)
+	}
+
+	mr.Set(lk.Key(), "someone-else")
+	select {
+	case <-heldCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("KeepAlive() context not cancelled after the lock was taken")
+	}
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultPath is the default route of the Prometheus scrape endpoint.
+const DefaultPath = "/metrics"
+
+// Config configures metrics collection and exposition.
+//
+// Example:
+//
+//	metrics:
+//	  enabled: true
+//	  path: /metrics
+//	  addr: 0.0.0.0:9090
+type Config struct {
+	// Enabled turns metrics collection on.
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+
+	// Path is the route of the scrape endpoint (default: /metrics).
+	Path string `yaml:"path" json:"path" toml:"path"`
+
+	// Addr, when set, serves the scrape endpoint on a dedicated listener.
+	// Otherwise it is mounted on the API server.
+	Addr string `yaml:"addr" json:"addr" toml:"addr"`
+
+	// Namespace is prepended to the names of the built-in metrics.
+	Namespace string `yaml:"namespace" json:"namespace" toml:"namespace"`
+}
+
+// Normalize fills default values.
+func (c *Config) Normalize() {
+	if c.Path == "" {
+		c.Path = DefaultPath
+	}
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		return errors.New("metrics: path must start with /")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// UnaryServerInterceptor records per-method metrics for unary RPCs. Codes
+// are those clients receive: errors are converted like the errors
+// interceptor does, and panics are recorded as codes.Internal.
+func (r *Registry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+		completed := false
+		defer func() { r.observeRPC("unary", info.FullMethod, start, completed, err) }()
+		resp, err = handler(ctx, req)
+		completed = true
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records per-method metrics for streaming RPCs,
+// with codes as for unary RPCs.
+func (r *Registry) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		completed := false
+		defer func() { r.observeRPC(streamType(info), info.FullMethod, start, completed, err) }()
+		err = handler(srv, ss)
+		completed = true
+		return err
+	}
+}
+
+// observeRPC records a call. Calls that did not complete panicked, which
+// the recovery interceptor answers with codes.Internal.
+func (r *Registry) observeRPC(typ, fullMethod string, start time.Time, completed bool, err error) {
+	code := codes.Internal
+	if completed {
+		code = errors.ToStatus(err).Code()
+	}
+	service, method := splitMethod(fullMethod)
+	r.grpcHandled.WithLabelValues(typ, service, method, code.String()).Inc()
+	r.grpcDuration.WithLabelValues(typ, service, method).Observe(time.Since(start).Seconds())
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
+
+// splitMethod splits "/package.Service/Method" into service and method.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPMiddleware records the count, latency and status of the requests
+// handled by a Gin engine. Requests are labelled by route template rather than
+// raw path to keep the number of series bounded. Panicking requests are
+// recorded with the 500 the outer recovery middleware answers them with.
+func (r *Registry) HTTPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		r.httpInFlight.Inc()
+		completed := false
+		defer func() {
+			r.httpInFlight.Dec()
+			code := c.Writer.Status()
+			if !completed {
+				code = http.StatusInternalServerError
+			}
+			route := c.FullPath()
+			if route == "" {
+				route = "unmatched"
+			}
+			method := c.Request.Method
+			r.httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+			r.httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
@@ -0,0 +1,13 @@
+package metrics
+
+import "time"
+
+// ObserveRun records the outcome of one job run. It implements job.Observer.
+func (r *Registry) ObserveRun(name string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	r.jobRuns.WithLabelValues(name, result).Inc()
+	r.jobDuration.WithLabelValues(name).Observe(duration.Seconds())
+}
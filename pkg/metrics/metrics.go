@@ -0,0 +1,126 @@
+// Package metrics records application metrics and serves them in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// durationBuckets are the histogram buckets, in seconds, of the built-in
+// latency metrics.
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the collectors of an application, including the built-in
+// HTTP, gRPC, job and connection pool metrics.
+type Registry struct {
+	reg       *prometheus.Registry
+	namespace string
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+
+	grpcHandled  *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+
+	jobRuns     *prometheus.CounterVec
+	jobDuration *prometheus.HistogramVec
+}
+
+// Option customizes a Registry.
+type Option func(*Registry)
+
+// WithNamespace prefixes the names of the built-in metrics.
+func WithNamespace(ns string) Option {
+	return func(r *Registry) {
+		r.namespace = ns
+	}
+}
+
+// New creates a Registry with the Go runtime and process collectors and the
+// built-in metrics registered.
+func New(opts ...Option) *Registry {
+	r := &Registry{reg: prometheus.NewRegistry()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(r)
+		}
+	}
+
+	r.httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: r.namespace,
+		Name:      "http_server_requests_total",
+		Help:      "Total number of HTTP requests handled.",
+	}, []string{"method", "route", "status"})
+	r.httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: r.namespace,
+		Name:      "http_server_request_duration_seconds",
+		Help:      "Latency of HTTP requests.",
+		Buckets:   durationBuckets,
+	}, []string{"method", "route"})
+	r.httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: r.namespace,
+		Name:      "http_server_requests_in_flight",
+		Help:      "Number of HTTP requests being handled.",
+	})
+	r.grpcHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: r.namespace,
+		Name:      "grpc_server_handled_total",
+		Help:      "Total number of RPCs completed on the server.",
+	}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"})
+	r.grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: r.namespace,
+		Name:      "grpc_server_handling_seconds",
+		Help:      "Latency of RPCs handled by the server.",
+		Buckets:   durationBuckets,
+	}, []string{"grpc_type", "grpc_service", "grpc_method"})
+	r.jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: r.namespace,
+		Name:      "job_runs_total",
+		Help:      "Total number of job runs by result.",
+	}, []string{"job", "result"})
+	r.jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: r.namespace,
+		Name:      "job_run_duration_seconds",
+		Help:      "Duration of job runs, including retries.",
+		Buckets:   []float64{.01, .1, .5, 1, 5, 10, 30, 60, 300, 900, 3600},
+	}, []string{"job"})
+
+	r.reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		r.httpRequests, r.httpDuration, r.httpInFlight,
+		r.grpcHandled, r.grpcDuration,
+		r.jobRuns, r.jobDuration,
+	)
+	return r
+}
+
+// Register registers application-defined collectors.
+func (r *Registry) Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := r.reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Registerer returns the underlying prometheus.Registerer.
+func (r *Registry) Registerer() prometheus.Registerer {
+	return r.reg
+}
+
+// Gatherer returns the underlying prometheus.Gatherer.
+func (r *Registry) Gatherer() prometheus.Gatherer {
+	return r.reg
+}
+
+// Handler serves the registered metrics in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.reg, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeDB struct{}
+
+func (fakeDB) Stats() sql.DBStats { return sql.DBStats{MaxOpenConnections: 10, InUse: 3} }
+
+type fakeRedis struct{}
+
+func (fakeRedis) PoolStats() *goredis.PoolStats { return &goredis.PoolStats{Hits: 7, TotalConns: 2} }
+
+// scrape returns the text exposition of r.
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scrape status = %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func assertContains(t *testing.T, body string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(body, w) {
+			t.Fatalf("metrics output missing %q\n%s", w, body)
+		}
+	}
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := New(WithNamespace("app"))
+	e := gin.New()
+	e.Use(gin.Recovery(), r.HTTPMiddleware())
+	e.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	e.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing", "/panic"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assertContains(t, scrape(t, r),
+		`app_http_server_requests_total{method="GET",route="/users/:id",status="204"} 2`,
+		`app_http_server_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`app_http_server_request_duration_seconds_count{method="GET",route="/users/:id"} 2`,
+		`app_http_server_requests_total{method="GET",route="/panic",status="500"} 1`,
+		`app_http_server_requests_in_flight 0`,
+	)
+}
+
+func TestServerInterceptors(t *testing.T) {
+	r := New()
+	unary := r.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/greeter.v1.Greeter/SayHello"}
+	_, _ = unary(context.Background(), nil, info, func(context.Context, any) (any, error) { return "ok", nil })
+	_, _ = unary(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	})
+	_, _ = unary(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return nil, errors.New("boom")
+	})
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = unary(context.Background(), nil, info, func(context.Context, any) (any, error) { panic("boom") })
+	}()
+
+	stream := r.StreamServerInterceptor()
+	_ = stream(nil, nil, &grpc.StreamServerInfo{FullMethod: "/greeter.v1.Greeter/Chat", IsClientStream: true, IsServerStream: true},
+		func(any, grpc.ServerStream) error { return nil })
+
+	assertContains(t, scrape(t, r),
+		`grpc_server_handled_total{grpc_code="OK",grpc_method="SayHello",grpc_service="greeter.v1.Greeter",grpc_type="unary"} 1`,
+		`grpc_server_handled_total{grpc_code="NotFound",grpc_method="SayHello",grpc_service="greeter.v1.Greeter",grpc_type="unary"} 1`,
+		`grpc_server_handled_total{grpc_code="Internal",grpc_method="SayHello",grpc_service="greeter.v1.Greeter",grpc_type="unary"} 2`,
+		`grpc_server_handled_total{grpc_code="OK",grpc_method="Chat",grpc_service="greeter.v1.Greeter",grpc_type="bidi_stream"} 1`,
+	)
+}
+
+func TestObserveRun(t *testing.T) {
+	r := New()
+	r.ObserveRun("report", time.Second, nil)
+	r.ObserveRun("report", time.Second, errors.New("boom"))
+
+	assertContains(t, scrape(t, r),
+		`job_runs_total{job="report",result="success"} 1`,
+		`job_runs_total{job="report",result="failure"} 1`,
+		`job_run_duration_seconds_sum{job="report"} 2`,
+	)
+}
+
+func TestPoolCollectors(t *testing.T) {
+	r := New()
+	if err := r.RegisterDB("mysql/primary", fakeDB{}); err != nil {
+		t.Fatalf("RegisterDB() error = %v", err)
+	}
+	if err := r.RegisterDB("mysql/primary", fakeDB{}); err == nil {
+		t.Fatalf("RegisterDB() expected duplicate error")
+	}
+	if err := r.RegisterRedis("cache", fakeRedis{}); err != nil {
+		t.Fatalf("RegisterRedis() error = %v", err)
+	}
+
+	assertContains(t, scrape(t, r),
+		`db_pool_max_open_connections{db="mysql/primary"} 10`,
+		`db_pool_in_use_connections{db="mysql/primary"} 3`,
+		`redis_pool_hits_total{client="cache"} 7`,
+		`redis_pool_connections{client="cache"} 2`,
+	)
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := &Config{Path: "metrics"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("Validate() expected error for relative path")
+	}
+	cfg = &Config{}
+	cfg.Normalize()
+	if cfg.Path != DefaultPath {
+		t.Fatalf("Normalize() path = %q, want %q", cfg.Path, DefaultPath)
+	}
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// DBStatser is implemented by database handles such as *sql.DB and
+// *database.DB.
+type DBStatser interface {
+	Stats() sql.DBStats
+}
+
+// RedisPoolStatser is implemented by go-redis clients.
+type RedisPoolStatser interface {
+	PoolStats() *goredis.PoolStats
+}
+
+// RegisterDB exports the connection pool stats of db, labelled with name.
+func (r *Registry) RegisterDB(name string, db DBStatser) error {
+	return r.reg.Register(newDBCollector(r.namespace, name, db))
+}
+
+// RegisterRedis exports the connection pool stats of client, labelled with
+// name.
+func (r *Registry) RegisterRedis(name string, client RedisPoolStatser) error {
+	return r.reg.Register(newRedisCollector(r.namespace, name, client))
+}
+
+type dbCollector struct {
+	db DBStatser
+
+	maxOpen, open, inUse, idle                                *prometheus.Desc
+	waitCount, waitDuration, maxIdleClosed, maxLifetimeClosed *prometheus.Desc
+}
+
+func newDBCollector(ns, name string, db DBStatser) *dbCollector {
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(ns, "db_pool", metric), help, nil, prometheus.Labels{"db": name})
+	}
+	return &dbCollector{
+		db:                db,
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections."),
+		open:              desc("open_connections", "Number of established connections, in use and idle."),
+		inUse:             desc("in_use_connections", "Number of connections currently in use."),
+		idle:              desc("idle_connections", "Number of idle connections."),
+		waitCount:         desc("wait_count_total", "Total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "Total time blocked waiting for a new connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "Total number of connections closed due to the idle limit."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "Total number of connections closed due to the lifetime limit."),
+	}
+}
+
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.maxOpen, c.open, c.inUse, c.idle, c.waitCount, c.waitDuration, c.maxIdleClosed, c.maxLifetimeClosed} {
+		ch <- d
+	}
+}
+
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(s.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(s.MaxLifetimeClosed))
+}
+
+type redisCollector struct {
+	client RedisPoolStatser
+
+	hits, misses, timeouts, total, idle, stale *prometheus.Desc
+}
+
+func newRedisCollector(ns, name string, client RedisPoolStatser) *redisCollector {
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(ns, "redis_pool", metric), help, nil, prometheus.Labels{"client": name})
+	}
+	return &redisCollector{
+		client:   client,
+		hits:     desc("hits_total", "Total number of times a free connection was found in the pool."),
+		misses:   desc("misses_total", "Total number of times a free connection was not found in the pool."),
+		timeouts: desc("timeouts_total", "Total number of times a wait for a connection timed out."),
+		total:    desc("connections", "Number of connections in the pool."),
+		idle:     desc("idle_connections", "Number of idle connections in the pool."),
+		stale:    desc("stale_connections_total", "Total number of stale connections removed from the pool."),
+	}
+}
+
+func (c *redisCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.hits, c.misses, c.timeouts, c.total, c.idle, c.stale} {
+		ch <- d
+	}
+}
+
+func (c *redisCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.stale, prometheus.CounterValue, float64(s.StaleConns))
+}
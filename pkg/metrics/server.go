@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+// Server serves the scrape endpoint on a dedicated listener, keeping metrics
+// off the public API port.
+type Server struct {
+	log  *xlog.Logger
+	addr string
+
+	httpServer *http.Server
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// NewServer creates a Server for r listening on config.Addr.
+func NewServer(log *xlog.Logger, config *Config, r *Registry) (*Server, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Addr == "" {
+		return nil, errors.New("metrics: addr is required")
+	}
+	config.Normalize()
+
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, r.Handler())
+	return &Server{
+		log:        log,
+		addr:       config.Addr,
+		httpServer: &http.Server{Addr: config.Addr, Handler: mux},
+		ready:      make(chan struct{}),
+	}, nil
+}
+
+// Run starts the listener and blocks until ctx is cancelled or the server
+// fails. Like api.Server, it leaves shutdown to Stop.
+func (s *Server) Run(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		s.log.Error("failed to listen", "error", err)
+		return err
+	}
+	s.log.Info("starting metrics server", "addr", lis.Addr().String())
+
+	errCh := make(chan error, 1)
+	go func() {
+		err := s.httpServer.Serve(lis)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+	s.readyOnce.Do(func() { close(s.ready) })
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// Ready returns a channel that is closed once the server is listening.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Stop gracefully shuts down the listener.
+func (s *Server) Stop(ctx context.Context) error {
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.log.Error("failed to shutdown metrics server", "error", err)
+		return err
+	}
+	return nil
+}
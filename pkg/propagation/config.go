@@ -0,0 +1,45 @@
+package propagation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultHeaders lists the headers propagated when Config.Headers is empty:
+// the request ID and W3C baggage.
+var DefaultHeaders = []string{RequestIDKey, BaggageKey}
+
+// Config configures which incoming request values are propagated to
+// outgoing calls.
+//
+// Example:
+//
+//	propagation:
+//	  headers: [x-request-id, baggage, x-tenant-id]
+//	  principal: true
+type Config struct {
+	// Headers lists the incoming HTTP headers and gRPC metadata keys copied
+	// to outgoing calls, case-insensitively. Defaults to DefaultHeaders.
+	Headers []string `yaml:"headers" json:"headers" toml:"headers"`
+
+	// Principal sends the subject of the authenticated caller as
+	// x-principal, so that downstream logs name the original caller. It is
+	// informational: downstream services must not authenticate with it.
+	Principal bool `yaml:"principal" json:"principal" toml:"principal"`
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	for i, h := range c.Headers {
+		key := strings.ToLower(strings.TrimSpace(h))
+		switch {
+		case key == "":
+			return fmt.Errorf("propagation: headers[%d] is empty", i)
+		case strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-"):
+			return fmt.Errorf("propagation: headers[%d]: %q is reserved", i, h)
+		case key == PrincipalKey:
+			return fmt.Errorf("propagation: headers[%d]: set principal to propagate %s", i, PrincipalKey)
+		}
+	}
+	return nil
+}
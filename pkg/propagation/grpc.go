@@ -0,0 +1,78 @@
+package propagation
+
+import (
+	"context"
+
+	"github.com/HorseArcher567/octopus/pkg/rpc/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerInterceptor captures the propagated values of unary RPCs. The
+// request ID assigned by the request ID interceptor takes precedence over
+// the incoming one.
+func (p *Propagator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(p.captureRPC(ctx), req)
+	}
+}
+
+// StreamServerInterceptor captures the propagated values of streaming RPCs.
+func (p *Propagator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: p.captureRPC(ss.Context())})
+	}
+}
+
+func (p *Propagator) captureRPC(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID, _ := middleware.RequestIDFromContext(ctx)
+	return p.capture(ctx, md.Get, requestID)
+}
+
+// UnaryClientInterceptor sends the values captured in the call context as
+// outgoing metadata, unless the call sets them itself.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor sends the values captured in the stream context as
+// outgoing metadata.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+func outgoing(ctx context.Context) context.Context {
+	md, ok := FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	out, _ := metadata.FromOutgoingContext(ctx)
+	var kv []string
+	for key, values := range md {
+		if len(out.Get(key)) > 0 {
+			continue
+		}
+		for _, v := range values {
+			kv = append(kv, key, v)
+		}
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// contextServerStream overrides the context of a grpc.ServerStream.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
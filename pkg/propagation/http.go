@@ -0,0 +1,58 @@
+package propagation
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPMiddleware captures the propagated values of HTTP requests. Install it
+// after the authentication middleware to propagate the principal.
+func (p *Propagator) HTTPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Request.Header
+		ctx := p.capture(c.Request.Context(), func(key string) []string {
+			return header.Values(key)
+		}, "")
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Transport returns an http.RoundTripper that sends the values captured in
+// the request context as headers, unless the request sets them itself. A nil
+// base uses http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	md, ok := FromContext(req.Context())
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	var out *http.Request
+	for key, values := range md {
+		if req.Header.Get(key) != "" {
+			continue
+		}
+		if out == nil {
+			// A RoundTripper must not modify the caller's request.
+			out = req.Clone(req.Context())
+		}
+		for _, v := range values {
+			out.Header.Add(key, v)
+		}
+	}
+	if out == nil {
+		out = req
+	}
+	return t.base.RoundTrip(out)
+}
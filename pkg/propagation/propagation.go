@@ -0,0 +1,89 @@
+// Package propagation carries request values from incoming requests to the
+// outgoing calls made while handling them.
+//
+// A Propagator captures an allow-list of incoming HTTP headers or gRPC
+// metadata, the request ID and optionally the caller's principal into the
+// request context, through Gin middleware and gRPC server interceptors.
+// Client interceptors and an http.RoundTripper then send the captured values
+// with every outgoing call made with that context, so that logs across
+// services correlate. Deadlines need no help: gRPC sends the context
+// deadline, and HTTP requests are cancelled when it expires.
+package propagation
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"google.golang.org/grpc/metadata"
+)
+
+// Propagated keys.
+const (
+	RequestIDKey = "x-request-id"
+	BaggageKey   = "baggage"
+	PrincipalKey = "x-principal"
+)
+
+// Propagator captures the propagated values of incoming requests.
+type Propagator struct {
+	headers   []string
+	principal bool
+}
+
+// New creates a Propagator from cfg.
+func New(cfg *Config) (*Propagator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	headers := cfg.Headers
+	if len(headers) == 0 {
+		headers = DefaultHeaders
+	}
+	p := &Propagator{principal: cfg.Principal}
+	for _, h := range headers {
+		key := strings.ToLower(strings.TrimSpace(h))
+		if !slices.Contains(p.headers, key) {
+			p.headers = append(p.headers, key)
+		}
+	}
+	return p, nil
+}
+
+type valuesKey struct{}
+
+// NewContext returns a copy of ctx carrying md as the values to propagate.
+func NewContext(ctx context.Context, md metadata.MD) context.Context {
+	return context.WithValue(ctx, valuesKey{}, md)
+}
+
+// FromContext returns the values to propagate carried by ctx. Keys are
+// lower case.
+func FromContext(ctx context.Context) (metadata.MD, bool) {
+	md, ok := ctx.Value(valuesKey{}).(metadata.MD)
+	return md, ok && len(md) > 0
+}
+
+// capture stores the allowed values returned by get in ctx. requestID, if
+// not empty, replaces the incoming request ID.
+func (p *Propagator) capture(ctx context.Context, get func(key string) []string, requestID string) context.Context {
+	md := metadata.MD{}
+	for _, key := range p.headers {
+		if v := get(key); len(v) > 0 {
+			md[key] = slices.Clone(v)
+		}
+	}
+	if requestID != "" && slices.Contains(p.headers, RequestIDKey) {
+		md[RequestIDKey] = []string{requestID}
+	}
+	if p.principal {
+		if subject := auth.SubjectFromContext(ctx); subject != "" {
+			md[PrincipalKey] = []string{subject}
+		}
+	}
+	if len(md) == 0 {
+		return ctx
+	}
+	return NewContext(ctx, md)
+}
@@ -0,0 +1,131 @@
+package propagation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/rpc/middleware"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHTTPMiddlewareAndTransport(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	p, err := New(&Config{Headers: []string{"X-Request-Id", "baggage", "X-Tenant-Id"}, Principal: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var got http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &auth.Principal{Subject: "alice"}))
+	}, p.HTTPMiddleware())
+	engine.GET("/orders", func(c *gin.Context) {
+		req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, downstream.URL, nil)
+		req.Header.Set("X-Tenant-Id", "override")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("Do() error = %v", err)
+			return
+		}
+		resp.Body.Close()
+		if len(req.Header) != 1 {
+			t.Errorf("Transport modified the request headers: %v", req.Header)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("Baggage", "tenant=acme")
+	req.Header.Set("X-Tenant-Id", "acme")
+	req.Header.Set("X-Secret", "s3cr3t")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]string{
+		"X-Request-Id": "req-1",
+		"Baggage":      "tenant=acme",
+		"X-Tenant-Id":  "override",
+		"X-Principal":  "alice",
+		"X-Secret":     "",
+	}
+	for key, v := range want {
+		if got.Get(key) != v {
+			t.Fatalf("downstream %s = %q, want %q", key, got.Get(key), v)
+		}
+	}
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	p, err := New(&Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var out metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		out, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		ctx = metadata.AppendToOutgoingContext(ctx, BaggageKey, "explicit")
+		return nil, UnaryClientInterceptor()(ctx, "/inventory.Inventory/Reserve", nil, nil, nil, invoker)
+	}
+
+	// The request ID interceptor runs first and assigns the ID.
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		RequestIDKey, "incoming", BaggageKey, "tenant=acme", "authorization", "Bearer token",
+	))
+	_, err = middleware.UnaryRequestID()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		return p.UnaryServerInterceptor()(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Create"}, handler)
+	})
+	if err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+	if got := out.Get(RequestIDKey); len(got) != 1 || got[0] != "incoming" {
+		t.Fatalf("outgoing %s = %v", RequestIDKey, got)
+	}
+	if got := out.Get(BaggageKey); len(got) != 1 || got[0] != "explicit" {
+		t.Fatalf("outgoing %s = %v, want the explicit value only", BaggageKey, got)
+	}
+	if got := out.Get("authorization"); len(got) != 0 {
+		t.Fatalf("outgoing authorization = %v, want none", got)
+	}
+	if got := out.Get(PrincipalKey); len(got) != 0 {
+		t.Fatalf("outgoing %s = %v without principal enabled", PrincipalKey, got)
+	}
+
+	// Without captured values the call context is left alone.
+	out = nil
+	if err := UnaryClientInterceptor()(context.Background(), "/a.A/B", nil, nil, nil, invoker); err != nil || out != nil {
+		t.Fatalf("uncaptured call: err = %v, metadata = %v", err, out)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		headers []string
+		want    string
+	}{
+		{[]string{" "}, "is empty"},
+		{[]string{"grpc-timeout"}, "reserved"},
+		{[]string{":authority"}, "reserved"},
+		{[]string{"X-Principal"}, "set principal"},
+	}
+	for _, tt := range tests {
+		cfg := Config{Headers: tt.headers}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("Validate(%v) error = %v, want %q", tt.headers, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Key sources of a Rule.
+const (
+	KeyIP        = "ip"
+	KeyPrincipal = "principal"
+
+	keyHeaderPrefix   = "header:"
+	keyMetadataPrefix = "metadata:"
+)
+
+// Config configures the rate limits of a server.
+//
+// Example:
+//
+//	rateLimit:
+//	  redis: cache
+//	  rules:
+//	    - name: login
+//	      match: ["POST /v1/login"]
+//	      limit: 10
+//	      window: 1m
+//	    - name: api-key
+//	      match: ["/multi.User/*"]
+//	      key: metadata:x-api-key
+//	      limit: 100
+//	      window: 1s
+//	      burst: 200
+type Config struct {
+	// Redis names the Redis client in the store that keeps the counts, so
+	// that replicas share them. Empty keeps them in memory per replica.
+	Redis string `yaml:"redis" json:"redis" toml:"redis"`
+
+	// Prefix is prepended to the Redis keys (default: "octopus:ratelimit:").
+	Prefix string `yaml:"prefix" json:"prefix" toml:"prefix"`
+
+	// Rules are the rate limits. A request must pass every rule it matches.
+	Rules []Rule `yaml:"rules" json:"rules" toml:"rules"`
+}
+
+// Rule limits the requests it matches, per key.
+type Rule struct {
+	// Name identifies the rule in errors, logs and Redis keys.
+	Name string `yaml:"name" json:"name" toml:"name"`
+
+	// Match lists the routes or methods the rule applies to; empty matches
+	// every request. HTTP patterns are route templates, optionally preceded
+	// by a method, e.g. "GET /v1/users/:id"; gRPC patterns are full method
+	// names, e.g. "/pkg.Service/Method". A trailing * matches any suffix.
+	Match []string `yaml:"match" json:"match" toml:"match"`
+
+	// Key selects what requests are counted by: "ip" (default), "principal",
+	// "header:<name>" or "metadata:<key>", which are the same for HTTP
+	// headers and gRPC metadata. The principal is found as set by
+	// WithPrincipal, the auth subject in assembled apps. Requests without the
+	// principal, header or metadata are counted by client IP.
+	Key string `yaml:"key" json:"key" toml:"key"`
+
+	// Limit is the number of requests allowed per Window.
+	Limit int `yaml:"limit" json:"limit" toml:"limit"`
+
+	// Window is the period of Limit (default: 1s).
+	Window time.Duration `yaml:"window" json:"window" toml:"window"`
+
+	// Burst is the bucket size of in-memory limits (default: Limit). It is
+	// ignored by Redis limits.
+	Burst int `yaml:"burst" json:"burst" toml:"burst"`
+}
+
+// Normalize fills default values.
+func (c *Config) Normalize() {
+	if c.Prefix == "" {
+		c.Prefix = DefaultPrefix
+	}
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if r.Key == "" {
+			r.Key = KeyIP
+		}
+		if r.Window == 0 {
+			r.Window = time.Second
+		}
+	}
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	names := make(map[string]bool, len(c.Rules))
+	for i, r := range c.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("ratelimit: rules[%d]: name is required", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("ratelimit: duplicate rule %q", r.Name)
+		}
+		names[r.Name] = true
+		if r.Limit <= 0 {
+			return fmt.Errorf("ratelimit: rule %q: limit must be positive", r.Name)
+		}
+		if r.Window < 0 || r.Burst < 0 {
+			return fmt.Errorf("ratelimit: rule %q: window and burst cannot be negative", r.Name)
+		}
+		if err := validateKey(r.Key); err != nil {
+			return fmt.Errorf("ratelimit: rule %q: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateKey(key string) error {
+	switch {
+	case key == "", key == KeyIP, key == KeyPrincipal:
+		return nil
+	case strings.HasPrefix(key, keyHeaderPrefix) && len(key) > len(keyHeaderPrefix),
+		strings.HasPrefix(key, keyMetadataPrefix) && len(key) > len(keyMetadataPrefix):
+		return nil
+	}
+	return errors.New("key must be ip, principal, header:<name> or metadata:<key>")
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+
+	"github.com/HorseArcher567/octopus/pkg/internal/route"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// UnaryServerInterceptor applies the policy to unary RPCs. Rules match the
+// full method name. Rejected calls fail with ErrRateLimited and a
+// retry-after header in seconds.
+func (p *Policy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := p.checkRPC(ctx, info.FullMethod, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		}); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor applies the policy to streaming RPCs when they
+// start.
+func (p *Policy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := p.checkRPC(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (p *Policy) checkRPC(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	res, err := p.check(ctx, request{
+		route:    method,
+		ip:       peerIP(ctx),
+		certName: peerCommonName(ctx),
+		value: func(name string) string {
+			if v := md.Get(name); len(v) > 0 {
+				return v[0]
+			}
+			return ""
+		},
+	})
+	if err != nil {
+		_ = setHeader(metadata.Pairs("retry-after", route.RetryAfterSeconds(res.RetryAfter)))
+	}
+	return err
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func peerCommonName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	return tlsCommonName(&info.State)
+}
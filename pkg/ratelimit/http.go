@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"strconv"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/internal/route"
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPMiddleware applies the policy to the requests handled by a Gin engine.
+// Rules match the route template, or the request path for requests that no
+// route matches. Rejected requests get 429 with a Retry-After header; every
+// limited request gets X-RateLimit-Limit and X-RateLimit-Remaining headers.
+func (p *Policy) HTTPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		res, err := p.check(c.Request.Context(), request{
+			method:   c.Request.Method,
+			route:    path,
+			ip:       c.ClientIP(),
+			certName: tlsCommonName(c.Request.TLS),
+			value:    c.GetHeader,
+		})
+		if res.Limit > 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		}
+		if err != nil {
+			c.Header("Retry-After", route.RetryAfterSeconds(res.RetryAfter))
+			middleware.WriteError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
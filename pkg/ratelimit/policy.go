@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/internal/route"
+	"github.com/HorseArcher567/octopus/pkg/redis"
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+// Policy applies the rules of a Config to requests.
+type Policy struct {
+	rules     []*rule
+	redis     *redis.Client
+	principal func(context.Context) string
+}
+
+type rule struct {
+	Rule
+	limiter Limiter
+}
+
+// Option customizes a Policy.
+type Option func(*Policy)
+
+// WithRedis keeps the counts of every rule in Redis with a sliding window
+// instead of in-memory token buckets.
+func WithRedis(client *redis.Client) Option {
+	return func(p *Policy) {
+		p.redis = client
+	}
+}
+
+// WithPrincipal sets how the "principal" key finds the authenticated
+// principal of a request. By default it is the common name of the verified
+// client certificate.
+func WithPrincipal(fn func(context.Context) string) Option {
+	return func(p *Policy) {
+		p.principal = fn
+	}
+}
+
+// New creates a Policy from cfg.
+func New(cfg *Config, opts ...Option) (*Policy, error) {
+	c := *cfg
+	c.Rules = append([]Rule(nil), cfg.Rules...)
+	c.Normalize()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &Policy{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	for _, r := range c.Rules {
+		var limiter Limiter
+		if p.redis != nil {
+			limiter = NewSlidingWindow(p.redis, c.Prefix, r.Limit, r.Window)
+		} else {
+			limiter = NewTokenBucket(r.Limit, r.Window, r.Burst)
+		}
+		p.rules = append(p.rules, &rule{Rule: r, limiter: limiter})
+	}
+	return p, nil
+}
+
+// request describes a request to the rules.
+type request struct {
+	method   string // HTTP method, empty for gRPC
+	route    string // route template, request path or full gRPC method
+	ip       string
+	certName string // common name of the verified client certificate
+	value    func(name string) string
+}
+
+// check takes a request from every matching rule. It returns the result of
+// the first rule that rejects the request with ErrRateLimited, or else the
+// result with the fewest remaining requests. Rules whose limiter fails let
+// the request through.
+func (p *Policy) check(ctx context.Context, req request) (Result, error) {
+	var res Result
+	for _, r := range p.rules {
+		if !r.matches(req) {
+			continue
+		}
+		rr, err := r.limiter.Allow(ctx, r.Name+":"+p.key(ctx, r, req))
+		if err != nil {
+			xlog.Get(ctx).Warn("rate limit check failed, allowing request", "rule", r.Name, "error", err)
+			continue
+		}
+		if !rr.Allowed {
+			return rr, ErrRateLimited.
+				WithMetadata("rule", r.Name).
+				WithMetadata("retry_after", rr.RetryAfter.Round(time.Millisecond).String())
+		}
+		if res.Limit == 0 || rr.Remaining < res.Remaining {
+			res = rr
+		}
+	}
+	return res, nil
+}
+
+// key returns the key the rule counts req by.
+func (p *Policy) key(ctx context.Context, r *rule, req request) string {
+	switch {
+	case r.Key == KeyPrincipal:
+		principal := req.certName
+		if p.principal != nil {
+			principal = p.principal(ctx)
+		}
+		if principal != "" {
+			return "principal=" + principal
+		}
+	case strings.HasPrefix(r.Key, keyHeaderPrefix), strings.HasPrefix(r.Key, keyMetadataPrefix):
+		_, name, _ := strings.Cut(r.Key, ":")
+		if v := req.value(name); v != "" {
+			return strings.ToLower(name) + "=" + v
+		}
+	}
+	return "ip=" + req.ip
+}
+
+func (r *rule) matches(req request) bool {
+	return len(r.Match) == 0 || route.MatchAny(r.Match, req.method, req.route)
+}
+
+func tlsCommonName(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.CommonName
+}
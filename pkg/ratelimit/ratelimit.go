@@ -0,0 +1,115 @@
+// Package ratelimit limits the rate of HTTP requests and gRPC calls.
+//
+// A Policy holds rules that match routes or methods and key requests by
+// client IP, a header or metadata value, or the authenticated principal. Each
+// rule has its own Limiter: an in-memory token bucket, or a Redis sliding
+// window shared by all replicas. Policies are applied as Gin middleware and as
+// gRPC server interceptors, and reject requests with RESOURCE_EXHAUSTED
+// (HTTP 429) and a Retry-After hint.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/errors"
+)
+
+// ErrRateLimited is returned for requests rejected by a rule. errors.Is
+// matches it for every rejected request.
+var ErrRateLimited = errors.New(errors.ResourceExhausted, "rate limit exceeded").WithReason("RATE_LIMITED")
+
+// Limiter decides whether requests identified by a key may proceed.
+type Limiter interface {
+	// Allow takes one request for key and reports whether it is allowed.
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// Result is the outcome of Limiter.Allow.
+type Result struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+
+	// Limit is the number of requests allowed per window.
+	Limit int
+
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+
+	// RetryAfter is how long to wait before the next request is allowed.
+	// It is zero for allowed requests.
+	RetryAfter time.Duration
+}
+
+// TokenBucket is an in-memory Limiter. Each key has a bucket of burst tokens
+// that refills at limit tokens per window; a request takes one token.
+type TokenBucket struct {
+	limit int
+	rate  float64 // tokens per second
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a TokenBucket allowing limit requests per window
+// with bursts of up to burst requests. burst defaults to limit.
+func NewTokenBucket(limit int, window time.Duration, burst int) *TokenBucket {
+	if burst <= 0 {
+		burst = limit
+	}
+	return &TokenBucket{
+		limit:   limit,
+		rate:    float64(limit) / window.Seconds(),
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow implements Limiter.
+func (b *TokenBucket) Allow(_ context.Context, key string) (Result, error) {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sweep(now)
+	bk, ok := b.buckets[key]
+	if !ok {
+		bk = &bucket{tokens: b.burst, last: now}
+		b.buckets[key] = bk
+	}
+	bk.tokens = min(b.burst, bk.tokens+now.Sub(bk.last).Seconds()*b.rate)
+	bk.last = now
+
+	if bk.tokens < 1 {
+		wait := time.Duration((1 - bk.tokens) / b.rate * float64(time.Second))
+		return Result{Limit: b.limit, RetryAfter: wait}, nil
+	}
+	bk.tokens--
+	return Result{Allowed: true, Limit: b.limit, Remaining: int(math.Floor(bk.tokens))}, nil
+}
+
+// sweep drops the buckets that have refilled completely, at most once per
+// refill period, so that idle keys do not accumulate.
+func (b *TokenBucket) sweep(now time.Time) {
+	full := time.Duration(b.burst / b.rate * float64(time.Second))
+	if now.Sub(b.lastSweep) < full {
+		return
+	}
+	b.lastSweep = now
+	for key, bk := range b.buckets {
+		if now.Sub(bk.last) >= full {
+			delete(b.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/api/middleware"
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"github.com/HorseArcher567/octopus/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func allow(t *testing.T, l Limiter, key string) Result {
+	t.Helper()
+	res, err := l.Allow(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Allow(%q) error = %v", key, err)
+	}
+	return res
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	b := NewTokenBucket(2, time.Second, 0)
+	b.now = clock.now
+
+	if res := allow(t, b, "a"); !res.Allowed || res.Remaining != 1 || res.Limit != 2 {
+		t.Fatalf("first Allow() = %+v", res)
+	}
+	allow(t, b, "a")
+	res := allow(t, b, "a")
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("third Allow() = %+v, want rejected with 500ms retry", res)
+	}
+	if res := allow(t, b, "b"); !res.Allowed {
+		t.Fatalf("Allow(b) = %+v, keys must be independent", res)
+	}
+
+	clock.advance(500 * time.Millisecond)
+	if res := allow(t, b, "a"); !res.Allowed {
+		t.Fatalf("Allow() after refill = %+v", res)
+	}
+
+	// Idle buckets that refilled are dropped.
+	clock.advance(time.Minute)
+	allow(t, b, "c")
+	if len(b.buckets) != 1 {
+		t.Fatalf("buckets = %d after sweep, want 1", len(b.buckets))
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := redis.New(&redis.Config{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("redis.New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	w := NewSlidingWindow(client, "", 2, time.Second)
+	w.now = clock.now
+
+	if res := allow(t, w, "a"); !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("first Allow() = %+v", res)
+	}
+	clock.advance(400 * time.Millisecond)
+	allow(t, w, "a")
+	res := allow(t, w, "a")
+	if res.Allowed || res.RetryAfter != 600*time.Millisecond {
+		t.Fatalf("third Allow() = %+v, want rejected with 600ms retry", res)
+	}
+	if !mr.Exists(DefaultPrefix + "a") {
+		t.Fatalf("key %q not stored", DefaultPrefix+"a")
+	}
+
+	// The first request leaves the window, the second is still in it.
+	clock.advance(601 * time.Millisecond)
+	if res := allow(t, w, "a"); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("Allow() after slide = %+v", res)
+	}
+	if res := allow(t, w, "a"); res.Allowed {
+		t.Fatalf("Allow() = %+v, want rejected", res)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"missing name", Config{Rules: []Rule{{Limit: 1}}}, "name is required"},
+		{"duplicate", Config{Rules: []Rule{{Name: "a", Limit: 1}, {Name: "a", Limit: 1}}}, "duplicate rule"},
+		{"limit", Config{Rules: []Rule{{Name: "a"}}}, "limit must be positive"},
+		{"key", Config{Rules: []Rule{{Name: "a", Limit: 1, Key: "cookie:x"}}}, "key must be"},
+		{"empty header", Config{Rules: []Rule{{Name: "a", Limit: 1, Key: "header:"}}}, "key must be"},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: Validate() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	ok := Config{Rules: []Rule{{Name: "a", Limit: 1, Key: "header:X-Api-Key"}}}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestPolicyHTTPMiddleware(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	policy, err := New(&Config{Rules: []Rule{
+		{Name: "login", Match: []string{"POST /login"}, Limit: 1, Window: time.Minute},
+		{Name: "users", Match: []string{"/users/*"}, Key: "header:X-Api-Key", Limit: 1, Window: time.Minute},
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	engine := gin.New()
+	engine.Use(middleware.Errors(), policy.HTTPMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	engine.POST("/login", ok)
+	engine.GET("/login", ok)
+	engine.GET("/users/:id", ok)
+
+	do := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-Api-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/login", ""); w.Code != http.StatusNoContent || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("first login = %d %v", w.Code, w.Header())
+	}
+	w := do(http.MethodPost, "/login", "")
+	var body middleware.ErrorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" || body.Code != errors.ResourceExhausted || body.Metadata["rule"] != "login" {
+		t.Fatalf("second login = %d %v %+v", w.Code, w.Header(), body)
+	}
+	if w := do(http.MethodGet, "/login", ""); w.Code != http.StatusNoContent || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Fatalf("unmatched method = %d %v", w.Code, w.Header())
+	}
+
+	// Requests are counted per API key.
+	if w := do(http.MethodGet, "/users/1", "k1"); w.Code != http.StatusNoContent {
+		t.Fatalf("k1 = %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/users/2", "k2"); w.Code != http.StatusNoContent {
+		t.Fatalf("k2 = %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/users/1", "k1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("k1 again = %d", w.Code)
+	}
+}
+
+type headerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *headerStream) Context() context.Context { return s.ctx }
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestPolicyGRPCInterceptors(t *testing.T) {
+	policy, err := New(&Config{Rules: []Rule{
+		{Name: "user", Match: []string{"/multi.User/*"}, Key: KeyPrincipal, Limit: 1, Window: time.Minute},
+	}}, WithPrincipal(func(ctx context.Context) string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get("user"); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	unary := policy.UnaryServerInterceptor()
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+	call := func(method, user string) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}})
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("user", user))
+		_, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	if err := call("/multi.User/GetUser", "alice"); err != nil {
+		t.Fatalf("first call error = %v", err)
+	}
+	if err := call("/multi.User/CreateUser", "alice"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second call error = %v, want ErrRateLimited", err)
+	}
+	if err := call("/multi.User/GetUser", "bob"); err != nil {
+		t.Fatalf("other principal error = %v", err)
+	}
+	if err := call("/multi.Order/GetOrder", "alice"); err != nil {
+		t.Fatalf("unmatched method error = %v", err)
+	}
+
+	stream := policy.StreamServerInterceptor()
+	ss := &headerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("user", "alice"))}
+	err = stream(nil, ss, &grpc.StreamServerInfo{FullMethod: "/multi.User/Watch"}, func(any, grpc.ServerStream) error { return nil })
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("stream error = %v, want ErrRateLimited", err)
+	}
+	if got := ss.header.Get("retry-after"); len(got) != 1 || got[0] != "60" {
+		t.Fatalf("retry-after header = %v", got)
+	}
+}
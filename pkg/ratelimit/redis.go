@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// DefaultPrefix is the default prefix of the Redis keys of SlidingWindow.
+const DefaultPrefix = "octopus:ratelimit:"
+
+// slidingWindowScript keeps the timestamps of the requests of the last window
+// in a sorted set. It returns {allowed, remaining, retry after in ms}.
+var slidingWindowScript = goredis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("zremrangebyscore", KEYS[1], "-inf", now - window)
+local count = redis.call("zcard", KEYS[1])
+if count < limit then
+	redis.call("zadd", KEYS[1], now, ARGV[4])
+	redis.call("pexpire", KEYS[1], window)
+	return {1, limit - count - 1, 0}
+end
+local oldest = redis.call("zrange", KEYS[1], 0, 0, "withscores")
+return {0, 0, tonumber(oldest[2]) + window - now}`)
+
+// SlidingWindow is a Limiter backed by Redis, so that replicas share the
+// counts. It allows limit requests in any window-long interval.
+type SlidingWindow struct {
+	client *redis.Client
+	prefix string
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+// NewSlidingWindow returns a SlidingWindow allowing limit requests per window.
+// Keys are stored under prefix, DefaultPrefix if empty.
+func NewSlidingWindow(client *redis.Client, prefix string, limit int, window time.Duration) *SlidingWindow {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &SlidingWindow{client: client, prefix: prefix, limit: limit, window: window, now: time.Now}
+}
+
+// Allow implements Limiter.
+func (w *SlidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	member, err := newMember()
+	if err != nil {
+		return Result{}, err
+	}
+	now := w.now().UnixMilli()
+	res, err := slidingWindowScript.Run(ctx, w.client, []string{w.prefix + key},
+		now, w.window.Milliseconds(), w.limit, member).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: %q: %w", key, err)
+	}
+	if len(res) != 3 {
+		return Result{}, fmt.Errorf("ratelimit: %q: unexpected script result %v", key, res)
+	}
+	return Result{
+		Allowed:    res[0] == 1,
+		Limit:      w.limit,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+// newMember returns a unique sorted set member for a request.
+func newMember() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ratelimit: generate member: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/HorseArcher567/octopus/pkg/redis"
+
+// EnableTracing records a client span for every command and pipeline sent
+// through c.
+func (c *Client) EnableTracing(tp trace.TracerProvider) {
+	c.AddHook(&tracingHook{tracer: tp.Tracer(tracerName), addr: c.Options().Addr})
+}
+
+type tracingHook struct {
+	tracer trace.Tracer
+	addr   string
+}
+
+func (h *tracingHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+func (h *tracingHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		ctx, span := h.start(ctx, cmd.FullName(), attribute.String("db.operation.name", cmd.FullName()))
+		err := next(ctx, cmd)
+		h.end(span, err)
+		return err
+	}
+}
+
+func (h *tracingHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		names := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			names = append(names, cmd.FullName())
+		}
+		ctx, span := h.start(ctx, "pipeline",
+			attribute.String("db.operation.name", "pipeline "+strings.Join(names, " ")),
+			attribute.Int("db.operation.batch.size", len(cmds)),
+		)
+		err := next(ctx, cmds)
+		h.end(span, err)
+		return err
+	}
+}
+
+func (h *tracingHook) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		attribute.String("db.system.name", "redis"),
+		attribute.String("server.address", h.addr),
+	)
+	return h.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func (h *tracingHook) end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClientTracing(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := New(&Config{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer client.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	client.EnableTracing(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := context.Background()
+	if err := client.Set(ctx, "k", "v", 0).Err(); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := client.Pipelined(ctx, func(p goredis.Pipeliner) error {
+		p.Get(ctx, "k")
+		p.Get(ctx, "missing")
+		return nil
+	}); err == nil {
+		t.Fatalf("Pipelined() expected redis.Nil")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	if spans[0].Name() != "set" || spans[1].Name() != "pipeline" {
+		t.Fatalf("span names = %q, %q", spans[0].Name(), spans[1].Name())
+	}
+	if spans[1].Status().Code != 0 {
+		t.Fatalf("pipeline span status = %v, want unset for redis.Nil", spans[1].Status())
+	}
+}
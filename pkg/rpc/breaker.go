@@ -0,0 +1,187 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/internal/circuit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is the status message of calls rejected by an open circuit
+// breaker. The calls fail with codes.Unavailable.
+var ErrCircuitOpen = errors.New("rpc: circuit breaker is open")
+
+// CircuitBreakerConfig configures a client circuit breaker. Each method has
+// its own breaker.
+//
+// The breaker opens when, within Window, at least MinRequests calls were made
+// and the ratio of failures reaches ErrorRatio. While open, calls fail fast
+// with codes.Unavailable. After Cooldown it half-opens and lets
+// HalfOpenRequests probe calls through: if they all succeed it closes, and
+// any failure opens it again.
+//
+// Example:
+//
+//	circuitBreaker:
+//	  window: 10s
+//	  minRequests: 20
+//	  errorRatio: 0.5
+//	  cooldown: 5s
+type CircuitBreakerConfig struct {
+	// Window is the period over which the error ratio is measured
+	// (default: 10s).
+	Window time.Duration `yaml:"window" json:"window" toml:"window"`
+
+	// MinRequests is the number of calls in a window below which the breaker
+	// never opens (default: 20).
+	MinRequests int `yaml:"minRequests" json:"minRequests" toml:"minRequests"`
+
+	// ErrorRatio is the failure ratio, in (0, 1], that opens the breaker
+	// (default: 0.5).
+	ErrorRatio float64 `yaml:"errorRatio" json:"errorRatio" toml:"errorRatio"`
+
+	// Cooldown is how long the breaker stays open before half-opening
+	// (default: 5s).
+	Cooldown time.Duration `yaml:"cooldown" json:"cooldown" toml:"cooldown"`
+
+	// HalfOpenRequests is the number of probe calls allowed while half-open
+	// (default: 1).
+	HalfOpenRequests int `yaml:"halfOpenRequests" json:"halfOpenRequests" toml:"halfOpenRequests"`
+
+	// FailureCodes are the status codes counted as failures
+	// (default: [UNAVAILABLE, DEADLINE_EXCEEDED, INTERNAL, UNKNOWN, RESOURCE_EXHAUSTED]).
+	FailureCodes []string `yaml:"failureCodes" json:"failureCodes" toml:"failureCodes"`
+}
+
+// Normalize sets default values for the circuit breaker configuration.
+func (c *CircuitBreakerConfig) Normalize() {
+	if c.Window == 0 {
+		c.Window = 10 * time.Second
+	}
+	if c.MinRequests == 0 {
+		c.MinRequests = 20
+	}
+	if c.ErrorRatio == 0 {
+		c.ErrorRatio = 0.5
+	}
+	if c.Cooldown == 0 {
+		c.Cooldown = 5 * time.Second
+	}
+	if c.HalfOpenRequests == 0 {
+		c.HalfOpenRequests = 1
+	}
+	if len(c.FailureCodes) == 0 {
+		c.FailureCodes = []string{"UNAVAILABLE", "DEADLINE_EXCEEDED", "INTERNAL", "UNKNOWN", "RESOURCE_EXHAUSTED"}
+	}
+}
+
+// Validate validates the circuit breaker configuration.
+func (c *CircuitBreakerConfig) Validate() error {
+	if c.Window < 0 || c.Cooldown < 0 {
+		return errors.New("circuit breaker window and cooldown cannot be negative")
+	}
+	if c.MinRequests < 0 || c.HalfOpenRequests < 0 {
+		return errors.New("circuit breaker request counts cannot be negative")
+	}
+	if c.ErrorRatio < 0 || c.ErrorRatio > 1 {
+		return errors.New("circuit breaker errorRatio must be between 0 and 1")
+	}
+	if _, err := parseCodes(c.FailureCodes); err != nil {
+		return fmt.Errorf("circuit breaker failureCodes: %w", err)
+	}
+	return nil
+}
+
+// isFailure returns the classifier of a method breaker counting the status
+// codes in failures.
+func isFailure(failures map[codes.Code]struct{}) func(context.Context, error) bool {
+	return func(_ context.Context, err error) bool {
+		if err == nil {
+			return false
+		}
+		_, ok := failures[status.Code(err)]
+		return ok
+	}
+}
+
+// breakers holds the per-method circuit breakers of a connection.
+type breakers struct {
+	defaults *CircuitBreakerConfig
+	policies methodPolicies
+	now      func() time.Time
+
+	mu     sync.Mutex
+	byName map[string]*circuit.Breaker[error]
+}
+
+func newBreakers(defaults *CircuitBreakerConfig, policies methodPolicies) *breakers {
+	return &breakers{defaults: defaults, policies: policies, now: time.Now, byName: make(map[string]*circuit.Breaker[error])}
+}
+
+// get returns the breaker of method, or nil if no breaker applies.
+func (bs *breakers) get(method string) *circuit.Breaker[error] {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if b, ok := bs.byName[method]; ok {
+		return b
+	}
+	cfg := bs.defaults
+	if p := bs.policies.lookup(method); p != nil && p.CircuitBreaker != nil {
+		cfg = p.CircuitBreaker
+	}
+	var b *circuit.Breaker[error]
+	if cfg != nil {
+		failures, _ := parseCodes(cfg.FailureCodes)
+		b = circuit.New(circuit.Config{
+			Window:           cfg.Window,
+			MinRequests:      cfg.MinRequests,
+			ErrorRatio:       cfg.ErrorRatio,
+			Cooldown:         cfg.Cooldown,
+			HalfOpenRequests: cfg.HalfOpenRequests,
+			Kind:             "rpc",
+			Attrs:            []any{"method", method},
+			Now:              bs.now,
+		}, isFailure(failures))
+	}
+	bs.byName[method] = b
+	return b
+}
+
+func (bs *breakers) unary() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		b := bs.get(method)
+		if b == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		state, ok := b.Allow(ctx)
+		if !ok {
+			return status.Error(codes.Unavailable, ErrCircuitOpen.Error())
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.Record(ctx, state, err)
+		return err
+	}
+}
+
+func (bs *breakers) stream() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		b := bs.get(method)
+		if b == nil {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		state, ok := b.Allow(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unavailable, ErrCircuitOpen.Error())
+		}
+		// Only stream creation is judged; the stream's own outcome is not.
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		b.Record(ctx, state, err)
+		return cs, err
+	}
+}
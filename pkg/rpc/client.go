@@ -3,6 +3,9 @@ package rpc
 import (
 	"fmt"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 )
 
@@ -15,6 +18,17 @@ func NewClient(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error)
 	return conn, nil
 }
 
+// NewClientFromConfig creates the gRPC client connection described by cfg.
+// Like NewClient it does not connect until the first RPC. Additional dial
+// options are applied after the configured ones.
+func NewClientFromConfig(cfg *ClientConfig, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	dialOpts, err := cfg.BuildDialOptions()
+	if err != nil {
+		return nil, fmt.Errorf("client %s: %w", cfg.Name, err)
+	}
+	return NewClient(cfg.Target, append(dialOpts, opts...)...)
+}
+
 // MustNewClient panics when NewClient returns an error.
 func MustNewClient(target string, opts ...grpc.DialOption) *grpc.ClientConn {
 	conn, err := NewClient(target, opts...)
@@ -23,3 +37,12 @@ func MustNewClient(target string, opts ...grpc.DialOption) *grpc.ClientConn {
 	}
 	return conn
 }
+
+// WithClientTracing returns a dial option that starts a client span for every
+// RPC and propagates it to the server in the outgoing metadata.
+func WithClientTracing(tp trace.TracerProvider, propagator propagation.TextMapPropagator) grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+		otelgrpc.WithTracerProvider(tp),
+		otelgrpc.WithPropagators(propagator),
+	))
+}
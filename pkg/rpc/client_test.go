@@ -1,5 +1,78 @@
 package rpc
 
-import "testing"
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
 
 func TestClientPackageBuilds(t *testing.T) {}
+
+func TestClientConfigValidate(t *testing.T) {
+	tests := []ClientConfig{
+		{Target: "direct:///a:1"},
+		{Name: "users"},
+		{Name: "users", Target: "direct:///a:1", Timeout: -time.Second},
+		{Name: "users", Target: "direct:///a:1", Interceptors: []string{"missing"}},
+	}
+	for _, cfg := range tests {
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) expected error", cfg)
+		}
+	}
+	if err := RegisterClientInterceptor("logging", ClientInterceptor{Unary: nil, Stream: nil}); err == nil {
+		t.Fatalf("RegisterClientInterceptor() expected error without interceptors")
+	}
+}
+
+func TestNewClientFromConfigAppliesTimeoutAndInterceptors(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	deadlines := make(chan bool, 1)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		_, ok := ctx.Deadline()
+		deadlines <- ok
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	var intercepted []string
+	if err := RegisterClientInterceptor("test-recorder", ClientInterceptor{
+		Unary: func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			intercepted = append(intercepted, method)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		},
+	}); err != nil {
+		t.Fatalf("RegisterClientInterceptor() error = %v", err)
+	}
+
+	conn, err := NewClientFromConfig(&ClientConfig{
+		Name:         "health",
+		Target:       "passthrough:///" + lis.Addr().String(),
+		Timeout:      time.Second,
+		Interceptors: []string{"logging", "test-recorder"},
+	})
+	if err != nil {
+		t.Fatalf("NewClientFromConfig() error = %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !<-deadlines {
+		t.Fatalf("server saw no deadline, want the configured timeout")
+	}
+	if len(intercepted) != 1 || intercepted[0] != healthpb.Health_Check_FullMethodName {
+		t.Fatalf("intercepted = %v", intercepted)
+	}
+}
@@ -3,9 +3,16 @@ package rpc
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/HorseArcher567/octopus/pkg/auth"
+	"github.com/HorseArcher567/octopus/pkg/authz"
+	"github.com/HorseArcher567/octopus/pkg/loadshed"
+	"github.com/HorseArcher567/octopus/pkg/ratelimit"
+	"github.com/HorseArcher567/octopus/pkg/rpc/middleware"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 )
@@ -79,6 +86,18 @@ type ClientOptions struct {
 	// Keepalive is the keepalive configuration for the client.
 	// If nil, keepalive will not be enabled.
 	Keepalive *ClientKeepalive `yaml:"keepalive" json:"keepalive" toml:"keepalive"`
+
+	// TLS enables transport security, and mutual TLS when a client
+	// certificate is configured. If nil, the connection is insecure.
+	TLS *TLSConfig `yaml:"tls" json:"tls" toml:"tls"`
+
+	// Methods declares per-service and per-method deadlines, retry and
+	// hedging policies, and circuit breaker overrides.
+	Methods []MethodPolicy `yaml:"methods" json:"methods" toml:"methods"`
+
+	// CircuitBreaker enables a circuit breaker for every method.
+	// If nil, only methods with their own circuit breaker are protected.
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuitBreaker" json:"circuitBreaker" toml:"circuitBreaker"`
 }
 
 // Normalize sets default values for the client configuration.
@@ -92,26 +111,79 @@ func (c *ClientOptions) Normalize() {
 	if c.Keepalive != nil {
 		c.Keepalive.Normalize()
 	}
+
+	for i := range c.Methods {
+		c.Methods[i].Normalize()
+	}
+	if c.CircuitBreaker != nil {
+		c.CircuitBreaker.Normalize()
+	}
+}
+
+// Validate validates the client configuration.
+func (c *ClientOptions) Validate() error {
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]struct{}, len(c.Methods))
+	for i := range c.Methods {
+		p := &c.Methods[i]
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("methods[%d]: %w", i, err)
+		}
+		if _, ok := seen[p.name()]; ok {
+			return fmt.Errorf("methods[%d]: duplicate policy for %q", i, p.name())
+		}
+		seen[p.name()] = struct{}{}
+	}
+
+	if c.CircuitBreaker != nil {
+		if err := c.CircuitBreaker.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // BuildDialOptions builds gRPC dial options from the client configuration.
 //
-// It normalizes the configuration first, then constructs dial options for:
-//   - Transport credentials (always insecure)
-//   - Load balancing policy
+// It normalizes and validates the configuration first, then constructs dial
+// options for:
+//   - Transport credentials (TLS if configured, insecure otherwise)
+//   - Load balancing policy, method deadlines and retry policies (as the
+//     default service config)
 //   - Keepalive parameters (if enabled)
+//   - Circuit breaker and hedging interceptors (if configured)
 //
 // The returned options can be used directly with grpc.NewClient.
-func (c *ClientOptions) BuildDialOptions() []grpc.DialOption {
+func (c *ClientOptions) BuildDialOptions() ([]grpc.DialOption, error) {
 	c.Normalize()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
 
 	opts := []grpc.DialOption{}
 
-	// Set transport credentials to insecure by default.
-	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// Set transport credentials, insecure unless TLS is configured.
+	if c.TLS != nil {
+		creds, err := clientCredentials(c.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
 
-	// Set load balancing policy.
-	serviceConfig := fmt.Sprintf(`{"loadBalancingPolicy":"%s"}`, c.LoadBalancingPolicy)
+	// Set load balancing policy, method deadlines and retry policies.
+	serviceConfig, err := buildServiceConfig(c.LoadBalancingPolicy, c.Methods)
+	if err != nil {
+		return nil, err
+	}
 	opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig))
 
 	// Configure keepalive if configured.
@@ -125,7 +197,137 @@ func (c *ClientOptions) BuildDialOptions() []grpc.DialOption {
 		opts = append(opts, grpc.WithKeepaliveParams(kaParams))
 	}
 
-	return opts
+	// The breaker wraps hedging so that a hedged call counts once, and both
+	// sit above gRPC retries so that they see the final outcome.
+	policies := newMethodPolicies(slices.Clone(c.Methods))
+	breakerEnabled := c.CircuitBreaker != nil
+	hedgingEnabled := false
+	for _, p := range c.Methods {
+		breakerEnabled = breakerEnabled || p.CircuitBreaker != nil
+		hedgingEnabled = hedgingEnabled || p.Hedging != nil
+	}
+	if breakerEnabled {
+		bs := newBreakers(c.CircuitBreaker, policies)
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(bs.unary()),
+			grpc.WithChainStreamInterceptor(bs.stream()),
+		)
+	}
+	if hedgingEnabled {
+		h := &hedging{policies: policies}
+		opts = append(opts, grpc.WithChainUnaryInterceptor(h.unary()))
+	}
+
+	return opts, nil
+}
+
+// ClientConfig declares a named gRPC client connection.
+//
+// Example:
+//
+//	rpcClients:
+//	  - name: users
+//	    target: etcd:///user-service
+//	    timeout: 3s
+//	    interceptors: [logging]
+type ClientConfig struct {
+	// Name identifies the connection, e.g. the key it is published under.
+	Name string `yaml:"name" json:"name" toml:"name"`
+
+	// Target is the dial target, e.g. etcd:///svc or direct:///a:9001,b:9001.
+	Target string `yaml:"target" json:"target" toml:"target"`
+
+	// ClientOptions configures transport, load balancing and keepalive.
+	ClientOptions `yaml:",inline" json:",inline" toml:",inline"`
+
+	// Timeout is the deadline applied to unary calls whose context has none.
+	// Zero means no default deadline.
+	Timeout time.Duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+
+	// ConnectTimeout is the minimum time given to establish a connection.
+	// Zero means gRPC default (20 seconds).
+	ConnectTimeout time.Duration `yaml:"connectTimeout" json:"connectTimeout" toml:"connectTimeout"`
+
+	// Interceptors lists client interceptors by name, in call order.
+	// Built-in: "logging"; "errors", which decodes returned statuses into
+	// *errors.Error; and "propagation", which forwards the request values
+	// captured by a propagation.Propagator. More can be added with
+	// RegisterClientInterceptor.
+	Interceptors []string `yaml:"interceptors" json:"interceptors" toml:"interceptors"`
+}
+
+// Validate validates the client configuration.
+func (c *ClientConfig) Validate() error {
+	if c.Name == "" {
+		return errors.New("client name is required")
+	}
+
+	if c.Target == "" {
+		return errors.New("client target is required")
+	}
+
+	if c.Timeout < 0 || c.ConnectTimeout < 0 {
+		return errors.New("client timeouts cannot be negative")
+	}
+
+	for _, name := range c.Interceptors {
+		if _, ok := lookupClientInterceptor(name); !ok {
+			return fmt.Errorf("unknown client interceptor %q", name)
+		}
+	}
+
+	return c.ClientOptions.Validate()
+}
+
+// BuildDialOptions builds gRPC dial options from the client configuration.
+//
+// In addition to the ClientOptions dial options, it configures:
+//   - The minimum connect timeout (if set)
+//   - The default unary call timeout (if set), before the ClientOptions
+//     interceptors so that it bounds the whole call, hedged attempts included
+//   - The named interceptors, after the ClientOptions interceptors
+func (c *ClientConfig) BuildDialOptions() ([]grpc.DialOption, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var opts []grpc.DialOption
+	if c.Timeout > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(middleware.UnaryClientTimeout(c.Timeout)))
+	}
+
+	clientOpts, err := c.ClientOptions.BuildDialOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, clientOpts...)
+
+	if c.ConnectTimeout > 0 {
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: c.ConnectTimeout,
+		}))
+	}
+
+	var unary []grpc.UnaryClientInterceptor
+	var stream []grpc.StreamClientInterceptor
+	for _, name := range c.Interceptors {
+		ci, _ := lookupClientInterceptor(name)
+		if ci.Unary != nil {
+			unary = append(unary, ci.Unary)
+		}
+		if ci.Stream != nil {
+			stream = append(stream, ci.Stream)
+		}
+	}
+	if len(unary) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(unary...))
+	}
+	if len(stream) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(stream...))
+	}
+
+	return opts, nil
 }
 
 // ServerParameters is the server keepalive parameters configuration.
@@ -275,6 +477,22 @@ type ServerAdvertiseConfig struct {
 
 	// Etcd is the name of the etcd client used for service registration.
 	Etcd string `yaml:"etcd" json:"etcd" toml:"etcd"`
+
+	// ID identifies the instance. Defaults to address:port.
+	ID string `yaml:"id" json:"id" toml:"id"`
+
+	// Version is the version of the service the instance runs.
+	Version string `yaml:"version" json:"version" toml:"version"`
+
+	// Zone is the availability zone or region the instance runs in.
+	Zone string `yaml:"zone" json:"zone" toml:"zone"`
+
+	// Weight is the relative share of traffic the instance should receive.
+	// Zero means discovery.DefaultWeight.
+	Weight int `yaml:"weight" json:"weight" toml:"weight"`
+
+	// Labels are arbitrary key/value pairs published with the instance.
+	Labels map[string]string `yaml:"labels" json:"labels" toml:"labels"`
 }
 
 // ServerConfig is the configuration for the RPC server.
@@ -303,6 +521,59 @@ type ServerConfig struct {
 	// Keepalive is the keepalive configuration for the server.
 	// If nil, gRPC defaults will be used.
 	Keepalive *ServerKeepalive `yaml:"keepalive" json:"keepalive" toml:"keepalive"`
+
+	// TLS enables transport security. Set CAFile to verify client
+	// certificates (mutual TLS). If nil, the server accepts plaintext.
+	TLS *TLSConfig `yaml:"tls" json:"tls" toml:"tls"`
+
+	// Interceptors toggles the built-in server interceptors.
+	Interceptors ServerInterceptors `yaml:"interceptors" json:"interceptors" toml:"interceptors"`
+
+	// Auth authenticates calls with JWTs, API keys or client certificates
+	// and puts the principal in the call context. If nil, calls are not
+	// authenticated.
+	Auth *auth.Config `yaml:"auth" json:"auth" toml:"auth"`
+
+	// Authz authorizes calls by method, roles and request attributes,
+	// including fields of the request message. Denied calls fail with
+	// PermissionDenied. Health checks are exempt. If nil, calls are not
+	// authorized.
+	Authz *authz.Config `yaml:"authz" json:"authz" toml:"authz"`
+
+	// RateLimit limits the rate of calls per method and client. Rules match
+	// full method names. If nil, calls are not limited.
+	RateLimit *ratelimit.Config `yaml:"rateLimit" json:"rateLimit" toml:"rateLimit"`
+
+	// LoadShed rejects the calls beyond an adaptive concurrency limit.
+	// Health checks and reflection are never shed. If nil, calls are not
+	// shed.
+	LoadShed *loadshed.Config `yaml:"loadShed" json:"loadShed" toml:"loadShed"`
+}
+
+// ServerInterceptors toggles the built-in server interceptors. Panic
+// recovery, request IDs, error conversion and request validation are enabled
+// by default.
+type ServerInterceptors struct {
+	// DisableRecovery lets handler panics crash the process instead of
+	// failing the call with codes.Internal.
+	DisableRecovery bool `yaml:"disableRecovery" json:"disableRecovery" toml:"disableRecovery"`
+
+	// DisableRequestID stops reading, generating and returning the
+	// x-request-id metadata.
+	DisableRequestID bool `yaml:"disableRequestID" json:"disableRequestID" toml:"disableRequestID"`
+
+	// DisableErrors stops converting handler errors with
+	// errors.ToStatus: *errors.Error values are no longer sent with their
+	// details and other errors reach gRPC as they are.
+	DisableErrors bool `yaml:"disableErrors" json:"disableErrors" toml:"disableErrors"`
+
+	// DisableValidation stops calling Validate() on request messages that
+	// implement it.
+	DisableValidation bool `yaml:"disableValidation" json:"disableValidation" toml:"disableValidation"`
+
+	// MaxTimeout caps the deadline of every call: calls without a deadline,
+	// or with a later one, get MaxTimeout. Zero means no cap.
+	MaxTimeout time.Duration `yaml:"maxTimeout" json:"maxTimeout" toml:"maxTimeout"`
 }
 
 // Validate validates the server configuration.
@@ -326,6 +597,48 @@ func (c *ServerConfig) Validate() error {
 		if c.Advertise.Etcd == "" {
 			return errors.New("server advertise etcd is required")
 		}
+		if c.Advertise.Weight < 0 {
+			return errors.New("server advertise weight cannot be negative")
+		}
+		for k := range c.Advertise.Labels {
+			if k == "" {
+				return errors.New("server advertise label key cannot be empty")
+			}
+		}
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Interceptors.MaxTimeout < 0 {
+		return errors.New("server interceptors maxTimeout cannot be negative")
+	}
+
+	if c.Auth != nil {
+		if err := c.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Authz != nil {
+		if err := c.Authz.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.LoadShed != nil {
+		if err := c.LoadShed.Validate(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// hedging sends hedged attempts for the unary methods with a HedgingPolicy.
+type hedging struct {
+	policies methodPolicies
+}
+
+type hedgeResult struct {
+	reply proto.Message
+	err   error
+}
+
+func (h *hedging) unary() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p := h.policies.lookup(method)
+		msg, ok := reply.(proto.Message)
+		if p == nil || p.Hedging == nil || !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		nonFatal, _ := parseCodes(p.Hedging.NonFatalCodes)
+
+		// Cancelling ctx on return stops the attempts that lost the race.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan hedgeResult, p.Hedging.MaxAttempts)
+		launched, inflight := 0, 0
+		launch := func() {
+			attempt := msg.ProtoReflect().New().Interface()
+			launched++
+			inflight++
+			go func() {
+				err := invoker(ctx, method, req, attempt, cc, opts...)
+				results <- hedgeResult{reply: attempt, err: err}
+			}()
+		}
+
+		launch()
+		for p.Hedging.Delay == 0 && launched < p.Hedging.MaxAttempts {
+			launch()
+		}
+		timer := time.NewTimer(p.Hedging.Delay)
+		defer timer.Stop()
+
+		var lastErr error
+		for inflight > 0 {
+			select {
+			case <-timer.C:
+				if launched < p.Hedging.MaxAttempts {
+					launch()
+					timer.Reset(p.Hedging.Delay)
+				}
+			case res := <-results:
+				inflight--
+				if res.err == nil {
+					proto.Reset(msg)
+					proto.Merge(msg, res.reply)
+					return nil
+				}
+				lastErr = res.err
+				if _, ok := nonFatal[status.Code(res.err)]; !ok {
+					return res.err
+				}
+				// A non-fatal failure sends the next attempt right away.
+				if launched < p.Hedging.MaxAttempts {
+					launch()
+					timer.Reset(p.Hedging.Delay)
+				}
+			}
+		}
+		if lastErr == nil {
+			lastErr = status.Error(codes.Unknown, "rpc: hedged call produced no result")
+		}
+		return lastErr
+	}
+}
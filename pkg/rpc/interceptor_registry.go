@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/HorseArcher567/octopus/pkg/propagation"
+	"github.com/HorseArcher567/octopus/pkg/rpc/middleware"
+	"google.golang.org/grpc"
+)
+
+// ClientInterceptor is a pair of client interceptors selectable by name in
+// ClientConfig.Interceptors. Either may be nil.
+type ClientInterceptor struct {
+	Unary  grpc.UnaryClientInterceptor
+	Stream grpc.StreamClientInterceptor
+}
+
+var clientInterceptorRegistry struct {
+	mu         sync.RWMutex
+	registered map[string]ClientInterceptor
+}
+
+func init() {
+	clientInterceptorRegistry.registered = map[string]ClientInterceptor{
+		"logging": {Unary: middleware.UnaryClientLogging(), Stream: middleware.StreamClientLogging()},
+		"errors":  {Unary: middleware.UnaryClientErrors(), Stream: middleware.StreamClientErrors()},
+		"propagation": {
+			Unary:  propagation.UnaryClientInterceptor(),
+			Stream: propagation.StreamClientInterceptor(),
+		},
+	}
+}
+
+// RegisterClientInterceptor registers client interceptors under name so that
+// configured clients can enable them. It returns an error if the name is
+// already registered.
+func RegisterClientInterceptor(name string, ci ClientInterceptor) error {
+	if name == "" {
+		return fmt.Errorf("rpc: client interceptor name is required")
+	}
+	if ci.Unary == nil && ci.Stream == nil {
+		return fmt.Errorf("rpc: client interceptor %q: unary or stream interceptor is required", name)
+	}
+	clientInterceptorRegistry.mu.Lock()
+	defer clientInterceptorRegistry.mu.Unlock()
+	if _, ok := clientInterceptorRegistry.registered[name]; ok {
+		return fmt.Errorf("rpc: client interceptor %q already registered", name)
+	}
+	clientInterceptorRegistry.registered[name] = ci
+	return nil
+}
+
+func lookupClientInterceptor(name string) (ClientInterceptor, bool) {
+	clientInterceptorRegistry.mu.RLock()
+	defer clientInterceptorRegistry.mu.RUnlock()
+	ci, ok := clientInterceptorRegistry.registered[name]
+	return ci, ok
+}
@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const localBufferSize = 1 << 20
+
+// localServer serves the registered services over an in-memory listener. It
+// is created on the first LocalConn call and started once both LocalConn and
+// Run have been called, so that every service is registered.
+type localServer struct {
+	options     []grpc.ServerOption
+	dialOptions []grpc.DialOption
+
+	mu      sync.Mutex
+	lis     *bufconn.Listener
+	conn    *grpc.ClientConn
+	server  *grpc.Server
+	running bool
+	stopped bool
+}
+
+// LocalConn returns a connection to the server's own services that never
+// leaves the process: calls go through an in-memory pipe rather than a
+// socket, and run the same interceptors and stats handlers as remote calls.
+// The local server has no transport security and no keepalive settings.
+//
+// The connection is shared and closed by Stop. Calls made before Run wait
+// until the server is started.
+func (s *Server) LocalConn() (*grpc.ClientConn, error) {
+	l := &s.local
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		return l.conn, nil
+	}
+
+	lis := bufconn.Listen(localBufferSize)
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, l.dialOptions...)
+	conn, err := grpc.NewClient("passthrough:///"+s.config.Name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	l.lis, l.conn = lis, conn
+	s.startLocalLocked()
+	return conn, nil
+}
+
+// serveLocal is called by Run. It starts the local server if LocalConn was
+// already called.
+func (s *Server) serveLocal() {
+	l := &s.local
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running = true
+	s.startLocalLocked()
+}
+
+func (s *Server) startLocalLocked() {
+	l := &s.local
+	if !l.running || l.lis == nil || l.server != nil || l.stopped {
+		return
+	}
+	l.server = grpc.NewServer(l.options...)
+	for _, svc := range s.services {
+		l.server.RegisterService(svc.desc, svc.impl)
+	}
+	go func(server *grpc.Server, lis net.Listener) {
+		if err := server.Serve(lis); err != nil {
+			s.log.Warn("local rpc server stopped", "error", err)
+		}
+	}(l.server, l.lis)
+}
+
+// stop stops the local server, gracefully or not, and closes the local
+// connection.
+func (l *localServer) stop(graceful bool) {
+	l.mu.Lock()
+	l.stopped = true
+	server, lis, conn := l.server, l.lis, l.conn
+	l.mu.Unlock()
+
+	switch {
+	case server == nil && lis != nil:
+		_ = lis.Close()
+	case server != nil && graceful:
+		server.GracefulStop()
+	case server != nil:
+		server.Stop()
+	}
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
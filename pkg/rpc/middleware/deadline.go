@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryServerMaxTimeout 限制 Unary RPC 的最长处理时间
+// 调用方未设置截止时间或截止时间晚于 max 时，使用 max 作为截止时间
+func UnaryServerMaxTimeout(max time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := capDeadline(ctx, max)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerMaxTimeout 限制 Stream RPC 的最长处理时间，行为同 UnaryServerMaxTimeout
+func StreamServerMaxTimeout(max time.Duration) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := capDeadline(ss.Context(), max)
+		defer cancel()
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func capDeadline(ctx context.Context, max time.Duration) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= max {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, max)
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"io"
+
+	"github.com/HorseArcher567/octopus/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerErrors 将 handler 返回的错误转换为 gRPC status
+// *errors.Error 转换为带 errdetails 的 status，且不包含内部 cause；
+// 已是 status 的错误保持不变；其他错误转换为 codes.Internal，不暴露内部细节
+// 应放在 UnaryServerLogging 之前，以便日志记录原始错误
+func UnaryServerErrors() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, errors.ToStatus(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerErrors 将流式 handler 返回的错误转换为 gRPC status，规则同 UnaryServerErrors
+func StreamServerErrors() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return errors.ToStatus(err).Err()
+		}
+		return nil
+	}
+}
+
+// UnaryClientErrors 将客户端 Unary RPC 返回的 gRPC status 解码为 *errors.Error
+// 解码后的错误仍实现 GRPCStatus，status.Code 等函数照常可用
+func UnaryClientErrors() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return errors.FromError(err)
+		}
+		return nil
+	}
+}
+
+// StreamClientErrors 将客户端流式 RPC 的建流错误和接收错误解码为 *errors.Error
+func StreamClientErrors() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, errors.FromError(err)
+		}
+		return &errorsClientStream{ClientStream: cs}, nil
+	}
+}
+
+type errorsClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *errorsClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return errors.FromError(err)
+}
@@ -195,10 +195,14 @@ func StreamClientLogging() grpc.StreamClientInterceptor {
 	}
 }
 
-// extractRequestID 从 gRPC metadata 中提取 request_id
+// extractRequestID 提取 request_id，优先使用 UnaryRequestID / StreamRequestID
+// 写入 context 的请求 ID，其次读取 gRPC metadata
 func extractRequestID(ctx context.Context) string {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return id
+	}
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if values := md.Get("x-request-id"); len(values) > 0 {
+		if values := md.Get(RequestIDKey); len(values) > 0 {
 			return values[0]
 		}
 	}
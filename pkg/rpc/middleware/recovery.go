@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerRecovery 捕获 Unary RPC handler 中的 panic，记录日志并返回 codes.Internal
+// 应放在 UnaryServerLogging 之后，以便失败请求被正常记录
+func UnaryServerRecovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(ctx, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRecovery 捕获 Stream RPC handler 中的 panic，记录日志并返回 codes.Internal
+func StreamServerRecovery() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(ss.Context(), info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recoverPanic 记录 panic 及调用栈，返回不暴露内部细节的错误
+func recoverPanic(ctx context.Context, method string, r interface{}) error {
+	xlog.Get(ctx).Error("panic recovered in grpc handler",
+		"method", method,
+		"panic", r,
+		"stack", string(debug.Stack()),
+	)
+	return status.Error(codes.Internal, "internal server error")
+}
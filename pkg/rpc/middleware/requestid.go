@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDKey 是携带请求 ID 的 metadata 键
+const RequestIDKey = "x-request-id"
+
+type requestIDKey struct{}
+
+// RequestIDFromContext 返回当前请求的请求 ID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// UnaryRequestID 沿用调用方传入的 x-request-id，缺失时生成新的请求 ID
+// 请求 ID 写入 context，并通过响应 header 返回给调用方
+// 应放在 UnaryServerLogging 之前，以便日志带上 request_id
+func UnaryRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id := requestIDOrNew(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id))
+		return handler(context.WithValue(ctx, requestIDKey{}, id), req)
+	}
+}
+
+// StreamRequestID 为流式请求处理请求 ID，行为同 UnaryRequestID
+func StreamRequestID() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := requestIDOrNew(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(RequestIDKey, id))
+		ctx := context.WithValue(ss.Context(), requestIDKey{}, id)
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func requestIDOrNew(ctx context.Context) string {
+	if id := extractRequestID(ctx); id != "" {
+		return id
+	}
+	return uuid.NewString()
+}
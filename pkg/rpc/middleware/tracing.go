@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/HorseArcher567/octopus/pkg/tracing"
+	"google.golang.org/grpc"
+)
+
+// UnaryTraceLogger 将当前 span 的 trace_id / span_id 注入 context 中的 logger
+// 应放在 UnaryInjectLogger 之后、UnaryServerLogging 之前
+func UnaryTraceLogger() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(tracing.InjectLogger(ctx), req)
+	}
+}
+
+// StreamTraceLogger 将当前 span 的 trace_id / span_id 注入流式请求 context 中的 logger
+// 应放在 StreamInjectLogger 之后、StreamServerLogging 之前
+func StreamTraceLogger() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := tracing.InjectLogger(ss.Context())
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
@@ -2,6 +2,9 @@ package rpc
 
 import (
 	"github.com/HorseArcher567/octopus/pkg/discovery"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/stats"
 )
@@ -30,6 +33,33 @@ func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) Option
 	}
 }
 
+// WithAuth authenticates every call with the given interceptors, such as
+// those of an auth.Chain. They run after error conversion and before the
+// request is logged, so that the request log carries the principal and later
+// interceptors, like rate limits, can use it. Either may be nil.
+func WithAuth(unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor) Option {
+	return func(s *Server) {
+		s.authUnary = unary
+		s.authStream = stream
+	}
+}
+
+// WithAdmission adds interceptors that admit or reject calls before they are
+// authenticated, logged and validated, such as load shedding and rate limits
+// by client IP, so that rejected calls cost little. They run after error
+// conversion, in the order added, and so do observers that must see rejected
+// calls, like metrics. Either may be nil.
+func WithAdmission(unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor) Option {
+	return func(s *Server) {
+		if unary != nil {
+			s.admissionUnary = append(s.admissionUnary, unary)
+		}
+		if stream != nil {
+			s.admissionStream = append(s.admissionStream, stream)
+		}
+	}
+}
+
 // WithServerOptions configures the underlying grpc.Server with non-interceptor server options.
 func WithServerOptions(opts ...grpc.ServerOption) Option {
 	return func(s *Server) {
@@ -43,3 +73,17 @@ func WithStatsHandlers(handlers ...stats.Handler) Option {
 		s.statsHandlers = append(s.statsHandlers, handlers...)
 	}
 }
+
+// WithTracing starts a server span for every RPC, continuing the trace carried
+// in the incoming metadata, and adds the trace and span IDs to the request
+// logger. Calls through LocalConn start a client span as well.
+func WithTracing(tp trace.TracerProvider, propagator propagation.TextMapPropagator) Option {
+	return func(s *Server) {
+		s.tracing = true
+		s.statsHandlers = append(s.statsHandlers, otelgrpc.NewServerHandler(
+			otelgrpc.WithTracerProvider(tp),
+			otelgrpc.WithPropagators(propagator),
+		))
+		s.local.dialOptions = append(s.local.dialOptions, WithClientTracing(tp, propagator))
+	}
+}
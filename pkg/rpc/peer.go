@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// PeerIdentity is the identity a client proved with its TLS certificate.
+type PeerIdentity struct {
+	// CommonName is the subject common name of the certificate.
+	CommonName string
+
+	// DNSNames and URIs are the subject alternative names, e.g. SPIFFE IDs.
+	DNSNames []string
+	URIs     []*url.URL
+
+	// Certificate is the verified leaf certificate.
+	Certificate *x509.Certificate
+}
+
+// PeerIdentityFromContext returns the identity of the client of the RPC in
+// ctx. It returns false unless the client presented a certificate that the
+// server verified.
+func PeerIdentityFromContext(ctx context.Context) (*PeerIdentity, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	cert := info.State.VerifiedChains[0][0]
+	return &PeerIdentity{
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		URIs:        cert.URIs,
+		Certificate: cert,
+	}, true
+}
@@ -0,0 +1,309 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// MethodPolicy declares the call policy of a service or of a single method.
+//
+// A policy with only Service set applies to every method of that service, and
+// a policy with neither set applies to every method of the connection. The
+// most specific policy wins, as in the gRPC service config.
+//
+// Example:
+//
+//	methods:
+//	  - service: user.v1.UserService
+//	    timeout: 2s
+//	    retry:
+//	      maxAttempts: 3
+//	      retryableCodes: [UNAVAILABLE]
+//	  - service: user.v1.UserService
+//	    method: Search
+//	    hedging:
+//	      maxAttempts: 2
+//	      delay: 50ms
+type MethodPolicy struct {
+	// Service is the fully-qualified service name, e.g. user.v1.UserService.
+	Service string `yaml:"service" json:"service" toml:"service"`
+
+	// Method is the method name within Service. Empty means every method.
+	Method string `yaml:"method" json:"method" toml:"method"`
+
+	// Timeout is the deadline of each call, including all retry and hedging
+	// attempts. Zero means no deadline.
+	Timeout time.Duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+
+	// Retry retries failed calls. It cannot be combined with Hedging.
+	Retry *RetryPolicy `yaml:"retry" json:"retry" toml:"retry"`
+
+	// Hedging sends additional attempts when a call is slow. It cannot be
+	// combined with Retry and only applies to unary calls.
+	Hedging *HedgingPolicy `yaml:"hedging" json:"hedging" toml:"hedging"`
+
+	// CircuitBreaker overrides ClientOptions.CircuitBreaker for the matched
+	// methods.
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuitBreaker" json:"circuitBreaker" toml:"circuitBreaker"`
+}
+
+// RetryPolicy is the retry policy of a method, enforced by gRPC through the
+// service config.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the original
+	// call. gRPC caps it at 5 (default: 3).
+	MaxAttempts int `yaml:"maxAttempts" json:"maxAttempts" toml:"maxAttempts"`
+
+	// InitialBackoff is the delay before the first retry (default: 100ms).
+	InitialBackoff time.Duration `yaml:"initialBackoff" json:"initialBackoff" toml:"initialBackoff"`
+
+	// MaxBackoff caps the delay between retries (default: 1s).
+	MaxBackoff time.Duration `yaml:"maxBackoff" json:"maxBackoff" toml:"maxBackoff"`
+
+	// BackoffMultiplier grows the delay after each retry (default: 2).
+	BackoffMultiplier float64 `yaml:"backoffMultiplier" json:"backoffMultiplier" toml:"backoffMultiplier"`
+
+	// RetryableCodes are the status codes that trigger a retry, e.g.
+	// UNAVAILABLE (default: [UNAVAILABLE]).
+	RetryableCodes []string `yaml:"retryableCodes" json:"retryableCodes" toml:"retryableCodes"`
+}
+
+// Normalize sets default values for the retry policy.
+func (p *RetryPolicy) Normalize() {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = time.Second
+	}
+	if p.BackoffMultiplier == 0 {
+		p.BackoffMultiplier = 2
+	}
+	if len(p.RetryableCodes) == 0 {
+		p.RetryableCodes = []string{"UNAVAILABLE"}
+	}
+}
+
+// Validate validates the retry policy.
+func (p *RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 || p.MaxAttempts == 1 {
+		return errors.New("retry maxAttempts must be at least 2")
+	}
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 || p.BackoffMultiplier < 0 {
+		return errors.New("retry backoff cannot be negative")
+	}
+	if _, err := parseCodes(p.RetryableCodes); err != nil {
+		return fmt.Errorf("retry retryableCodes: %w", err)
+	}
+	return nil
+}
+
+// HedgingPolicy is the hedging policy of a method. The first attempt is sent
+// immediately and each further attempt after Delay, or as soon as an earlier
+// attempt fails with a non-fatal code. The first successful response wins and
+// the remaining attempts are cancelled.
+//
+// Hedge only idempotent methods: every attempt may reach the server.
+type HedgingPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the original
+	// call (default: 2).
+	MaxAttempts int `yaml:"maxAttempts" json:"maxAttempts" toml:"maxAttempts"`
+
+	// Delay is the time to wait before sending the next attempt. Zero sends
+	// every attempt at once.
+	Delay time.Duration `yaml:"delay" json:"delay" toml:"delay"`
+
+	// NonFatalCodes are the status codes that let the remaining attempts
+	// continue. Any other error is returned immediately
+	// (default: [UNAVAILABLE]).
+	NonFatalCodes []string `yaml:"nonFatalCodes" json:"nonFatalCodes" toml:"nonFatalCodes"`
+}
+
+// Normalize sets default values for the hedging policy.
+func (p *HedgingPolicy) Normalize() {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = 2
+	}
+	if len(p.NonFatalCodes) == 0 {
+		p.NonFatalCodes = []string{"UNAVAILABLE"}
+	}
+}
+
+// Validate validates the hedging policy.
+func (p *HedgingPolicy) Validate() error {
+	if p.MaxAttempts < 0 || p.MaxAttempts == 1 {
+		return errors.New("hedging maxAttempts must be at least 2")
+	}
+	if p.Delay < 0 {
+		return errors.New("hedging delay cannot be negative")
+	}
+	if _, err := parseCodes(p.NonFatalCodes); err != nil {
+		return fmt.Errorf("hedging nonFatalCodes: %w", err)
+	}
+	return nil
+}
+
+// Normalize sets default values for the method policy.
+func (p *MethodPolicy) Normalize() {
+	if p.Retry != nil {
+		p.Retry.Normalize()
+	}
+	if p.Hedging != nil {
+		p.Hedging.Normalize()
+	}
+	if p.CircuitBreaker != nil {
+		p.CircuitBreaker.Normalize()
+	}
+}
+
+// Validate validates the method policy.
+func (p *MethodPolicy) Validate() error {
+	if p.Method != "" && p.Service == "" {
+		return errors.New("method policy requires service when method is set")
+	}
+	if p.Timeout < 0 {
+		return errors.New("method timeout cannot be negative")
+	}
+	if p.Retry != nil && p.Hedging != nil {
+		return errors.New("method policy cannot combine retry and hedging")
+	}
+	if p.Retry != nil {
+		if err := p.Retry.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Hedging != nil {
+		if err := p.Hedging.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.CircuitBreaker != nil {
+		if err := p.CircuitBreaker.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// name returns the policy key in the same "service/method" form as the
+// lookup keys of methodPolicies.
+func (p *MethodPolicy) name() string {
+	return p.Service + "/" + p.Method
+}
+
+// methodPolicies resolves the policy of a full method name.
+type methodPolicies map[string]*MethodPolicy
+
+func newMethodPolicies(policies []MethodPolicy) methodPolicies {
+	m := make(methodPolicies, len(policies))
+	for i := range policies {
+		m[policies[i].name()] = &policies[i]
+	}
+	return m
+}
+
+// lookup returns the most specific policy of fullMethod
+// ("/package.Service/Method"), or nil.
+func (m methodPolicies) lookup(fullMethod string) *MethodPolicy {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	for _, key := range []string{service + "/" + method, service + "/", "/"} {
+		if p, ok := m[key]; ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// serviceConfig mirrors the subset of the gRPC service config JSON built from
+// ClientOptions.
+type serviceConfig struct {
+	LoadBalancingPolicy string                `json:"loadBalancingPolicy"`
+	MethodConfig        []serviceMethodConfig `json:"methodConfig,omitempty"`
+}
+
+type serviceMethodName struct {
+	Service string `json:"service,omitempty"`
+	Method  string `json:"method,omitempty"`
+}
+
+type serviceMethodConfig struct {
+	Name        []serviceMethodName `json:"name"`
+	Timeout     string              `json:"timeout,omitempty"`
+	RetryPolicy *serviceRetryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type serviceRetryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// buildServiceConfig returns the gRPC service config JSON for the load
+// balancing policy and the method timeouts and retry policies. Hedging is not
+// part of it: grpc-go does not implement hedging, so it runs as an
+// interceptor instead.
+func buildServiceConfig(lb string, policies []MethodPolicy) (string, error) {
+	sc := serviceConfig{LoadBalancingPolicy: lb}
+	for _, p := range policies {
+		// Every policy is listed, even without timeout or retry, so that gRPC
+		// resolves the most specific policy the same way as the interceptors.
+		mc := serviceMethodConfig{
+			Name: []serviceMethodName{{Service: p.Service, Method: p.Method}},
+		}
+		if p.Timeout > 0 {
+			mc.Timeout = formatJSONDuration(p.Timeout)
+		}
+		if r := p.Retry; r != nil {
+			mc.RetryPolicy = &serviceRetryPolicy{
+				MaxAttempts:          r.MaxAttempts,
+				InitialBackoff:       formatJSONDuration(r.InitialBackoff),
+				MaxBackoff:           formatJSONDuration(r.MaxBackoff),
+				BackoffMultiplier:    r.BackoffMultiplier,
+				RetryableStatusCodes: upperCodes(r.RetryableCodes),
+			}
+		}
+		sc.MethodConfig = append(sc.MethodConfig, mc)
+	}
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// formatJSONDuration formats d as a protobuf JSON duration, e.g. "1.5s".
+func formatJSONDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+func upperCodes(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = strings.ToUpper(name)
+	}
+	return out
+}
+
+// parseCodes parses status code names such as UNAVAILABLE or unavailable.
+func parseCodes(names []string) (map[codes.Code]struct{}, error) {
+	set := make(map[codes.Code]struct{}, len(names))
+	for _, name := range names {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(name)))); err != nil {
+			return nil, fmt.Errorf("unknown status code %q", name)
+		}
+		set[code] = struct{}{}
+	}
+	return set, nil
+}
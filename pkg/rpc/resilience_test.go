@@ -0,0 +1,212 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// scriptedHealth answers Check with the result of check for the n-th call.
+type scriptedHealth struct {
+	healthpb.UnimplementedHealthServer
+	calls atomic.Int32
+	check func(ctx context.Context, n int32) error
+}
+
+func (s *scriptedHealth) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if err := s.check(ctx, s.calls.Add(1)); err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func startScriptedHealth(t *testing.T, check func(ctx context.Context, n int32) error) (*scriptedHealth, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	svc := &scriptedHealth{check: check}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, svc)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return svc, "passthrough:///" + lis.Addr().String()
+}
+
+func dialHealth(t *testing.T, opts *ClientOptions, target string) healthpb.HealthClient {
+	t.Helper()
+	dialOpts, err := opts.BuildDialOptions()
+	if err != nil {
+		t.Fatalf("BuildDialOptions() error = %v", err)
+	}
+	conn, err := NewClient(target, dialOpts...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestBuildServiceConfig(t *testing.T) {
+	opts := &ClientOptions{Methods: []MethodPolicy{
+		{Timeout: 1500 * time.Millisecond},
+		{Service: "grpc.health.v1.Health", Method: "Check", Retry: &RetryPolicy{RetryableCodes: []string{"unavailable", "ABORTED"}}},
+	}}
+	opts.Normalize()
+	got, err := buildServiceConfig(opts.LoadBalancingPolicy, opts.Methods)
+	if err != nil {
+		t.Fatalf("buildServiceConfig() error = %v", err)
+	}
+	var sc serviceConfig
+	if err := json.Unmarshal([]byte(got), &sc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if sc.LoadBalancingPolicy != "round_robin" || len(sc.MethodConfig) != 2 {
+		t.Fatalf("service config = %s", got)
+	}
+	if sc.MethodConfig[0].Timeout != "1.5s" || len(sc.MethodConfig[0].Name) != 1 || sc.MethodConfig[0].Name[0] != (serviceMethodName{}) {
+		t.Fatalf("default method config = %+v", sc.MethodConfig[0])
+	}
+	retry := sc.MethodConfig[1].RetryPolicy
+	if retry == nil || retry.MaxAttempts != 3 || retry.InitialBackoff != "0.1s" || retry.RetryableStatusCodes[1] != "ABORTED" {
+		t.Fatalf("retry policy = %+v", retry)
+	}
+}
+
+func TestClientOptionsValidate(t *testing.T) {
+	tests := []ClientOptions{
+		{Methods: []MethodPolicy{{Method: "Check"}}},
+		{Methods: []MethodPolicy{{Service: "a.S", Retry: &RetryPolicy{}, Hedging: &HedgingPolicy{}}}},
+		{Methods: []MethodPolicy{{Service: "a.S", Retry: &RetryPolicy{MaxAttempts: 1}}}},
+		{Methods: []MethodPolicy{{Service: "a.S", Retry: &RetryPolicy{RetryableCodes: []string{"SOMETIMES"}}}}},
+		{Methods: []MethodPolicy{{Service: "a.S"}, {Service: "a.S"}}},
+		{CircuitBreaker: &CircuitBreakerConfig{ErrorRatio: 2}},
+	}
+	for _, opts := range tests {
+		if err := opts.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) expected error", opts)
+		}
+	}
+}
+
+func TestRetryPolicyRetriesUnavailable(t *testing.T) {
+	svc, target := startScriptedHealth(t, func(_ context.Context, n int32) error {
+		if n < 3 {
+			return status.Error(codes.Unavailable, "warming up")
+		}
+		return nil
+	})
+	client := dialHealth(t, &ClientOptions{Methods: []MethodPolicy{{
+		Service: "grpc.health.v1.Health",
+		Retry:   &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	}}}, target)
+
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if got := svc.calls.Load(); got != 3 {
+		t.Fatalf("server calls = %d, want 3", got)
+	}
+}
+
+func TestMethodTimeoutFromServiceConfig(t *testing.T) {
+	_, target := startScriptedHealth(t, func(ctx context.Context, _ int32) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	client := dialHealth(t, &ClientOptions{Methods: []MethodPolicy{{
+		Service: "grpc.health.v1.Health",
+		Method:  "Check",
+		Timeout: 50 * time.Millisecond,
+	}}}, target)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Check() error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestHedgingReturnsFastestAttempt(t *testing.T) {
+	svc, target := startScriptedHealth(t, func(ctx context.Context, n int32) error {
+		if n == 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+		return nil
+	})
+	client := dialHealth(t, &ClientOptions{Methods: []MethodPolicy{{
+		Service: "grpc.health.v1.Health",
+		Hedging: &HedgingPolicy{MaxAttempts: 2, Delay: 20 * time.Millisecond},
+	}}}, target)
+
+	start := time.Now()
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("status = %v", resp.GetStatus())
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("hedged call took %v", elapsed)
+	}
+	if got := svc.calls.Load(); got != 2 {
+		t.Fatalf("server calls = %d, want 2", got)
+	}
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Unix(0, 0)
+	bs := newBreakers(&CircuitBreakerConfig{MinRequests: 4, ErrorRatio: 0.5, Cooldown: time.Second}, nil)
+	bs.defaults.Normalize()
+	bs.now = func() time.Time { return now }
+	interceptor := bs.unary()
+
+	var failing bool
+	var invoked int
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		invoked++
+		if failing {
+			return status.Error(codes.Unavailable, "down")
+		}
+		return nil
+	}
+	call := func() error {
+		return interceptor(context.Background(), "/a.S/M", nil, nil, nil, invoker)
+	}
+
+	failing = true
+	for i := 0; i < 4; i++ {
+		_ = call()
+	}
+	if err := call(); status.Convert(err).Message() != ErrCircuitOpen.Error() || invoked != 4 {
+		t.Fatalf("call on open breaker error = %v, invoked = %d", err, invoked)
+	}
+
+	// After the cooldown a single probe is let through; its success closes
+	// the breaker.
+	now = now.Add(time.Second)
+	failing = false
+	if err := call(); err != nil {
+		t.Fatalf("probe error = %v", err)
+	}
+	if err := call(); err != nil || invoked != 6 {
+		t.Fatalf("call on closed breaker error = %v, invoked = %d", err, invoked)
+	}
+
+	// Another method keeps its own breaker.
+	if b := bs.get("/a.S/Other"); b == bs.get("/a.S/M") {
+		t.Fatalf("methods share a breaker")
+	}
+}
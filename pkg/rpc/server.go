@@ -3,7 +3,9 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net"
+	"sync"
 
 	"github.com/HorseArcher567/octopus/pkg/discovery"
 	"github.com/HorseArcher567/octopus/pkg/rpc/middleware"
@@ -24,9 +26,20 @@ type Server struct {
 	unaryInterceptors  []grpc.UnaryServerInterceptor
 	streamInterceptors []grpc.StreamServerInterceptor
 	statsHandlers      []stats.Handler
+	tracing            bool
+	admissionUnary     []grpc.UnaryServerInterceptor
+	admissionStream    []grpc.StreamServerInterceptor
+	authUnary          grpc.UnaryServerInterceptor
+	authStream         grpc.StreamServerInterceptor
 
 	registrar discovery.Registrar
 	instance  *discovery.Instance
+
+	services []registeredService
+	local    localServer
+
+	ready     chan struct{}
+	readyOnce sync.Once
 }
 
 // MustNewServer creates a new Server and panics if initialization fails.
@@ -50,12 +63,15 @@ func NewServer(log *xlog.Logger, config *ServerConfig, opts ...Option) (*Server,
 	s := &Server{
 		log:    log,
 		config: config,
+		ready:  make(chan struct{}),
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	userOpts := append([]grpc.ServerOption(nil), s.serverOptions...)
+
 	// Configure keepalive if configured
 	keepaliveOpts := s.config.Keepalive.BuildServerOptions()
 	if len(keepaliveOpts) > 0 {
@@ -81,62 +97,148 @@ func NewServer(log *xlog.Logger, config *ServerConfig, opts ...Option) (*Server,
 		s.serverOptions = append(s.serverOptions, keepaliveOpts...)
 	}
 
-	defaultUnary := []grpc.UnaryServerInterceptor{
-		middleware.UnaryInjectLogger(s.log),
-		middleware.UnaryServerLogging(),
-	}
-	defaultStream := []grpc.StreamServerInterceptor{
-		middleware.StreamInjectLogger(s.log),
-		middleware.StreamServerLogging(),
+	if s.config.TLS != nil {
+		creds, err := serverCredentials(s.config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		s.serverOptions = append(s.serverOptions, grpc.Creds(creds))
+		s.log.Info("configuring tls", "mutual", s.config.TLS.CAFile != "")
 	}
 
-	allUnary := append(defaultUnary, s.unaryInterceptors...)
-	allStream := append(defaultStream, s.streamInterceptors...)
+	// The local server shares the handler pipeline but not the transport
+	// settings above.
+	var handlerOpts []grpc.ServerOption
+	allUnary := append(s.defaultUnaryInterceptors(), s.unaryInterceptors...)
+	allStream := append(s.defaultStreamInterceptors(), s.streamInterceptors...)
 	if len(allUnary) > 0 {
-		s.serverOptions = append(s.serverOptions, grpc.ChainUnaryInterceptor(allUnary...))
+		handlerOpts = append(handlerOpts, grpc.ChainUnaryInterceptor(allUnary...))
 	}
 	if len(allStream) > 0 {
-		s.serverOptions = append(s.serverOptions, grpc.ChainStreamInterceptor(allStream...))
+		handlerOpts = append(handlerOpts, grpc.ChainStreamInterceptor(allStream...))
 	}
 	for _, h := range s.statsHandlers {
 		if h != nil {
-			s.serverOptions = append(s.serverOptions, grpc.StatsHandler(h))
+			handlerOpts = append(handlerOpts, grpc.StatsHandler(h))
 		}
 	}
+	s.local.options = append(append(s.local.options, userOpts...), handlerOpts...)
+	s.serverOptions = append(s.serverOptions, handlerOpts...)
 
 	s.grpcServer = grpc.NewServer(s.serverOptions...)
 	return s, nil
 }
 
+// defaultUnaryInterceptors returns the built-in unary interceptors. With
+// tracing enabled, the trace IDs are added to the logger before the request
+// is logged. Error conversion sits before logging so that the original error,
+// including its internal cause, is logged, and so does authentication, so
+// that the request log carries the principal. Admission interceptors sit
+// before authentication so that the calls they reject skip it. Recovery sits
+// after logging so that a recovered panic is logged as a failed request.
+func (s *Server) defaultUnaryInterceptors() []grpc.UnaryServerInterceptor {
+	cfg := s.config.Interceptors
+	interceptors := []grpc.UnaryServerInterceptor{middleware.UnaryInjectLogger(s.log)}
+	if s.tracing {
+		interceptors = append(interceptors, middleware.UnaryTraceLogger())
+	}
+	if !cfg.DisableRequestID {
+		interceptors = append(interceptors, middleware.UnaryRequestID())
+	}
+	if !cfg.DisableErrors {
+		interceptors = append(interceptors, middleware.UnaryServerErrors())
+	}
+	interceptors = append(interceptors, s.admissionUnary...)
+	if s.authUnary != nil {
+		interceptors = append(interceptors, s.authUnary)
+	}
+	interceptors = append(interceptors, middleware.UnaryServerLogging())
+	if !cfg.DisableRecovery {
+		interceptors = append(interceptors, middleware.UnaryServerRecovery())
+	}
+	if cfg.MaxTimeout > 0 {
+		interceptors = append(interceptors, middleware.UnaryServerMaxTimeout(cfg.MaxTimeout))
+	}
+	if !cfg.DisableValidation {
+		interceptors = append(interceptors, middleware.UnaryServerValidation())
+	}
+	return interceptors
+}
+
+// defaultStreamInterceptors returns the built-in stream interceptors, in the
+// same order as the unary ones.
+func (s *Server) defaultStreamInterceptors() []grpc.StreamServerInterceptor {
+	cfg := s.config.Interceptors
+	interceptors := []grpc.StreamServerInterceptor{middleware.StreamInjectLogger(s.log)}
+	if s.tracing {
+		interceptors = append(interceptors, middleware.StreamTraceLogger())
+	}
+	if !cfg.DisableRequestID {
+		interceptors = append(interceptors, middleware.StreamRequestID())
+	}
+	if !cfg.DisableErrors {
+		interceptors = append(interceptors, middleware.StreamServerErrors())
+	}
+	interceptors = append(interceptors, s.admissionStream...)
+	if s.authStream != nil {
+		interceptors = append(interceptors, s.authStream)
+	}
+	interceptors = append(interceptors, middleware.StreamServerLogging())
+	if !cfg.DisableRecovery {
+		interceptors = append(interceptors, middleware.StreamServerRecovery())
+	}
+	if cfg.MaxTimeout > 0 {
+		interceptors = append(interceptors, middleware.StreamServerMaxTimeout(cfg.MaxTimeout))
+	}
+	if !cfg.DisableValidation {
+		interceptors = append(interceptors, middleware.StreamServerValidation())
+	}
+	return interceptors
+}
+
 // UnaryInterceptorCount returns the effective unary interceptor count, including defaults.
 func (s *Server) UnaryInterceptorCount() int {
-	return 2 + len(s.unaryInterceptors)
+	return len(s.defaultUnaryInterceptors()) + len(s.unaryInterceptors)
 }
 
 // StreamInterceptorCount returns the effective stream interceptor count, including defaults.
 func (s *Server) StreamInterceptorCount() int {
-	return 2 + len(s.streamInterceptors)
+	return len(s.defaultStreamInterceptors()) + len(s.streamInterceptors)
 }
 
 // Register applies one or more gRPC service registrations to the underlying grpc.Server.
+// The services are also recorded for the local server behind LocalConn.
 func (s *Server) Register(register func(grpc.ServiceRegistrar)) error {
 	if register != nil {
-		register(s.grpcServer)
+		register(serviceRecorder{s})
 	}
 	return nil
 }
 
+// GetServiceInfo returns the services registered on the server, keyed by
+// their full name.
+func (s *Server) GetServiceInfo() map[string]grpc.ServiceInfo {
+	return s.grpcServer.GetServiceInfo()
+}
+
+type registeredService struct {
+	desc *grpc.ServiceDesc
+	impl any
+}
+
+// serviceRecorder registers services on the grpc.Server and records them.
+type serviceRecorder struct {
+	s *Server
+}
+
+func (r serviceRecorder) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	r.s.grpcServer.RegisterService(desc, impl)
+	r.s.services = append(r.s.services, registeredService{desc: desc, impl: impl})
+}
+
 // Run starts the gRPC server and blocks until ctx is cancelled or server errors.
 // Note: Run does NOT call Stop; Stop is called by App uniformly.
 func (s *Server) Run(ctx context.Context) error {
-	// Register to etcd if configured (do this before starting server)
-	if s.config.ShouldRegisterInstance() {
-		if err := s.registerInstance(ctx); err != nil {
-			s.log.Error("failed to register instance", "error", err)
-			return err
-		}
-	}
-
 	// Enable reflection if configured.
 	if s.config.EnableReflection {
 		reflection.Register(s.grpcServer)
@@ -151,7 +253,17 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 
+	// Register to etcd if configured, once the port is bound.
+	if s.config.ShouldRegisterInstance() {
+		if err := s.registerInstance(ctx); err != nil {
+			s.log.Error("failed to register instance", "error", err)
+			_ = lis.Close()
+			return err
+		}
+	}
+
 	s.log.Info("starting rpc server", "addr", addr)
+	s.serveLocal()
 
 	// Start server in goroutine
 	errCh := make(chan error, 1)
@@ -161,6 +273,7 @@ func (s *Server) Run(ctx context.Context) error {
 			errCh <- err
 		}
 	}()
+	s.readyOnce.Do(func() { close(s.ready) })
 
 	// Wait for ctx cancelled or server error
 	select {
@@ -171,6 +284,12 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 }
 
+// Ready returns a channel that is closed once the server is listening and,
+// when configured, registered in service discovery.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
 // Stop gracefully stops the server and deregisters its discovery instance when present.
 // It blocks until the server has finished shutting down.
 func (s *Server) Stop(ctx context.Context) error {
@@ -186,6 +305,7 @@ func (s *Server) Stop(ctx context.Context) error {
 	done := make(chan struct{})
 	go func() {
 		s.grpcServer.GracefulStop()
+		s.local.stop(true)
 		close(done)
 	}()
 
@@ -196,6 +316,7 @@ func (s *Server) Stop(ctx context.Context) error {
 	case <-ctx.Done():
 		s.log.Warn("rpc server shutdown timeout, forcing stop")
 		s.grpcServer.Stop() // Force stop
+		s.local.stop(false)
 		return ctx.Err()
 	}
 }
@@ -205,10 +326,22 @@ func (s *Server) registerInstance(ctx context.Context) error {
 	if s.registrar == nil {
 		return fmt.Errorf("rpc: discovery registrar is not configured")
 	}
+	adv := s.config.Advertise
 	instance := discovery.Instance{
-		Name: s.config.Name,
-		Host: s.config.Advertise.Address,
-		Port: s.config.Port,
+		ID:       adv.ID,
+		Name:     s.config.Name,
+		Host:     adv.Address,
+		Port:     s.config.Port,
+		Version:  adv.Version,
+		Zone:     adv.Zone,
+		Weight:   adv.Weight,
+		Metadata: maps.Clone(adv.Labels),
+	}
+	if instance.ID == "" {
+		instance.ID = instance.Addr()
+	}
+	if instance.Weight == 0 {
+		instance.Weight = discovery.DefaultWeight
 	}
 	if err := s.registrar.Register(ctx, instance); err != nil {
 		return err
@@ -1,11 +1,24 @@
 package rpc
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
 	"testing"
+	"time"
 
+	"github.com/HorseArcher567/octopus/pkg/discovery"
+	octoerrors "github.com/HorseArcher567/octopus/pkg/errors"
 	rpcmiddleware "github.com/HorseArcher567/octopus/pkg/rpc/middleware"
 	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestServerDefaultInterceptorsInstalled(t *testing.T) {
@@ -21,11 +34,11 @@ func TestServerDefaultInterceptorsInstalled(t *testing.T) {
 		t.Fatalf("new server: %v", err)
 	}
 
-	if got := s.UnaryInterceptorCount(); got != 2 {
-		t.Fatalf("expected 2 default unary interceptors, got %d", got)
+	if got := s.UnaryInterceptorCount(); got != 6 {
+		t.Fatalf("expected 6 default unary interceptors, got %d", got)
 	}
-	if got := s.StreamInterceptorCount(); got != 2 {
-		t.Fatalf("expected 2 default stream interceptors, got %d", got)
+	if got := s.StreamInterceptorCount(); got != 6 {
+		t.Fatalf("expected 6 default stream interceptors, got %d", got)
 	}
 }
 
@@ -45,6 +58,57 @@ func TestServerCustomInterceptorsAppended(t *testing.T) {
 		t.Fatalf("new server: %v", err)
 	}
 
+	if got := s.UnaryInterceptorCount(); got != 7 {
+		t.Fatalf("expected 7 unary interceptors, got %d", got)
+	}
+	if got := s.StreamInterceptorCount(); got != 7 {
+		t.Fatalf("expected 7 stream interceptors, got %d", got)
+	}
+}
+
+func TestServerWithTracingAddsTraceLogger(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	s, err := NewServer(log, &ServerConfig{
+		Name: "rpc-test",
+		Host: "127.0.0.1",
+		Port: 50053,
+	}, WithTracing(noop.NewTracerProvider(), propagation.TraceContext{}))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	if got := s.UnaryInterceptorCount(); got != 7 {
+		t.Fatalf("expected 7 unary interceptors, got %d", got)
+	}
+	if got := s.StreamInterceptorCount(); got != 7 {
+		t.Fatalf("expected 7 stream interceptors, got %d", got)
+	}
+	if len(s.statsHandlers) != 1 {
+		t.Fatalf("expected 1 stats handler, got %d", len(s.statsHandlers))
+	}
+}
+
+func TestServerInterceptorsToggle(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	s, err := NewServer(log, &ServerConfig{
+		Name: "rpc-test",
+		Host: "127.0.0.1",
+		Port: 50055,
+		Interceptors: ServerInterceptors{
+			DisableRecovery:   true,
+			DisableRequestID:  true,
+			DisableErrors:     true,
+			DisableValidation: true,
+			MaxTimeout:        time.Second,
+		},
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
 	if got := s.UnaryInterceptorCount(); got != 3 {
 		t.Fatalf("expected 3 unary interceptors, got %d", got)
 	}
@@ -52,3 +116,282 @@ func TestServerCustomInterceptorsAppended(t *testing.T) {
 		t.Fatalf("expected 3 stream interceptors, got %d", got)
 	}
 }
+
+// panickyHealth panics for the "panic" service, fails the "missing" service
+// with errHealthNotFound and reports the time left before the deadline for
+// every other service through deadlines.
+type panickyHealth struct {
+	healthpb.UnimplementedHealthServer
+	deadlines chan time.Duration
+}
+
+func (h *panickyHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	switch req.GetService() {
+	case "panic":
+		panic("boom")
+	case "missing":
+		return nil, fmt.Errorf("lookup %q: %w", req.GetService(), errHealthNotFound)
+	}
+	deadline, _ := ctx.Deadline()
+	h.deadlines <- time.Until(deadline)
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+var errHealthNotFound = octoerrors.New(octoerrors.NotFound, "service not found").
+	WithReason("SERVICE_NOT_FOUND").
+	WithFieldViolation("service", "unknown service")
+
+func TestServerBuiltinInterceptors(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	s, err := NewServer(log, &ServerConfig{
+		Name:         "rpc-test",
+		Host:         "127.0.0.1",
+		Port:         50056,
+		Interceptors: ServerInterceptors{MaxTimeout: time.Second},
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	svc := &panickyHealth{deadlines: make(chan time.Duration, 1)}
+	healthpb.RegisterHealthServer(s.grpcServer, svc)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	go func() { _ = s.grpcServer.Serve(lis) }()
+	defer s.grpcServer.Stop()
+
+	opts, _ := (&ClientOptions{}).BuildDialOptions()
+	opts = append(opts, grpc.WithChainUnaryInterceptor(rpcmiddleware.UnaryClientErrors()))
+	conn, err := NewClient(lis.Addr().String(), opts...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	// A panicking handler fails the call instead of crashing the process.
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "panic"})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("Check(panic) error = %v, want Internal", err)
+	}
+
+	// A domain error keeps its code, reason and field violations across the
+	// wire, but not its internal cause.
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	var e *octoerrors.Error
+	if !errors.Is(err, errHealthNotFound) || !errors.As(err, &e) {
+		t.Fatalf("Check(missing) error = %v, want errHealthNotFound", err)
+	}
+	if e.Message != "service not found" || len(e.FieldViolations) != 1 || status.Code(err) != codes.NotFound {
+		t.Fatalf("Check(missing) error = %+v", e)
+	}
+
+	// The caller's request ID is echoed back, and the deadline is capped.
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), rpcmiddleware.RequestIDKey, "req-1")
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if got := header.Get(rpcmiddleware.RequestIDKey); len(got) != 1 || got[0] != "req-1" {
+		t.Fatalf("request id header = %v, want req-1", got)
+	}
+	if left := <-svc.deadlines; left > time.Second {
+		t.Fatalf("deadline left = %v, want at most 1s", left)
+	}
+
+	// Without one, a request ID is generated.
+	header = nil
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	<-svc.deadlines
+	if got := header.Get(rpcmiddleware.RequestIDKey); len(got) != 1 || got[0] == "" {
+		t.Fatalf("generated request id header = %v", got)
+	}
+}
+
+type validatedRequest struct{ err error }
+
+func (r validatedRequest) Validate() error { return r.err }
+
+func TestServerValidationInterceptor(t *testing.T) {
+	interceptor := rpcmiddleware.UnaryServerValidation()
+	called := false
+	handler := func(context.Context, any) (any, error) {
+		called = true
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/a.S/M"}
+
+	_, err := interceptor(context.Background(), validatedRequest{err: errors.New("name is required")}, info, handler)
+	if status.Code(err) != codes.InvalidArgument || called {
+		t.Fatalf("invalid request error = %v, handler called = %v", err, called)
+	}
+	if _, err := interceptor(context.Background(), validatedRequest{}, info, handler); err != nil || !called {
+		t.Fatalf("valid request error = %v, handler called = %v", err, called)
+	}
+}
+
+func TestServerLocalConn(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	port := lis.Addr().(*net.TCPAddr).Port
+	_ = lis.Close()
+
+	s, err := NewServer(log, &ServerConfig{Name: "rpc-test", Host: "127.0.0.1", Port: port})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	svc := &panickyHealth{deadlines: make(chan time.Duration, 1)}
+	_ = s.Register(func(r grpc.ServiceRegistrar) { healthpb.RegisterHealthServer(r, svc) })
+	if _, ok := s.GetServiceInfo()[healthpb.Health_ServiceDesc.ServiceName]; !ok {
+		t.Fatalf("GetServiceInfo() = %v, want health service", s.GetServiceInfo())
+	}
+
+	// The connection may be created before Run; calls wait for the server.
+	conn, err := s.LocalConn()
+	if err != nil {
+		t.Fatalf("LocalConn() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+	<-s.Ready()
+
+	client := healthpb.NewHealthClient(conn)
+	var header metadata.MD
+	callCtx := metadata.AppendToOutgoingContext(context.Background(), rpcmiddleware.RequestIDKey, "req-1")
+	if _, err := client.Check(callCtx, &healthpb.HealthCheckRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	<-svc.deadlines
+	if got := header.Get(rpcmiddleware.RequestIDKey); len(got) != 1 || got[0] != "req-1" {
+		t.Fatalf("request id header = %v, want req-1", got)
+	}
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Check(missing) error = %v, want NotFound", err)
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err == nil {
+		t.Fatal("Check() after Stop succeeded")
+	}
+}
+
+type recordingRegistrar struct {
+	instances []discovery.Instance
+}
+
+func (r *recordingRegistrar) Register(_ context.Context, instance discovery.Instance) error {
+	r.instances = append(r.instances, instance)
+	return nil
+}
+
+func (r *recordingRegistrar) Deregister(context.Context, discovery.Instance) error { return nil }
+
+func TestServerRegistersAdvertisedInstance(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	registrar := &recordingRegistrar{}
+	s, err := NewServer(log, &ServerConfig{
+		Name: "rpc-test",
+		Host: "0.0.0.0",
+		Port: 50060,
+		Advertise: &ServerAdvertiseConfig{
+			Address: "10.0.0.1",
+			Etcd:    "default",
+			Version: "v1.2.0",
+			Zone:    "zone-a",
+			Labels:  map[string]string{"canary": "true"},
+		},
+	}, WithRegistrar(registrar))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := s.registerInstance(context.Background()); err != nil {
+		t.Fatalf("registerInstance() error = %v", err)
+	}
+
+	want := discovery.Instance{
+		ID:       "10.0.0.1:50060",
+		Name:     "rpc-test",
+		Host:     "10.0.0.1",
+		Port:     50060,
+		Version:  "v1.2.0",
+		Zone:     "zone-a",
+		Weight:   discovery.DefaultWeight,
+		Metadata: map[string]string{"canary": "true"},
+	}
+	if len(registrar.instances) != 1 || !registrar.instances[0].Equal(want) {
+		t.Fatalf("registered %+v, want %+v", registrar.instances, want)
+	}
+
+	cfg := &ServerConfig{Name: "rpc-test", Host: "0.0.0.0", Port: 50060, Advertise: &ServerAdvertiseConfig{Address: "10.0.0.1", Etcd: "default", Weight: -1}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected negative advertise weight to fail validation")
+	}
+}
+
+func TestServerAdmissionRunsBeforeAuth(t *testing.T) {
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	var order []string
+	admission := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		order = append(order, "admission")
+		return handler(ctx, req)
+	}
+	auth := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		order = append(order, "auth")
+		return nil, octoerrors.New(octoerrors.Unauthenticated, "no credentials")
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	port := lis.Addr().(*net.TCPAddr).Port
+	_ = lis.Close()
+
+	s, err := NewServer(log, &ServerConfig{Name: "rpc-test", Host: "127.0.0.1", Port: port},
+		WithAuth(auth, nil), WithAdmission(admission, nil))
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	_ = s.Register(func(r grpc.ServiceRegistrar) {
+		healthpb.RegisterHealthServer(r, &panickyHealth{deadlines: make(chan time.Duration, 1)})
+	})
+	if got := s.UnaryInterceptorCount(); got != 8 {
+		t.Fatalf("expected 8 unary interceptors, got %d", got)
+	}
+	if got := s.StreamInterceptorCount(); got != 6 {
+		t.Fatalf("expected 6 stream interceptors, got %d", got)
+	}
+
+	conn, err := s.LocalConn()
+	if err != nil {
+		t.Fatalf("LocalConn() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+	<-s.Ready()
+	defer s.Stop(context.Background())
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unauthenticated || fmt.Sprint(order) != "[admission auth]" {
+		t.Fatalf("Check() error = %v, interceptors = %v, want admission before auth", err, order)
+	}
+}
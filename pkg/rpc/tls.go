@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/HorseArcher567/octopus/pkg/xtls"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSConfig configures transport security for the RPC server or client.
+// See xtls.Config for the fields and the certificate reload behavior.
+type TLSConfig = xtls.Config
+
+// Client authentication modes accepted by TLSConfig.ClientAuth.
+const (
+	ClientAuthNone             = xtls.ClientAuthNone
+	ClientAuthRequest          = xtls.ClientAuthRequest
+	ClientAuthRequire          = xtls.ClientAuthRequire
+	ClientAuthVerifyIfGiven    = xtls.ClientAuthVerifyIfGiven
+	ClientAuthRequireAndVerify = xtls.ClientAuthRequireAndVerify
+)
+
+// serverCredentials builds gRPC server credentials from c.
+func serverCredentials(c *TLSConfig) (credentials.TransportCredentials, error) {
+	cfg, err := c.ServerTLSConfig("h2")
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(cfg), nil
+}
+
+// clientCredentials builds gRPC client credentials from c.
+func clientCredentials(c *TLSConfig) (credentials.TransportCredentials, error) {
+	client, err := c.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return &clientCreds{client: client}, nil
+}
+
+// clientCreds builds the TLS configuration of each connection from the
+// current files, and leaves the server name to grpc, which verifies the
+// certificate against the authority it dials.
+type clientCreds struct {
+	client     *xtls.Client
+	serverName string
+}
+
+func (c *clientCreds) creds() credentials.TransportCredentials {
+	return credentials.NewTLS(c.client.Config(c.serverName))
+}
+
+func (c *clientCreds) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.creds().ClientHandshake(ctx, authority, conn)
+}
+
+func (c *clientCreds) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("rpc: client credentials cannot serve")
+}
+
+func (c *clientCreds) Info() credentials.ProtocolInfo {
+	return c.creds().Info()
+}
+
+func (c *clientCreds) Clone() credentials.TransportCredentials {
+	clone := *c
+	return &clone
+}
+
+func (c *clientCreds) OverrideServerName(name string) error {
+	c.serverName = name
+	return nil
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"github.com/HorseArcher567/octopus/pkg/xtls/xtlstest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestMutualTLSExposesPeerIdentity(t *testing.T) {
+	dir := t.TempDir()
+	ca := xtlstest.NewCA(t)
+	caFile := ca.WriteCA(t, dir)
+	serverCert, serverKey := ca.Issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.Issue(t, dir, "client", x509.ExtKeyUsageClientAuth)
+
+	log := xlog.MustNew(nil)
+	defer log.Close()
+
+	identities := make(chan *PeerIdentity, 1)
+	s, err := NewServer(log, &ServerConfig{
+		Name: "rpc-test",
+		Host: "127.0.0.1",
+		Port: 50054,
+		TLS:  &TLSConfig{CertFile: serverCert, KeyFile: serverKey, CAFile: caFile},
+	}, WithUnaryInterceptors(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id, _ := PeerIdentityFromContext(ctx)
+		identities <- id
+		return handler(ctx, req)
+	}))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	healthpb.RegisterHealthServer(s.grpcServer, health.NewServer())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	go func() { _ = s.grpcServer.Serve(lis) }()
+	defer s.grpcServer.Stop()
+
+	dial := func(tlsCfg *TLSConfig) error {
+		opts, err := (&ClientOptions{TLS: tlsCfg}).BuildDialOptions()
+		if err != nil {
+			return err
+		}
+		conn, err := NewClient(lis.Addr().String(), opts...)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		return err
+	}
+
+	if err := dial(&TLSConfig{CertFile: clientCert, KeyFile: clientKey, CAFile: caFile, ServerName: "localhost"}); err != nil {
+		t.Fatalf("mTLS call error = %v", err)
+	}
+	id := <-identities
+	if id == nil || id.CommonName != "client" {
+		t.Fatalf("peer identity = %+v, want CN client", id)
+	}
+
+	if err := dial(&TLSConfig{CAFile: caFile, ServerName: "localhost"}); err == nil {
+		t.Fatalf("call without client certificate should fail")
+	}
+	if err := dial(&TLSConfig{CertFile: clientCert, KeyFile: clientKey, CAFile: caFile, ServerName: "other"}); err == nil {
+		t.Fatalf("call with mismatched server name should fail")
+	}
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Exporter names.
+const (
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterNone   = "none"
+)
+
+// Config configures trace collection.
+//
+// Example:
+//
+//	tracing:
+//	  enabled: true
+//	  serviceName: demo
+//	  exporter: file
+//	  file: ./logs/traces.jsonl
+//	  sampleRatio: 0.1
+type Config struct {
+	// Enabled turns tracing on.
+	Enabled bool `yaml:"enabled" json:"enabled" toml:"enabled"`
+
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `yaml:"serviceName" json:"serviceName" toml:"serviceName"`
+
+	// Exporter selects where finished spans are written: stdout (default),
+	// file, or none to record spans without exporting them.
+	Exporter string `yaml:"exporter" json:"exporter" toml:"exporter"`
+
+	// File is the output path of the file exporter. Spans are appended as
+	// one JSON document per span.
+	File string `yaml:"file" json:"file" toml:"file"`
+
+	// SampleRatio is the fraction of new traces that are sampled (default: 1).
+	// Spans with a sampled remote parent are always sampled.
+	SampleRatio *float64 `yaml:"sampleRatio" json:"sampleRatio" toml:"sampleRatio"`
+}
+
+// Normalize fills default values.
+func (c *Config) Normalize() {
+	if c.Exporter == "" {
+		c.Exporter = ExporterStdout
+	}
+	if c.SampleRatio == nil {
+		ratio := 1.0
+		c.SampleRatio = &ratio
+	}
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	if strings.TrimSpace(c.ServiceName) == "" {
+		return errors.New("tracing: serviceName is required")
+	}
+	switch c.Exporter {
+	case "", ExporterStdout, ExporterNone:
+	case ExporterFile:
+		if strings.TrimSpace(c.File) == "" {
+			return errors.New("tracing: file is required by the file exporter")
+		}
+	default:
+		return fmt.Errorf("tracing: unknown exporter %q", c.Exporter)
+	}
+	if c.SampleRatio != nil && (*c.SampleRatio < 0 || *c.SampleRatio > 1) {
+		return errors.New("tracing: sampleRatio must be between 0 and 1")
+	}
+	return nil
+}
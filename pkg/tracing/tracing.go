@@ -0,0 +1,113 @@
+// Package tracing sets up OpenTelemetry tracing and links spans to the
+// request-scoped xlog logger.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Provider owns a tracer provider and the exporter behind it.
+type Provider struct {
+	tp         *sdktrace.TracerProvider
+	propagator propagation.TextMapPropagator
+	closer     io.Closer
+}
+
+// MustNew creates a new Provider and panics if initialization fails.
+func MustNew(cfg *Config) *Provider {
+	p, err := New(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// New creates a Provider from cfg. Spans are propagated with the W3C trace
+// context and baggage formats.
+func New(cfg *Config) (*Provider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg.Normalize()
+
+	p := &Provider{
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*cfg.SampleRatio))),
+	}
+
+	var out io.Writer
+	switch cfg.Exporter {
+	case ExporterStdout:
+		out = os.Stdout
+	case ExporterFile:
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		out, p.closer = f, f
+	}
+	if out != nil {
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(out))
+		if err != nil {
+			if p.closer != nil {
+				_ = p.closer.Close()
+			}
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	p.tp = sdktrace.NewTracerProvider(opts...)
+	return p, nil
+}
+
+// TracerProvider returns the tracer provider used to create spans.
+func (p *Provider) TracerProvider() trace.TracerProvider {
+	return p.tp
+}
+
+// Propagator returns the propagator used to carry spans across processes.
+func (p *Provider) Propagator() propagation.TextMapPropagator {
+	return p.propagator
+}
+
+// Shutdown flushes pending spans and releases the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	err := p.tp.Shutdown(ctx)
+	if p.closer != nil {
+		err = errors.Join(err, p.closer.Close())
+	}
+	return err
+}
+
+// Close flushes pending spans with a background context. It is suitable for
+// store.WithClose.
+func (p *Provider) Close() error {
+	return p.Shutdown(context.Background())
+}
+
+// InjectLogger adds the trace and span IDs of the span in ctx to the xlog
+// logger in ctx, so that every log line of the request can be correlated
+// with its trace. It returns ctx unchanged when ctx has no valid span.
+func InjectLogger(ctx context.Context) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ctx
+	}
+	log := xlog.Get(ctx).With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	return xlog.Put(ctx, log)
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HorseArcher567/octopus/pkg/xlog"
+)
+
+func TestConfigValidate(t *testing.T) {
+	ratio := 2.0
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"missing service name", Config{}, "serviceName is required"},
+		{"unknown exporter", Config{ServiceName: "demo", Exporter: "zipkin"}, "unknown exporter"},
+		{"file without path", Config{ServiceName: "demo", Exporter: ExporterFile}, "file is required"},
+		{"ratio out of range", Config{ServiceName: "demo", SampleRatio: &ratio}, "sampleRatio"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileExporterWritesSpans(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	p, err := New(&Config{ServiceName: "demo", Exporter: ExporterFile, File: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, span := p.TracerProvider().Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{`"Name":"work"`, `"Value":"demo"`} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("exported spans missing %s:\n%s", want, data)
+		}
+	}
+}
+
+func TestInjectLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base := &xlog.Logger{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+	ctx := xlog.Put(context.Background(), base)
+
+	if got := InjectLogger(ctx); got != ctx {
+		t.Fatalf("InjectLogger() without span should return ctx unchanged")
+	}
+
+	p := MustNew(&Config{ServiceName: "demo", Exporter: ExporterNone})
+	defer p.Close()
+	ctx, span := p.TracerProvider().Tracer("test").Start(ctx, "work")
+	defer span.End()
+
+	xlog.Get(InjectLogger(ctx)).Info("hello")
+	sc := span.SpanContext()
+	for _, want := range []string{"trace_id=" + sc.TraceID().String(), "span_id=" + sc.SpanID().String()} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("log output missing %s: %s", want, buf.String())
+		}
+	}
+}
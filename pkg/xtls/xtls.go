@@ -0,0 +1,292 @@
+// Package xtls builds server and client TLS configurations from certificate
+// files and reloads them when the files are rotated on disk.
+package xtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultReloadInterval = time.Minute
+
+// Client authentication modes accepted by Config.ClientAuth.
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequire          = "require"
+	ClientAuthVerifyIfGiven    = "verify-if-given"
+	ClientAuthRequireAndVerify = "require-and-verify"
+)
+
+// Config configures transport security for a server or client.
+//
+// Certificate, key and CA files are re-read when they change on disk, so
+// rotated certificates are picked up without a restart.
+//
+// Example:
+//
+//	tls:
+//	  certFile: /etc/tls/tls.crt
+//	  keyFile: /etc/tls/tls.key
+//	  caFile: /etc/tls/ca.crt
+//	  clientAuth: require-and-verify
+type Config struct {
+	// CertFile and KeyFile are the PEM-encoded certificate chain and private
+	// key presented to the peer. Required on the server; on the client they
+	// enable mutual TLS.
+	CertFile string `yaml:"certFile" json:"certFile" toml:"certFile"`
+	KeyFile  string `yaml:"keyFile" json:"keyFile" toml:"keyFile"`
+
+	// CAFile is the PEM-encoded CA bundle used to verify the peer. On the
+	// client it defaults to the system roots.
+	CAFile string `yaml:"caFile" json:"caFile" toml:"caFile"`
+
+	// ClientAuth is the server's client certificate policy: none, request,
+	// require, verify-if-given or require-and-verify. It defaults to
+	// require-and-verify when CAFile is set and none otherwise.
+	ClientAuth string `yaml:"clientAuth" json:"clientAuth" toml:"clientAuth"`
+
+	// ServerName overrides the name used by the client to verify the server
+	// certificate. It defaults to the host of the dial target.
+	ServerName string `yaml:"serverName" json:"serverName" toml:"serverName"`
+
+	// MinVersion is the minimum TLS version: 1.2 (default) or 1.3.
+	MinVersion string `yaml:"minVersion" json:"minVersion" toml:"minVersion"`
+
+	// ReloadInterval bounds how often the files are checked for changes
+	// (default: 1m).
+	ReloadInterval time.Duration `yaml:"reloadInterval" json:"reloadInterval" toml:"reloadInterval"`
+}
+
+// Validate validates the configuration.
+func (c *Config) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("xtls: certFile and keyFile must be set together")
+	}
+	if _, err := c.minVersion(); err != nil {
+		return err
+	}
+	if _, err := c.clientAuth(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ServerTLSConfig builds a server TLS configuration that advertises
+// nextProtos through ALPN. The key pair and client CA bundle are reloaded for
+// new connections when the files change.
+func (c *Config) ServerTLSConfig(nextProtos ...string) (*tls.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.CertFile == "" {
+		return nil, errors.New("xtls: certFile and keyFile are required by the server")
+	}
+	clientAuth, _ := c.clientAuth()
+	if clientAuth >= tls.VerifyClientCertIfGiven && c.CAFile == "" {
+		return nil, fmt.Errorf("xtls: caFile is required by clientAuth %q", c.ClientAuth)
+	}
+	minVersion, _ := c.minVersion()
+	files, err := newFiles(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion: minVersion,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := files.current()
+			return &tls.Config{
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				ClientAuth:   clientAuth,
+				MinVersion:   minVersion,
+				NextProtos:   nextProtos,
+			}, nil
+		},
+	}, nil
+}
+
+// Client builds the TLS configurations of client connections. The client key
+// pair and CA bundle are reloaded for new connections when the files change.
+type Client struct {
+	files      *files
+	serverName string
+	minVersion uint16
+}
+
+// NewClient creates the Client of c.
+func (c *Config) NewClient() (*Client, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	minVersion, _ := c.minVersion()
+	files, err := newFiles(c)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{files: files, serverName: c.ServerName, minVersion: minVersion}, nil
+}
+
+// Config returns the TLS configuration of a new connection to serverName,
+// which Config.ServerName overrides. An empty name is left for the dialer to
+// fill in with the host it dials, as net/http and grpc do. The server
+// certificate is verified by crypto/tls against the current CA bundle, or
+// the system roots without caFile, and the server name.
+func (cl *Client) Config(serverName string) *tls.Config {
+	if cl.serverName != "" {
+		serverName = cl.serverName
+	}
+	cert, pool := cl.files.current()
+	cfg := &tls.Config{
+		ServerName: serverName,
+		RootCAs:    pool,
+		MinVersion: cl.minVersion,
+	}
+	if cert != nil {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert, nil
+		}
+	}
+	return cfg
+}
+
+// ClientTLSConfig builds a client TLS configuration with the files as they
+// are now. Clients that keep dialing new connections should use NewClient
+// instead, so that rotated files apply to them.
+func (c *Config) ClientTLSConfig() (*tls.Config, error) {
+	cl, err := c.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return cl.Config(""), nil
+}
+
+func (c *Config) minVersion() (uint16, error) {
+	switch c.MinVersion {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("xtls: unsupported minVersion %q", c.MinVersion)
+	}
+}
+
+func (c *Config) clientAuth() (tls.ClientAuthType, error) {
+	switch strings.ToLower(c.ClientAuth) {
+	case "":
+		if c.CAFile != "" {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthRequire:
+		return tls.RequireAnyClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("xtls: unsupported clientAuth %q", c.ClientAuth)
+	}
+}
+
+// files holds the key pair and CA bundle loaded from disk and reloads
+// them when the files change.
+type files struct {
+	certFile, keyFile, caFile string
+	interval                  time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+	modTimes  [3]time.Time
+	lastCheck time.Time
+}
+
+func newFiles(c *Config) (*files, error) {
+	f := &files{
+		certFile: c.CertFile,
+		keyFile:  c.KeyFile,
+		caFile:   c.CAFile,
+		interval: c.ReloadInterval,
+	}
+	if f.interval <= 0 {
+		f.interval = defaultReloadInterval
+	}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// current returns the key pair and CA pool, reloading them first if the files
+// changed since the last check. If a reload fails, the previously loaded
+// files stay in use so that a half-written rotation does not break serving.
+func (f *files) current() (*tls.Certificate, *x509.CertPool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.lastCheck) >= f.interval {
+		if f.modTimes != f.stat() {
+			_ = f.loadLocked()
+		}
+		f.lastCheck = time.Now()
+	}
+	return f.cert, f.pool
+}
+
+func (f *files) load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastCheck = time.Now()
+	return f.loadLocked()
+}
+
+func (f *files) loadLocked() error {
+	modTimes := f.stat()
+	var cert *tls.Certificate
+	if f.certFile != "" {
+		pair, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+		if err != nil {
+			return fmt.Errorf("xtls: load key pair: %w", err)
+		}
+		cert = &pair
+	}
+	var pool *x509.CertPool
+	if f.caFile != "" {
+		pem, err := os.ReadFile(f.caFile)
+		if err != nil {
+			return fmt.Errorf("xtls: read ca file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("xtls: ca file %q contains no certificates", f.caFile)
+		}
+	}
+	f.cert, f.pool, f.modTimes = cert, pool, modTimes
+	return nil
+}
+
+func (f *files) stat() [3]time.Time {
+	var times [3]time.Time
+	for i, name := range []string{f.certFile, f.keyFile, f.caFile} {
+		if name == "" {
+			continue
+		}
+		if info, err := os.Stat(name); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+	return times
+}
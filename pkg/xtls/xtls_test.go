@@ -0,0 +1,142 @@
+package xtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/HorseArcher567/octopus/pkg/xtls/xtlstest"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []Config{
+		{CertFile: "a.crt"},
+		{MinVersion: "1.1"},
+		{ClientAuth: "sometimes"},
+	}
+	for _, cfg := range tests {
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) expected error", cfg)
+		}
+	}
+	if _, err := (&Config{}).ServerTLSConfig(); err == nil {
+		t.Fatalf("ServerTLSConfig() expected error without key pair")
+	}
+}
+
+func TestServerTLSConfigRequiresCAForVerification(t *testing.T) {
+	dir := t.TempDir()
+	ca := xtlstest.NewCA(t)
+	certFile, keyFile := ca.Issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+
+	cfg := &Config{CertFile: certFile, KeyFile: keyFile, ClientAuth: ClientAuthRequireAndVerify}
+	if _, err := cfg.ServerTLSConfig(); err == nil {
+		t.Fatalf("ServerTLSConfig() expected error without caFile")
+	}
+	cfg.CAFile = ca.WriteCA(t, dir)
+	tlsCfg, err := cfg.ServerTLSConfig("h2", "http/1.1")
+	if err != nil {
+		t.Fatalf("ServerTLSConfig() error = %v", err)
+	}
+	conn, err := tlsCfg.GetConfigForClient(nil)
+	if err != nil {
+		t.Fatalf("GetConfigForClient() error = %v", err)
+	}
+	if len(conn.NextProtos) != 2 || conn.NextProtos[0] != "h2" || conn.ClientCAs == nil {
+		t.Fatalf("connection config = %+v", conn)
+	}
+}
+
+func TestFilesReloadOnChange(t *testing.T) {
+	dir := t.TempDir()
+	ca := xtlstest.NewCA(t)
+	certFile, keyFile := ca.Issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+
+	files, err := newFiles(&Config{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("newFiles() error = %v", err)
+	}
+	first, _ := files.current()
+
+	// Rotate the key pair and make sure the modification time moves.
+	rotatedCert, rotatedKey := ca.Issue(t, t.TempDir(), "server", x509.ExtKeyUsageServerAuth)
+	for _, f := range [][2]string{{rotatedCert, certFile}, {rotatedKey, keyFile}} {
+		data, _ := os.ReadFile(f[0])
+		xtlstest.WriteFile(t, f[1], data)
+		later := time.Now().Add(time.Minute)
+		_ = os.Chtimes(f[1], later, later)
+	}
+	second, _ := files.current()
+	if string(first.Certificate[0]) == string(second.Certificate[0]) {
+		t.Fatalf("certificate was not reloaded after rotation")
+	}
+
+	// A broken rotation keeps the last good key pair.
+	xtlstest.WriteFile(t, certFile, []byte("garbage"))
+	later := time.Now().Add(2 * time.Minute)
+	_ = os.Chtimes(certFile, later, later)
+	third, _ := files.current()
+	if third != second {
+		t.Fatalf("broken rotation should keep the previous certificate")
+	}
+}
+
+func TestClientVerifiesServerName(t *testing.T) {
+	dir := t.TempDir()
+	ca := xtlstest.NewCA(t)
+	caFile := ca.WriteCA(t, dir)
+	certFile, keyFile := ca.Issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+
+	serverCfg, err := (&Config{CertFile: certFile, KeyFile: keyFile, ClientAuth: ClientAuthNone}).ServerTLSConfig()
+	if err != nil {
+		t.Fatalf("ServerTLSConfig() error = %v", err)
+	}
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	client, err := (&Config{CAFile: caFile}).NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	handshake := func(cfg *tls.Config) error {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), cfg)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	// The certificate is valid for localhost only, and dialing an IP address
+	// sends no SNI: the name must still be verified.
+	if err := handshake(client.Config("localhost")); err != nil {
+		t.Fatalf("handshake with localhost error = %v", err)
+	}
+	if err := handshake(client.Config("127.0.0.1")); err == nil {
+		t.Fatalf("handshake with 127.0.0.1 should fail for a localhost certificate")
+	}
+	if err := handshake(client.Config("")); err == nil {
+		t.Fatalf("handshake with the dialed address should fail for a localhost certificate")
+	}
+	legacy, err := (&Config{CAFile: caFile}).ClientTLSConfig()
+	if err != nil {
+		t.Fatalf("ClientTLSConfig() error = %v", err)
+	}
+	if err := handshake(legacy); err == nil {
+		t.Fatalf("ClientTLSConfig() handshake should fail for a localhost certificate")
+	}
+}